@@ -2,21 +2,49 @@ package cli
 
 import (
 	"fmt"
+	"strings"
 
 	"github.com/spf13/cobra"
 
+	"github.com/terminally-online/shrugged/internal/diff"
 	"github.com/terminally-online/shrugged/internal/migrate"
 )
 
 var (
 	dryRun     bool
 	forceApply bool
+	applyTo    string
 )
 
 var applyCmd = &cobra.Command{
 	Use:   "apply",
 	Short: "Apply pending migrations to the database",
-	Long:  `Apply all pending migrations to the database in order. Use --dry-run to preview without applying.`,
+	Long: `Apply all pending migrations to the database in order. Use --dry-run to preview without applying.
+
+Use --to to apply pending migrations only up to and including the named one,
+given by file name or version. Every migration it would apply must have a
+.down.sql file, so the database can be moved back with rollback --to.
+
+The whole run holds a Postgres advisory lock, so concurrent apply or rollback
+runs against the same database wait for each other instead of racing.
+
+Pending migrations that sort before the latest applied migration, such as one
+merged from an older branch, are out of order. --out-of-order decides whether
+apply refuses them (error), warns about them (warn, the default) or applies
+them silently (allow).
+
+Migrations generated by migrate record the hazards of their changes, such as a
+table rewrite or a full-table scan, in shrugged:hazard comments, which apply
+lists. apply refuses migrations with hazards not listed in --allow-hazards, so
+a hand-written or edited migration cannot slip one through; --skip-hazard-check
+turns the check off.
+
+Use --lock-timeout so a statement waiting on a table lock held by a long-running
+transaction gives up instead of blocking all traffic on that table, and
+--lock-retries to retry the migration with backoff when that happens.
+
+A migration written by squash is recorded as applied without running it when
+the database already applied the migrations it replaces.`,
 	RunE: func(cmd *cobra.Command, args []string) error {
 		ctx := cmd.Context()
 
@@ -26,6 +54,11 @@ var applyCmd = &cobra.Command{
 		}
 		migrationsDir := cfg.GetMigrationsDir(&flags)
 
+		outOfOrderPolicy, err := migrate.ParseOutOfOrderPolicy(cfg.GetOutOfOrder(&flags))
+		if err != nil {
+			return err
+		}
+
 		if err := migrate.ValidateSum(migrationsDir); err != nil {
 			if !forceApply {
 				return fmt.Errorf("sum file validation failed: %w\nUse --force to apply anyway", err)
@@ -33,7 +66,18 @@ var applyCmd = &cobra.Command{
 			fmt.Printf("⚠ WARNING: %v\n", err)
 		}
 
-		modified, err := migrate.HasModifiedMigrations(ctx, dbURL, migrationsDir)
+		conn, release, err := connectLocked(ctx, dbURL)
+		if err != nil {
+			return err
+		}
+		defer release()
+
+		squashed, err := migrate.GetSquashed(ctx, conn, migrationsDir)
+		if err != nil {
+			return fmt.Errorf("failed to get squashed migrations: %w", err)
+		}
+
+		modified, err := migrate.HasModifiedMigrations(ctx, conn, migrationsDir)
 		if err != nil {
 			return fmt.Errorf("failed to check for modified migrations: %w", err)
 		}
@@ -49,19 +93,77 @@ var applyCmd = &cobra.Command{
 			return fmt.Errorf("refusing to apply migrations with modified history")
 		}
 
-		pending, err := migrate.GetPending(ctx, dbURL, migrationsDir)
+		var pending []migrate.Migration
+		if applyTo != "" {
+			pending, err = migrate.GetPendingTo(ctx, conn, migrationsDir, applyTo)
+			if err == nil {
+				err = migrate.CheckDownMigrations(migrationsDir, pending)
+			}
+		} else {
+			pending, err = migrate.GetPending(ctx, conn, migrationsDir)
+		}
 		if err != nil {
 			return fmt.Errorf("failed to get pending migrations: %w", err)
 		}
 
-		if len(pending) == 0 {
+		if len(pending) == 0 && len(squashed) == 0 {
 			fmt.Println("No pending migrations.")
 			return nil
 		}
 
+		if len(squashed) > 0 {
+			fmt.Printf("Found %d squashed migration(s) to record as applied without running:\n", len(squashed))
+			for _, m := range squashed {
+				fmt.Printf("  - %s\n", m.Name)
+			}
+		}
+
 		fmt.Printf("Found %d pending migration(s):\n", len(pending))
+		var outOfOrder int
+		var hazards []diff.HazardKind
 		for _, m := range pending {
-			fmt.Printf("  - %s\n", m.Name)
+			line := "  - " + m.Name
+			if m.OutOfOrder {
+				line += " (out of order)"
+				outOfOrder++
+			}
+			if kinds := migrate.Hazards(m.Content); len(kinds) > 0 {
+				line += " ⚠ " + strings.Join(kinds, ", ")
+				for _, kind := range kinds {
+					hazards = append(hazards, diff.HazardKind(kind))
+				}
+			}
+			fmt.Println(line)
+		}
+
+		if outOfOrder > 0 && outOfOrderPolicy != migrate.OutOfOrderAllow {
+			last, err := migrate.GetLastApplied(ctx, conn)
+			if err != nil {
+				return fmt.Errorf("failed to get last applied migration: %w", err)
+			}
+			fmt.Println()
+			fmt.Printf("%d pending migration(s) sort before the latest applied migration %s.\n", outOfOrder, last.Name)
+			if outOfOrderPolicy == migrate.OutOfOrderError {
+				fmt.Println("Rename them to sort after it, or use --out-of-order=warn or --out-of-order=allow to apply them anyway.")
+				return fmt.Errorf("refusing to apply out-of-order migrations")
+			}
+			fmt.Println("⚠ WARNING: applying them out of order. Set out_of_order: allow to silence this, or out_of_order: error to refuse them.")
+		}
+
+		if !cfg.GetSkipHazardCheck(&flags) {
+			disallowed, err := disallowedHazards(hazards)
+			if err != nil {
+				return err
+			}
+			if len(disallowed) > 0 {
+				fmt.Println()
+				fmt.Println("Pending migrations have hazards when run against tables with data; see the")
+				fmt.Printf("shrugged:hazard comments in them. Rerun with %s to apply them.\n", allowHazardsFlag(disallowed))
+				fmt.Println("Use --skip-hazard-check to apply migrations without checking their hazards.")
+				if !dryRun {
+					return fmt.Errorf("refusing to apply migrations with hazards that are not allowed")
+				}
+			}
 		}
 
 		if dryRun {
@@ -69,10 +171,24 @@ var applyCmd = &cobra.Command{
 			return nil
 		}
 
+		if err := migrate.RecordSquashed(ctx, conn, squashed); err != nil {
+			return fmt.Errorf("failed to record squashed migrations: %w", err)
+		}
+		for _, m := range squashed {
+			fmt.Printf("Recorded %s as applied.\n", m.Name)
+		}
+
 		fmt.Println()
 		for _, m := range pending {
-			fmt.Printf("Applying %s... ", m.Name)
-			if err := migrate.Apply(ctx, dbURL, m); err != nil {
+			err := retryOnLockTimeout(ctx, func() error {
+				if migrate.IsNoTransaction(m.Content) {
+					fmt.Printf("Applying %s outside a transaction...\n", m.Name)
+				} else {
+					fmt.Printf("Applying %s... ", m.Name)
+				}
+				return migrate.ApplyWithProgress(ctx, conn, m, printStatementProgress)
+			})
+			if err != nil {
 				fmt.Println("FAILED")
 				return fmt.Errorf("failed to apply migration %s: %w", m.Name, err)
 			}
@@ -86,4 +202,31 @@ var applyCmd = &cobra.Command{
 func init() {
 	applyCmd.Flags().BoolVar(&dryRun, "dry-run", false, "preview migrations without applying")
 	applyCmd.Flags().BoolVar(&forceApply, "force", false, "apply even if previous migrations have been modified")
+	applyCmd.Flags().StringVar(&applyTo, "to", "", "apply pending migrations up to and including this one (file name or version)")
+	applyCmd.Flags().StringSliceVar(&flags.AllowHazards, "allow-hazards", nil, "hazards to accept in pending migrations, e.g. table-rewrite,access-exclusive")
+	applyCmd.Flags().BoolVar(&flags.SkipHazardCheck, "skip-hazard-check", false, "apply pending migrations without refusing those with hazards not listed in --allow-hazards")
+	applyCmd.Flags().StringVar(&flags.OutOfOrder, "out-of-order", "", "what to do with out-of-order migrations: error, warn or allow (default warn)")
+	applyCmd.Flags().Int64Var(&flags.LockKey, "lock-key", 0, "advisory lock key used to serialize migration runs")
+	applyCmd.Flags().DurationVar(&flags.LockWaitTimeout, "lock-wait-timeout", 0, "how long to wait for the migration lock (default 1m)")
+	applyCmd.Flags().DurationVar(&flags.LockTimeout, "lock-timeout", 0, "Postgres lock_timeout for migration statements, e.g. 5s")
+	applyCmd.Flags().DurationVar(&flags.StatementTimeout, "statement-timeout", 0, "Postgres statement_timeout for migration statements, e.g. 10m")
+	applyCmd.Flags().IntVar(&flags.LockRetries, "lock-retries", 0, "times to retry a migration after its lock_timeout fires")
+	applyCmd.Flags().DurationVar(&flags.LockRetryBackoff, "lock-retry-backoff", 0, "wait before the first lock timeout retry, doubled for each retry after (default 1s)")
+	applyCmd.Flags().StringVar(&flags.Label, "label", "", "deploy identifier recorded in the migration history")
+	applyCmd.Flags().StringVar(&flags.HistoryTable, "history-table", "", "table migrations are recorded in (default shrugged_migrations)")
+	applyCmd.Flags().StringVar(&flags.HistorySchema, "history-schema", "", "schema of the history table (default: first schema on the search path)")
+}
+
+func printStatementProgress(index, total int, statement string) {
+	var line string
+	for _, l := range strings.Split(statement, "\n") {
+		if l = strings.TrimSpace(l); l != "" && !strings.HasPrefix(l, "--") {
+			line = l
+			break
+		}
+	}
+	if len(line) > 72 {
+		line = line[:69] + "..."
+	}
+	fmt.Printf("  [%d/%d] %s\n", index, total, line)
 }
@@ -0,0 +1,178 @@
+package cli
+
+import (
+	"context"
+	"fmt"
+	"os"
+	"path/filepath"
+
+	"github.com/spf13/cobra"
+
+	"github.com/terminally-online/shrugged/internal/diff"
+	"github.com/terminally-online/shrugged/internal/docker"
+	"github.com/terminally-online/shrugged/internal/introspect"
+	"github.com/terminally-online/shrugged/internal/migrate"
+	"github.com/terminally-online/shrugged/internal/parser"
+)
+
+var baselineCmd = &cobra.Command{
+	Use:   "baseline",
+	Short: "Adopt shrugged on a database that already has a schema",
+	Long: `Introspect the live database and write its schema as the first migration,
+marking it as applied without running it.
+
+The baseline is checked before anything is written: it is applied to a
+temporary Postgres container and compared with the live database, and baseline
+refuses if the two differ. The migrations directory must not contain any
+migrations yet, and the database must not have any migration history.`,
+	RunE: func(cmd *cobra.Command, args []string) error {
+		ctx := cmd.Context()
+
+		dbURL, err := cfg.GetDatabaseURL(&flags)
+		if err != nil {
+			return err
+		}
+		migrationsDir := cfg.GetMigrationsDir(&flags)
+
+		entries, err := os.ReadDir(migrationsDir)
+		if err != nil && !os.IsNotExist(err) {
+			return fmt.Errorf("failed to read migrations directory: %w", err)
+		}
+		if countSQLFiles(entries) > 0 {
+			return fmt.Errorf("%s already contains migrations; baseline must be the first migration", migrationsDir)
+		}
+
+		conn, release, err := connectLocked(ctx, dbURL)
+		if err != nil {
+			return err
+		}
+		defer release()
+
+		fmt.Println("Introspecting live database...")
+		live, err := introspect.Database(ctx, dbURL)
+		if err != nil {
+			return fmt.Errorf("failed to introspect database: %w", err)
+		}
+		withoutHistory(live)
+
+		applied, err := migrate.GetApplied(ctx, conn)
+		if err != nil {
+			return fmt.Errorf("failed to get applied migrations: %w", err)
+		}
+		if len(applied) > 0 {
+			return fmt.Errorf("database already has %d applied migration(s); baseline is for databases not yet managed by shrugged", len(applied))
+		}
+
+		baselineSQL := live.ToSQL()
+		if err := verifyBaseline(ctx, live, baselineSQL); err != nil {
+			return err
+		}
+
+		path, _, err := newMigrationFiles(migrationsDir, "baseline")
+		if err != nil {
+			return err
+		}
+		name := filepath.Base(path)
+		if err := os.WriteFile(path, []byte(baselineSQL), 0644); err != nil {
+			return fmt.Errorf("failed to write baseline migration: %w", err)
+		}
+
+		if err := migrate.UpdateSum(migrationsDir); err != nil {
+			return fmt.Errorf("failed to update sum file: %w", err)
+		}
+
+		if err := migrate.Baseline(ctx, conn, migrate.Migration{Name: name, Content: baselineSQL}); err != nil {
+			return err
+		}
+
+		fmt.Printf("\nCreated baseline: %s\n", path)
+		fmt.Println("Marked as applied without running it.")
+		return nil
+	},
+}
+
+func init() {
+	baselineCmd.Flags().StringVar(&flags.MigrationNaming, "naming", "", "how the baseline migration is versioned: timestamp or sequential (default timestamp)")
+	baselineCmd.Flags().Int64Var(&flags.LockKey, "lock-key", 0, "advisory lock key used to serialize migration runs")
+	baselineCmd.Flags().DurationVar(&flags.LockWaitTimeout, "lock-wait-timeout", 0, "how long to wait for the migration lock (default 1m)")
+	baselineCmd.Flags().StringVar(&flags.Label, "label", "", "deploy identifier recorded in the migration history")
+	baselineCmd.Flags().StringVar(&flags.HistoryTable, "history-table", "", "table migrations are recorded in (default shrugged_migrations)")
+	baselineCmd.Flags().StringVar(&flags.HistorySchema, "history-schema", "", "schema of the history table (default: first schema on the search path)")
+}
+
+// verifyBaseline applies baselineSQL to a temporary container and checks that
+// it reproduces the live schema.
+func verifyBaseline(ctx context.Context, live *parser.Schema, baselineSQL string) error {
+	dockerCfg := docker.PostgresConfig{
+		Version:  cfg.GetPostgresVersion(&flags),
+		User:     "shrugged",
+		Password: "shrugged",
+		Database: "shrugged",
+	}
+
+	fmt.Println("Starting Postgres container to verify baseline...")
+	container, err := docker.StartPostgres(ctx, dockerCfg)
+	if err != nil {
+		return fmt.Errorf("failed to start postgres: %w", err)
+	}
+	defer func() {
+		fmt.Println("Stopping container...")
+		_ = docker.StopContainer(context.Background(), container.ID)
+	}()
+
+	conn, err := migrate.Connect(ctx, container.ConnectionString())
+	if err != nil {
+		return err
+	}
+	defer func() { _ = conn.Close(context.Background()) }()
+
+	if err := migrate.Execute(ctx, conn, "baseline", baselineSQL); err != nil {
+		return fmt.Errorf("failed to apply baseline: %w", err)
+	}
+
+	fmt.Println("Introspecting baseline...")
+	rebuilt, err := introspect.Database(ctx, container.ConnectionString())
+	if err != nil {
+		return fmt.Errorf("failed to introspect baseline: %w", err)
+	}
+
+	changes := diff.Compare(rebuilt, live)
+	if len(changes) == 0 {
+		return nil
+	}
+
+	fmt.Printf("\nThe baseline does not reproduce the live schema; %d change(s) would still be needed:\n\n", len(changes))
+	for _, change := range changes {
+		fmt.Println(change.SQL())
+		fmt.Println()
+	}
+	return fmt.Errorf("refusing to write a baseline that does not match the live schema")
+}
+
+// withoutHistory removes the history and progress tables from an introspected
+// schema, so they are not part of a baseline.
+func withoutHistory(schema *parser.Schema) {
+	var tables []parser.Table
+	for _, t := range schema.Tables {
+		if !migrate.IsHistoryRelation(t.Schema, t.Name) {
+			tables = append(tables, t)
+		}
+	}
+	schema.Tables = tables
+
+	var sequences []parser.Sequence
+	for _, s := range schema.Sequences {
+		if !migrate.IsHistoryRelation(s.Schema, s.Name) {
+			sequences = append(sequences, s)
+		}
+	}
+	schema.Sequences = sequences
+
+	var indexes []parser.Index
+	for _, idx := range schema.Indexes {
+		if !migrate.IsHistoryRelation(idx.Schema, idx.Table) {
+			indexes = append(indexes, idx)
+		}
+	}
+	schema.Indexes = indexes
+}
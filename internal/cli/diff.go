@@ -1,16 +1,20 @@
 package cli
 
 import (
+	"bufio"
 	"context"
 	"fmt"
 	"os"
 	"path/filepath"
+	"sort"
+	"strings"
 
 	"github.com/spf13/cobra"
 
 	"github.com/terminally-online/shrugged/internal/diff"
 	"github.com/terminally-online/shrugged/internal/docker"
 	"github.com/terminally-online/shrugged/internal/introspect"
+	"github.com/terminally-online/shrugged/internal/migrate"
 	"github.com/terminally-online/shrugged/internal/parser"
 )
 
@@ -20,7 +24,8 @@ var diffCmd = &cobra.Command{
 	Long: `Compare the declarative schema file against the result of applying all migrations.
 
 This spins up a temporary Postgres container, applies all migrations to get the
-"current" state, then compares against the desired schema file.`,
+"current" state, then compares against the desired schema file. Hazards of
+each change, such as a table rewrite, are shown as comments above it.`,
 	RunE: func(cmd *cobra.Command, args []string) error {
 		ctx := cmd.Context()
 
@@ -71,7 +76,9 @@ This spins up a temporary Postgres container, applies all migrations to get the
 			return fmt.Errorf("failed to introspect desired state: %w", err)
 		}
 
-		changes := diff.Compare(currentSchema, desiredSchema)
+		opts := diffOptions()
+		opts.Renames = resolveRenames(currentSchema, desiredSchema, schemaSQL, false)
+		changes := diff.CompareWithOptions(currentSchema, desiredSchema, opts)
 
 		if len(changes) == 0 {
 			fmt.Println("\nNo changes detected. Schema is in sync with migrations.")
@@ -79,16 +86,101 @@ This spins up a temporary Postgres container, applies all migrations to get the
 		}
 
 		fmt.Printf("\nFound %d change(s):\n\n", len(changes))
-		for _, change := range changes {
-			fmt.Println(change.SQL())
-			fmt.Println()
+		hazards := diff.HazardsFor(changes)
+		runs := diff.SplitByTransaction(changes)
+		offset := 0
+		for i, run := range runs {
+			if len(runs) > 1 {
+				fmt.Printf("-- migration %d of %d\n", i+1, len(runs))
+			}
+			if diff.NeedsNoTransaction(run) {
+				fmt.Println(migrate.NoTransactionDirective)
+				fmt.Println()
+			}
+			for j, change := range run {
+				fmt.Print(hazardComments(hazards[offset+j]))
+				fmt.Println(change.SQL())
+				fmt.Println()
+			}
+			offset += len(run)
+		}
+
+		if kinds := hazardKinds(hazards); len(kinds) > 0 {
+			disallowed, err := disallowedHazards(kinds)
+			if err != nil {
+				return err
+			}
+			fmt.Printf("⚠ %d hazard(s) found when run against tables with data.\n", len(kinds))
+			if len(disallowed) > 0 {
+				fmt.Printf("migrate will need %s to write this migration.\n", allowHazardsFlag(disallowed))
+			}
 		}
 
 		return nil
 	},
 }
 
+func init() {
+	diffCmd.Flags().BoolVar(&flags.ConcurrentIndexes, "concurrent-indexes", false, "build and drop indexes with CONCURRENTLY")
+	diffCmd.Flags().BoolVar(&flags.Online, "online", false, "split SET NOT NULL, foreign keys and unique constraints into steps that avoid long locks")
+	diffCmd.Flags().StringSliceVar(&flags.AllowHazards, "allow-hazards", nil, "hazards migrate would accept, e.g. table-rewrite,access-exclusive")
+}
+
+func diffOptions() diff.Options {
+	return diff.Options{
+		ConcurrentIndexes: cfg.GetConcurrentIndexes(&flags),
+		Online:            cfg.GetOnline(&flags),
+	}
+}
+
+// resolveRenames combines the renamed-from annotations in the schema file with
+// any detected renames the user confirms. Prompts are only shown when asked
+// for and stdin is a terminal; otherwise unconfirmed renames stay a drop and
+// an add.
+func resolveRenames(current, desired *parser.Schema, schemaSQL string, interactive bool) []parser.Rename {
+	renames := parser.ParseRenames(schemaSQL)
+
+	if !interactive || !isTerminal(os.Stdin) {
+		return renames
+	}
+
+	reader := bufio.NewReader(os.Stdin)
+	for _, candidate := range diff.DetectRenames(current, desired, renames) {
+		var question string
+		if candidate.Enum != "" {
+			question = fmt.Sprintf("Was value %s of enum %s renamed to %s?", candidate.From, candidate.Enum, candidate.Value)
+		} else if candidate.Column != "" {
+			question = fmt.Sprintf("Was column %s.%s renamed to %s?", candidate.Table, candidate.From, candidate.Column)
+		} else {
+			question = fmt.Sprintf("Was table %s renamed to %s?", candidate.From, candidate.Table)
+		}
+		fmt.Printf("\n%s [y/N] ", question)
+
+		answer, _ := reader.ReadString('\n')
+		answer = strings.ToLower(strings.TrimSpace(answer))
+		if answer == "y" || answer == "yes" {
+			renames = append(renames, candidate)
+		}
+	}
+
+	return renames
+}
+
+func isTerminal(f *os.File) bool {
+	info, err := f.Stat()
+	if err != nil {
+		return false
+	}
+	return info.Mode()&os.ModeCharDevice != 0
+}
+
 func buildCurrentState(ctx context.Context, container *docker.Container, migrationsDir string) (*parser.Schema, error) {
+	return buildState(ctx, container, migrationsDir, nil)
+}
+
+// buildState applies the up migrations in migrationsDir for which include
+// returns true, or all of them when include is nil, and introspects the result.
+func buildState(ctx context.Context, container *docker.Container, migrationsDir string, include func(name string) bool) (*parser.Schema, error) {
 	entries, err := os.ReadDir(migrationsDir)
 	if err != nil {
 		if os.IsNotExist(err) {
@@ -98,13 +190,28 @@ func buildCurrentState(ctx context.Context, container *docker.Container, migrati
 		return nil, fmt.Errorf("failed to read migrations directory: %w", err)
 	}
 
+	var included []os.DirEntry
+	for _, entry := range entries {
+		if include == nil || include(entry.Name()) {
+			included = append(included, entry)
+		}
+	}
+	entries = included
+	sortMigrationEntries(entries)
+
 	sqlCount := countSQLFiles(entries)
 	if sqlCount > 0 {
 		fmt.Printf("Applying %d migration(s)...\n", sqlCount)
 	}
 
+	conn, err := migrate.Connect(ctx, container.ConnectionString())
+	if err != nil {
+		return nil, err
+	}
+	defer func() { _ = conn.Close(context.Background()) }()
+
 	for _, entry := range entries {
-		if entry.IsDir() || filepath.Ext(entry.Name()) != ".sql" {
+		if !isUpMigration(entry) {
 			continue
 		}
 
@@ -114,7 +221,7 @@ func buildCurrentState(ctx context.Context, container *docker.Container, migrati
 			return nil, fmt.Errorf("failed to read migration %s: %w", entry.Name(), err)
 		}
 
-		if err := docker.ExecuteSQL(ctx, container, string(content)); err != nil {
+		if err := migrate.Execute(ctx, conn, path, string(content)); err != nil {
 			return nil, fmt.Errorf("failed to apply migration %s: %w", entry.Name(), err)
 		}
 	}
@@ -126,9 +233,21 @@ func buildCurrentState(ctx context.Context, container *docker.Container, migrati
 func countSQLFiles(entries []os.DirEntry) int {
 	count := 0
 	for _, e := range entries {
-		if !e.IsDir() && filepath.Ext(e.Name()) == ".sql" {
+		if isUpMigration(e) {
 			count++
 		}
 	}
 	return count
 }
+
+// sortMigrationEntries puts migration files in the order they are applied,
+// which for sequential versions differs from the file name order.
+func sortMigrationEntries(entries []os.DirEntry) {
+	sort.SliceStable(entries, func(i, j int) bool {
+		return migrate.CompareNames(entries[i].Name(), entries[j].Name()) < 0
+	})
+}
+
+func isUpMigration(e os.DirEntry) bool {
+	return !e.IsDir() && filepath.Ext(e.Name()) == ".sql" && !strings.HasSuffix(e.Name(), ".down.sql")
+}
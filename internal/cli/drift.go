@@ -0,0 +1,169 @@
+package cli
+
+import (
+	"context"
+	"fmt"
+	"os"
+	"path/filepath"
+
+	"github.com/jackc/pgx/v5"
+	"github.com/spf13/cobra"
+
+	"github.com/terminally-online/shrugged/internal/diff"
+	"github.com/terminally-online/shrugged/internal/docker"
+	"github.com/terminally-online/shrugged/internal/introspect"
+	"github.com/terminally-online/shrugged/internal/migrate"
+)
+
+var writeDriftMigration bool
+
+var driftCmd = &cobra.Command{
+	Use:   "drift",
+	Short: "Detect changes made to a live database outside of migrations",
+	Long: `Compare the live database with the schema its applied migrations produce.
+
+This spins up a temporary Postgres container, applies the migrations recorded
+as applied in the live database, then compares the result with the live schema.
+Any difference was made by hand, and drift exits with a non-zero status so it
+can run from cron or CI.
+
+Use --write-migration to capture the drift as a new migration that is recorded
+as applied in the live database without running it. Its .down.sql undoes the
+manual changes, so rolling it back returns the database to what the migrations
+describe. Like migrate, it refuses to write a migration with hazards not listed
+in --allow-hazards.`,
+	RunE: func(cmd *cobra.Command, args []string) error {
+		ctx := cmd.Context()
+
+		dbURL, err := cfg.GetDatabaseURL(&flags)
+		if err != nil {
+			return err
+		}
+		migrationsDir := cfg.GetMigrationsDir(&flags)
+
+		var conn *pgx.Conn
+		if writeDriftMigration {
+			locked, release, err := connectLocked(ctx, dbURL)
+			if err != nil {
+				return err
+			}
+			defer release()
+			conn = locked
+		} else {
+			configureHistory()
+			conn, err = migrate.Connect(ctx, dbURL)
+			if err != nil {
+				return err
+			}
+			defer func() { _ = conn.Close(context.Background()) }()
+		}
+
+		pending, err := migrate.GetPending(ctx, conn, migrationsDir)
+		if err != nil {
+			return fmt.Errorf("failed to get pending migrations: %w", err)
+		}
+		pendingNames := make(map[string]bool)
+		for _, m := range pending {
+			pendingNames[m.Name] = true
+		}
+
+		fmt.Println("Introspecting live database...")
+		live, err := introspect.Database(ctx, dbURL)
+		if err != nil {
+			return fmt.Errorf("failed to introspect database: %w", err)
+		}
+		withoutHistory(live)
+
+		dockerCfg := docker.PostgresConfig{
+			Version:  cfg.GetPostgresVersion(&flags),
+			User:     "shrugged",
+			Password: "shrugged",
+			Database: "shrugged",
+		}
+
+		fmt.Println("Starting Postgres container...")
+		container, err := docker.StartPostgres(ctx, dockerCfg)
+		if err != nil {
+			return fmt.Errorf("failed to start postgres: %w", err)
+		}
+		defer func() {
+			fmt.Println("Stopping container...")
+			_ = docker.StopContainer(context.Background(), container.ID)
+		}()
+
+		// A squashed migration stands in for the applied migrations it replaces.
+		expected, err := buildState(ctx, container, migrationsDir, func(name string) bool {
+			return !pendingNames[name]
+		})
+		if err != nil {
+			return err
+		}
+
+		if len(pending) > 0 {
+			fmt.Printf("Ignoring %d pending migration(s) not yet applied to the live database.\n", len(pending))
+		}
+
+		changes := diff.CompareWithOptions(expected, live, diffOptions())
+		if len(changes) == 0 {
+			fmt.Println("\nNo drift detected. The live database matches its migrations.")
+			return nil
+		}
+
+		fmt.Printf("\nDrift detected: %d change(s) were made outside of migrations:\n\n", len(changes))
+		for _, change := range changes {
+			fmt.Printf("-- %s\n%s\n\n", change.ObjectName(), change.SQL())
+		}
+
+		if !writeDriftMigration {
+			return fmt.Errorf("live database has drifted from its migrations")
+		}
+
+		hazards := diff.HazardsFor(changes)
+		disallowed, err := disallowedHazards(hazardKinds(hazards))
+		if err != nil {
+			return err
+		}
+		if len(disallowed) > 0 {
+			fmt.Println("The changes have hazards when run against tables with data:")
+			printHazards(changes, hazards)
+			fmt.Printf("\nReview them, then rerun with %s to write the migration.\n", allowHazardsFlag(disallowed))
+			return fmt.Errorf("refusing to write a migration with hazards that are not allowed")
+		}
+
+		upFilenames, downFilenames, hasIrreversible, err := writeMigration(migrationsDir, "drift", changes)
+		if err != nil {
+			return err
+		}
+
+		for i, upFilename := range upFilenames {
+			content, err := os.ReadFile(upFilename)
+			if err != nil {
+				return fmt.Errorf("failed to read migration %s: %w", upFilename, err)
+			}
+			if err := migrate.Baseline(ctx, conn, migrate.Migration{Name: filepath.Base(upFilename), Content: string(content)}); err != nil {
+				return err
+			}
+
+			fmt.Printf("Created migration: %s\n", upFilename)
+			fmt.Printf("Created rollback:  %s\n", downFilenames[i])
+		}
+		fmt.Println("Recorded as applied in the live database. Update the schema file to match,")
+		fmt.Println("or roll the migration back to undo the manual changes.")
+		if hasIrreversible {
+			fmt.Println("\n⚠ WARNING: Some changes are not fully reversible. Review the down migration carefully.")
+		}
+		return nil
+	},
+}
+
+func init() {
+	driftCmd.Flags().BoolVar(&writeDriftMigration, "write-migration", false, "capture the drift as a migration recorded as already applied")
+	driftCmd.Flags().BoolVar(&flags.ConcurrentIndexes, "concurrent-indexes", false, "build and drop indexes with CONCURRENTLY in the written migration")
+	driftCmd.Flags().StringSliceVar(&flags.AllowHazards, "allow-hazards", nil, "hazards to accept in the written migration, e.g. table-rewrite,access-exclusive")
+	driftCmd.Flags().StringVar(&flags.MigrationNaming, "naming", "", "how the written migration is versioned: timestamp or sequential (default timestamp)")
+	driftCmd.Flags().Int64Var(&flags.LockKey, "lock-key", 0, "advisory lock key used to serialize migration runs")
+	driftCmd.Flags().DurationVar(&flags.LockWaitTimeout, "lock-wait-timeout", 0, "how long to wait for the migration lock (default 1m)")
+	driftCmd.Flags().StringVar(&flags.Label, "label", "", "deploy identifier recorded in the migration history")
+	driftCmd.Flags().StringVar(&flags.HistoryTable, "history-table", "", "table migrations are recorded in (default shrugged_migrations)")
+	driftCmd.Flags().StringVar(&flags.HistorySchema, "history-schema", "", "schema of the history table (default: first schema on the search path)")
+}
@@ -0,0 +1,63 @@
+package cli
+
+import (
+	"fmt"
+	"strings"
+
+	"github.com/terminally-online/shrugged/internal/diff"
+	"github.com/terminally-online/shrugged/internal/migrate"
+)
+
+func hazardComments(hazards []diff.Hazard) string {
+	var sb strings.Builder
+	for _, h := range hazards {
+		sb.WriteString(fmt.Sprintf("%s %s\n", migrate.HazardDirective, h))
+	}
+	return sb.String()
+}
+
+func printHazards(changes []diff.Change, hazards [][]diff.Hazard) {
+	for i, change := range changes {
+		for _, h := range hazards[i] {
+			fmt.Printf("  ⚠ %s: %s\n", change.ObjectName(), h)
+		}
+	}
+}
+
+func disallowedHazards(kinds []diff.HazardKind) ([]diff.HazardKind, error) {
+	allowed := make(map[diff.HazardKind]bool)
+	for _, s := range cfg.GetAllowHazards(&flags) {
+		kind, err := diff.ParseHazardKind(strings.TrimSpace(s))
+		if err != nil {
+			return nil, err
+		}
+		allowed[kind] = true
+	}
+
+	var disallowed []diff.HazardKind
+	for _, kind := range kinds {
+		if !allowed[kind] {
+			allowed[kind] = true
+			disallowed = append(disallowed, kind)
+		}
+	}
+	return disallowed, nil
+}
+
+func hazardKinds(hazards [][]diff.Hazard) []diff.HazardKind {
+	var kinds []diff.HazardKind
+	for _, hs := range hazards {
+		for _, h := range hs {
+			kinds = append(kinds, h.Kind)
+		}
+	}
+	return kinds
+}
+
+func allowHazardsFlag(kinds []diff.HazardKind) string {
+	var names []string
+	for _, kind := range kinds {
+		names = append(names, string(kind))
+	}
+	return "--allow-hazards=" + strings.Join(names, ",")
+}
@@ -0,0 +1,67 @@
+package cli
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"time"
+
+	"github.com/jackc/pgx/v5"
+
+	"github.com/terminally-online/shrugged/internal/migrate"
+)
+
+// The function connectLocked returns releases the lock and closes the
+// connection.
+func connectLocked(ctx context.Context, dbURL string) (*pgx.Conn, func(), error) {
+	configureHistory()
+
+	conn, err := migrate.Connect(ctx, dbURL)
+	if err != nil {
+		return nil, nil, err
+	}
+
+	opts := migrate.LockOptions{
+		Key:     cfg.GetLockKey(&flags),
+		Timeout: cfg.GetLockWaitTimeout(&flags),
+	}
+	if err := migrate.AcquireLock(ctx, conn, opts); err != nil {
+		_ = conn.Close(ctx)
+		if errors.Is(err, migrate.ErrLockHeld) {
+			return nil, nil, fmt.Errorf("another shrugged process is applying or rolling back migrations; gave up after waiting %s: %w", opts.Timeout, err)
+		}
+		return nil, nil, err
+	}
+
+	release := func() {
+		_ = migrate.ReleaseLock(context.Background(), conn, opts.Key)
+		_ = conn.Close(context.Background())
+	}
+
+	migrate.SetTimeouts(migrate.Timeouts{
+		Lock:      cfg.GetLockTimeout(&flags),
+		Statement: cfg.GetStatementTimeout(&flags),
+	})
+
+	return conn, release, nil
+}
+
+func retryOnLockTimeout(ctx context.Context, fn func() error) error {
+	opts := migrate.RetryOptions{
+		Retries: cfg.GetLockRetries(&flags),
+		Backoff: cfg.GetLockRetryBackoff(&flags),
+		OnRetry: func(retry, retries int, wait time.Duration, err error) {
+			fmt.Printf("LOCK TIMEOUT\n  retry %d/%d in %s\n", retry, retries, wait)
+		},
+	}
+	return migrate.RetryOnLockTimeout(ctx, opts, fn)
+}
+
+func configureHistory() {
+	migrate.SetHistory(migrate.History{
+		Schema:  cfg.GetHistorySchema(&flags),
+		Table:   cfg.GetHistoryTable(&flags),
+		Version: version,
+		Label:   cfg.GetLabel(&flags),
+	})
+}
@@ -5,6 +5,7 @@ import (
 	"fmt"
 	"os"
 	"path/filepath"
+	"strings"
 	"time"
 
 	"github.com/spf13/cobra"
@@ -16,18 +17,74 @@ import (
 	"github.com/terminally-online/shrugged/internal/parser"
 )
 
+var (
+	emptyMigration bool
+	rehashSum      bool
+)
+
 var migrateCmd = &cobra.Command{
-	Use:   "migrate",
+	Use:   "migrate [name]",
 	Short: "Generate a migration from schema differences",
 	Long: `Compare the schema file to the migrations and generate a new migration file.
 
+The optional name describes the migration and is added to its file name after
+the version, as in 20251216205122_add_invoice_status.sql. Versions are UTC
+timestamps by default; set migration_naming to sequential (or pass --naming
+sequential) to number migrations 0001, 0002, ... instead.
+
+Use --empty with a name to write an empty up and down migration for
+hand-written SQL, such as a data backfill, without comparing schemas. Objects
+created in hand-written migrations are part of the current state in later
+diff and migrate runs. After editing a migration by hand, run migrate --rehash
+to update shrugged.sum.
+
 This spins up a temporary Postgres container, applies all existing migrations,
-then diffs against the desired schema to produce a new migration.`,
+then diffs against the desired schema to produce a new migration.
+
+Tables and columns marked with "-- shrugged:renamed-from old_name" in the schema
+file are renamed rather than dropped and re-added. When run in a terminal, other
+likely renames are offered as a prompt.
+
+Changes that are risky on tables with data, such as a column type change that
+rewrites the table or SET NOT NULL scanning it, are listed as hazards and
+recorded as comments in the migration. migrate refuses to write it unless every
+kind of hazard is listed in --allow-hazards: data-loss, table-rewrite,
+access-exclusive, validation-scan or index-build.
+
+Use --online to avoid most validation-scan and index-build hazards: SET NOT NULL
+is checked with a NOT VALID check constraint that is validated separately,
+foreign keys are added NOT VALID and validated separately, and unique
+constraints are built with CREATE UNIQUE INDEX CONCURRENTLY. These steps, like
+the index builds of --concurrent-indexes, run outside a transaction so each one
+commits before the next. They are split into a migration of their own, given a
+sub-version such as 20251216205122.1, so the other changes keep their
+transaction.`,
+	Args: cobra.MaximumNArgs(1),
 	RunE: func(cmd *cobra.Command, args []string) error {
 		ctx := cmd.Context()
 
+		var name string
+		if len(args) > 0 {
+			name = args[0]
+		}
+
 		postgresVersion := cfg.GetPostgresVersion(&flags)
 		migrationsDir := cfg.GetMigrationsDir(&flags)
+
+		if rehashSum {
+			if err := migrate.UpdateSum(migrationsDir); err != nil {
+				return fmt.Errorf("failed to update sum file: %w", err)
+			}
+			fmt.Printf("Updated %s\n", filepath.Join(migrationsDir, migrate.SumFile))
+			return nil
+		}
+
+		if emptyMigration {
+			if migrate.Slugify(name) == "" {
+				return fmt.Errorf("--empty needs a name for the migration, e.g. shrugged migrate --empty backfill_invoice_status")
+			}
+			return scaffoldMigration(migrationsDir, name)
+		}
 		schemaFile := cfg.GetSchema(&flags)
 
 		dockerCfg := docker.PostgresConfig{
@@ -73,61 +130,179 @@ then diffs against the desired schema to produce a new migration.`,
 			return fmt.Errorf("failed to introspect desired state: %w", err)
 		}
 
-		changes := diff.Compare(currentSchema, desiredSchema)
+		opts := diffOptions()
+		opts.Renames = resolveRenames(currentSchema, desiredSchema, schemaSQL, true)
+		changes := diff.CompareWithOptions(currentSchema, desiredSchema, opts)
 
 		if len(changes) == 0 {
 			fmt.Println("\nNo changes detected. Nothing to migrate.")
 			return nil
 		}
 
-		if err := os.MkdirAll(migrationsDir, 0755); err != nil {
-			return fmt.Errorf("failed to create migrations directory: %w", err)
+		hazards := diff.HazardsFor(changes)
+		kinds := hazardKinds(hazards)
+		disallowed, err := disallowedHazards(kinds)
+		if err != nil {
+			return err
+		}
+		if len(kinds) > 0 {
+			fmt.Println("\nThe changes have hazards when run against tables with data:")
+			printHazards(changes, hazards)
+		}
+		if len(disallowed) > 0 {
+			fmt.Printf("\nReview them, then rerun with %s to write the migration.\n", allowHazardsFlag(disallowed))
+			return fmt.Errorf("refusing to write a migration with hazards that are not allowed")
 		}
 
-		timestamp := time.Now().UTC().Format("20060102150405")
-		upFilename := filepath.Join(migrationsDir, fmt.Sprintf("%s.sql", timestamp))
-		downFilename := filepath.Join(migrationsDir, fmt.Sprintf("%s.down.sql", timestamp))
-
-		upFile, err := os.Create(upFilename)
+		upFilenames, downFilenames, hasIrreversible, err := writeMigration(migrationsDir, name, changes)
 		if err != nil {
-			return fmt.Errorf("failed to create migration file: %w", err)
+			return err
+		}
+
+		fmt.Println()
+		for i := range upFilenames {
+			fmt.Printf("Created migration: %s\n", upFilenames[i])
+			fmt.Printf("Created rollback:  %s\n", downFilenames[i])
 		}
-		defer func() { _ = upFile.Close() }()
+		fmt.Printf("Contains %d change(s)\n", len(changes))
+		if len(upFilenames) > 1 {
+			fmt.Println("Changes that must run outside a transaction were split into their own migrations.")
+		}
+		if hasIrreversible {
+			fmt.Println("\n⚠ WARNING: Some changes are not fully reversible. Review the down migration carefully.")
+		}
+		return nil
+	},
+}
+
+func init() {
+	migrateCmd.Flags().BoolVar(&flags.ConcurrentIndexes, "concurrent-indexes", false, "build and drop indexes with CONCURRENTLY")
+	migrateCmd.Flags().BoolVar(&flags.Online, "online", false, "split SET NOT NULL, foreign keys and unique constraints into steps that avoid long locks")
+	migrateCmd.Flags().StringVar(&flags.MigrationNaming, "naming", "", "how new migrations are versioned: timestamp or sequential (default timestamp)")
+	migrateCmd.Flags().StringSliceVar(&flags.AllowHazards, "allow-hazards", nil, "hazards to accept in the generated migration, e.g. table-rewrite,access-exclusive")
+	migrateCmd.Flags().BoolVar(&emptyMigration, "empty", false, "write an empty migration to fill in by hand instead of comparing schemas")
+	migrateCmd.Flags().BoolVar(&rehashSum, "rehash", false, "update shrugged.sum after editing migrations by hand")
+	migrateCmd.MarkFlagsMutuallyExclusive("empty", "rehash")
+}
+
+// writeMigration reports whether any change is not fully reversible. Changes
+// that have to run outside a transaction go in migrations of their own, with
+// sub-versions so they run right after the first one.
+func writeMigration(migrationsDir, description string, changes []diff.Change) ([]string, []string, bool, error) {
+	upFilename, downFilename, err := newMigrationFiles(migrationsDir, description)
+	if err != nil {
+		return nil, nil, false, err
+	}
 
-		for _, change := range changes {
-			if _, err := upFile.WriteString(change.SQL() + "\n\n"); err != nil {
-				return fmt.Errorf("failed to write migration: %w", err)
+	var upFilenames, downFilenames []string
+	var hasIrreversible bool
+	hazards := diff.HazardsFor(changes)
+	offset := 0
+	for i, run := range diff.SplitByTransaction(changes) {
+		if i > 0 {
+			upFilename, downFilename, err = subMigrationFiles(upFilename)
+			if err != nil {
+				return nil, nil, false, err
 			}
 		}
 
-		downFile, err := os.Create(downFilename)
-		if err != nil {
-			return fmt.Errorf("failed to create down migration file: %w", err)
+		var up, down strings.Builder
+		if diff.NeedsNoTransaction(run) {
+			up.WriteString(migrate.NoTransactionDirective + "\n\n")
+			down.WriteString(migrate.NoTransactionDirective + "\n\n")
+		}
+
+		for j, change := range run {
+			up.WriteString(hazardComments(hazards[offset+j]) + change.SQL() + "\n\n")
 		}
-		defer func() { _ = downFile.Close() }()
+		offset += len(run)
 
-		var hasIrreversible bool
-		for i := len(changes) - 1; i >= 0; i-- {
-			change := changes[i]
-			downSQL := change.DownSQL()
+		for j := len(run) - 1; j >= 0; j-- {
+			change := run[j]
 			if !change.IsReversible() {
 				hasIrreversible = true
 			}
-			if _, err := downFile.WriteString(downSQL + "\n\n"); err != nil {
-				return fmt.Errorf("failed to write down migration: %w", err)
-			}
+			down.WriteString(change.DownSQL() + "\n\n")
 		}
 
-		if err := migrate.UpdateSum(migrationsDir); err != nil {
-			return fmt.Errorf("failed to update sum file: %w", err)
+		if err := os.WriteFile(upFilename, []byte(up.String()), 0644); err != nil {
+			return nil, nil, false, fmt.Errorf("failed to write migration: %w", err)
 		}
-
-		fmt.Printf("\nCreated migration: %s\n", upFilename)
-		fmt.Printf("Created rollback:  %s\n", downFilename)
-		fmt.Printf("Contains %d change(s)\n", len(changes))
-		if hasIrreversible {
-			fmt.Println("\n⚠ WARNING: Some changes are not fully reversible. Review the down migration carefully.")
+		if err := os.WriteFile(downFilename, []byte(down.String()), 0644); err != nil {
+			return nil, nil, false, fmt.Errorf("failed to write down migration: %w", err)
 		}
-		return nil
-	},
+		upFilenames = append(upFilenames, upFilename)
+		downFilenames = append(downFilenames, downFilename)
+	}
+
+	if err := migrate.UpdateSum(migrationsDir); err != nil {
+		return nil, nil, false, fmt.Errorf("failed to update sum file: %w", err)
+	}
+
+	return upFilenames, downFilenames, hasIrreversible, nil
+}
+
+// scaffoldMigration writes an up and down migration holding only a comment, to
+// be filled in by hand.
+func scaffoldMigration(migrationsDir, name string) error {
+	upFilename, downFilename, err := newMigrationFiles(migrationsDir, name)
+	if err != nil {
+		return err
+	}
+
+	up := fmt.Sprintf("-- %s\n-- Write the migration here. Add %q as the first line to run it outside a transaction.\n", name, migrate.NoTransactionDirective)
+	down := fmt.Sprintf("-- Undo %s\n", name)
+
+	if err := os.WriteFile(upFilename, []byte(up), 0644); err != nil {
+		return fmt.Errorf("failed to write migration: %w", err)
+	}
+	if err := os.WriteFile(downFilename, []byte(down), 0644); err != nil {
+		return fmt.Errorf("failed to write down migration: %w", err)
+	}
+
+	if err := migrate.UpdateSum(migrationsDir); err != nil {
+		return fmt.Errorf("failed to update sum file: %w", err)
+	}
+
+	fmt.Printf("Created migration: %s\n", upFilename)
+	fmt.Printf("Created rollback:  %s\n", downFilename)
+	fmt.Println("\nRun shrugged migrate --rehash after editing them to update the sum file.")
+	return nil
+}
+
+// newMigrationFiles returns the paths of the up and down files for a new
+// migration, creating migrationsDir if needed.
+func newMigrationFiles(migrationsDir, description string) (string, string, error) {
+	naming, err := migrate.ParseNaming(cfg.GetMigrationNaming(&flags))
+	if err != nil {
+		return "", "", err
+	}
+
+	if err := os.MkdirAll(migrationsDir, 0755); err != nil {
+		return "", "", fmt.Errorf("failed to create migrations directory: %w", err)
+	}
+
+	name, err := migrate.NextName(migrationsDir, naming, description, time.Now())
+	if err != nil {
+		return "", "", err
+	}
+
+	return migrationFiles(migrationsDir, name)
+}
+
+// subMigrationFiles returns the paths for a migration that runs right after
+// the one in upFilename, with the same description.
+func subMigrationFiles(upFilename string) (string, string, error) {
+	name := strings.TrimSuffix(filepath.Base(upFilename), ".sql")
+	version := migrate.Version(name)
+	return migrationFiles(filepath.Dir(upFilename), migrate.SubVersion(version)+strings.TrimPrefix(name, version))
+}
+
+func migrationFiles(migrationsDir, name string) (string, string, error) {
+	upFilename := filepath.Join(migrationsDir, name+".sql")
+	downFilename := filepath.Join(migrationsDir, name+".down.sql")
+	if _, err := os.Stat(upFilename); err == nil {
+		return "", "", fmt.Errorf("migration %s already exists", upFilename)
+	}
+	return upFilename, downFilename, nil
 }
@@ -11,12 +11,20 @@ import (
 var (
 	rollbackCount  int
 	rollbackDryRun bool
+	rollbackTo     string
 )
 
 var rollbackCmd = &cobra.Command{
 	Use:   "rollback",
 	Short: "Rollback the last applied migration(s)",
-	Long:  `Rollback one or more migrations using their corresponding .down.sql files.`,
+	Long: `Rollback one or more migrations using their corresponding .down.sql files.
+
+Use --to to roll back every migration applied after the named one, given by
+file name or version, leaving it as the last applied migration. Every .down.sql
+file needed is checked before anything is rolled back.
+
+The whole run holds the same Postgres advisory lock as apply, and uses the
+same lock and statement timeouts and retries.`,
 	RunE: func(cmd *cobra.Command, args []string) error {
 		ctx := cmd.Context()
 
@@ -26,7 +34,18 @@ var rollbackCmd = &cobra.Command{
 		}
 		migrationsDir := cfg.GetMigrationsDir(&flags)
 
-		rollbackable, err := migrate.GetRollbackable(ctx, dbURL, migrationsDir, rollbackCount)
+		conn, release, err := connectLocked(ctx, dbURL)
+		if err != nil {
+			return err
+		}
+		defer release()
+
+		var rollbackable []migrate.Migration
+		if rollbackTo != "" {
+			rollbackable, err = migrate.GetRollbackableTo(ctx, conn, migrationsDir, rollbackTo)
+		} else {
+			rollbackable, err = migrate.GetRollbackable(ctx, conn, migrationsDir, rollbackCount)
+		}
 		if err != nil {
 			return fmt.Errorf("failed to get rollbackable migrations: %w", err)
 		}
@@ -52,8 +71,15 @@ var rollbackCmd = &cobra.Command{
 
 		fmt.Println()
 		for _, m := range rollbackable {
-			fmt.Printf("Rolling back %s... ", m.Name)
-			if err := migrate.Rollback(ctx, dbURL, m); err != nil {
+			err := retryOnLockTimeout(ctx, func() error {
+				if migrate.IsNoTransaction(m.Content) {
+					fmt.Printf("Rolling back %s outside a transaction...\n", m.Name)
+				} else {
+					fmt.Printf("Rolling back %s... ", m.Name)
+				}
+				return migrate.RollbackWithProgress(ctx, conn, m, printStatementProgress)
+			})
+			if err != nil {
 				fmt.Println("FAILED")
 				return fmt.Errorf("failed to rollback migration %s: %w", m.Name, err)
 			}
@@ -67,5 +93,16 @@ var rollbackCmd = &cobra.Command{
 
 func init() {
 	rollbackCmd.Flags().IntVarP(&rollbackCount, "count", "n", 1, "number of migrations to rollback")
+	rollbackCmd.Flags().StringVar(&rollbackTo, "to", "", "rollback every migration applied after this one (file name or version)")
 	rollbackCmd.Flags().BoolVar(&rollbackDryRun, "dry-run", false, "preview rollback without executing")
+	rollbackCmd.Flags().Int64Var(&flags.LockKey, "lock-key", 0, "advisory lock key used to serialize migration runs")
+	rollbackCmd.Flags().DurationVar(&flags.LockWaitTimeout, "lock-wait-timeout", 0, "how long to wait for the migration lock (default 1m)")
+	rollbackCmd.Flags().DurationVar(&flags.LockTimeout, "lock-timeout", 0, "Postgres lock_timeout for migration statements, e.g. 5s")
+	rollbackCmd.Flags().DurationVar(&flags.StatementTimeout, "statement-timeout", 0, "Postgres statement_timeout for migration statements, e.g. 10m")
+	rollbackCmd.Flags().IntVar(&flags.LockRetries, "lock-retries", 0, "times to retry a migration after its lock_timeout fires")
+	rollbackCmd.Flags().DurationVar(&flags.LockRetryBackoff, "lock-retry-backoff", 0, "wait before the first lock timeout retry, doubled for each retry after (default 1s)")
+	rollbackCmd.Flags().StringVar(&flags.Label, "label", "", "deploy identifier recorded in the migration history")
+	rollbackCmd.Flags().StringVar(&flags.HistoryTable, "history-table", "", "table migrations are recorded in (default shrugged_migrations)")
+	rollbackCmd.Flags().StringVar(&flags.HistorySchema, "history-schema", "", "schema of the history table (default: first schema on the search path)")
+	rollbackCmd.MarkFlagsMutuallyExclusive("count", "to")
 }
@@ -63,6 +63,10 @@ func init() {
 	rootCmd.AddCommand(inspectCmd)
 	rootCmd.AddCommand(validateCmd)
 	rootCmd.AddCommand(statusCmd)
+	rootCmd.AddCommand(baselineCmd)
+	rootCmd.AddCommand(verifyMigrationsCmd)
+	rootCmd.AddCommand(driftCmd)
+	rootCmd.AddCommand(squashCmd)
 	rootCmd.AddCommand(generateCmd)
 	rootCmd.AddCommand(versionCmd)
 }
@@ -0,0 +1,235 @@
+package cli
+
+import (
+	"context"
+	"fmt"
+	"os"
+	"path/filepath"
+	"slices"
+	"strings"
+
+	"github.com/spf13/cobra"
+
+	"github.com/terminally-online/shrugged/internal/diff"
+	"github.com/terminally-online/shrugged/internal/docker"
+	"github.com/terminally-online/shrugged/internal/introspect"
+	"github.com/terminally-online/shrugged/internal/migrate"
+)
+
+var (
+	squashBefore    string
+	squashAllowData bool
+)
+
+var squashCmd = &cobra.Command{
+	Use:   "squash",
+	Short: "Collapse old migrations into a single migration",
+	Long: `Replace every migration before the one given by --before with a single
+migration holding the schema they produce, so diff and migrate have fewer
+migrations to replay.
+
+The migrations are applied to a temporary Postgres container and the result is
+introspected and written as one migration, with a sub-version of the last
+migration it replaces so it sorts right after it, as in 0007.1_squashed.sql. squash checks that the new migration reproduces the same schema
+before moving the old up and down files to the archive directory inside the
+migrations directory, and rewrites shrugged.sum.
+
+The squashed migration lists the migrations it replaces. apply records it as
+applied, without running it, in databases that already applied them, and runs
+it on new databases. A database that applied only some of them has to apply
+the rest from the archive first. The squashed migration has no .down.sql, so
+rollback refuses to go past it.
+
+The squashed migration only holds the schema, so squash refuses to replace
+migrations that insert, update or delete rows, such as seed data or backfills,
+unless --allow-data-loss is given. Move seed data to a migration after the
+squashed one first.`,
+	RunE: func(cmd *cobra.Command, args []string) error {
+		ctx := cmd.Context()
+
+		migrationsDir := cfg.GetMigrationsDir(&flags)
+
+		entries, err := os.ReadDir(migrationsDir)
+		if err != nil {
+			return fmt.Errorf("failed to read migrations directory: %w", err)
+		}
+		sortMigrationEntries(entries)
+
+		var names []string
+		for _, entry := range entries {
+			if isUpMigration(entry) {
+				names = append(names, entry.Name())
+			}
+		}
+
+		squashed, err := migrationsBefore(names, squashBefore)
+		if err != nil {
+			return err
+		}
+		if len(squashed) < 2 {
+			return fmt.Errorf("nothing to squash: %d migration(s) before %s", len(squashed), squashBefore)
+		}
+
+		archiveDir := filepath.Join(migrationsDir, "archive")
+		files, err := squashedFiles(migrationsDir, archiveDir, squashed)
+		if err != nil {
+			return err
+		}
+
+		name := migrate.SubVersion(migrate.Version(squashed[len(squashed)-1])) + "_squashed.sql"
+		path := filepath.Join(migrationsDir, name)
+		if _, err := os.Stat(path); err == nil && !slices.Contains(squashed, name) {
+			return fmt.Errorf("migration %s already exists", path)
+		}
+
+		var replaced, dataStatements []string
+		for _, n := range squashed {
+			content, err := os.ReadFile(filepath.Join(migrationsDir, n))
+			if err != nil {
+				return fmt.Errorf("failed to read migration %s: %w", n, err)
+			}
+			replaced = append(replaced, migrate.Squashes(string(content))...)
+			replaced = append(replaced, n)
+			for _, stmt := range migrate.DataStatements(string(content)) {
+				line, _, _ := strings.Cut(stmt.SQL, "\n")
+				dataStatements = append(dataStatements, fmt.Sprintf("%s:%d: %s", n, stmt.Line, line))
+			}
+		}
+
+		if len(dataStatements) > 0 {
+			if !squashAllowData {
+				fmt.Println("These migrations change data, which the squashed migration would leave out:")
+				fmt.Println("  " + strings.Join(dataStatements, "\n  "))
+				fmt.Println("\nMove them to a migration after the squashed ones, or use --allow-data-loss to squash anyway.")
+				return fmt.Errorf("refusing to squash migrations that change data")
+			}
+			fmt.Println("⚠ WARNING: leaving out data changes made by these migrations:")
+			fmt.Println("  " + strings.Join(dataStatements, "\n  "))
+			fmt.Println()
+		}
+
+		dockerCfg := docker.PostgresConfig{
+			Version:  cfg.GetPostgresVersion(&flags),
+			User:     "shrugged",
+			Password: "shrugged",
+			Database: "shrugged",
+		}
+
+		fmt.Println("Starting Postgres container...")
+		container, err := docker.StartPostgres(ctx, dockerCfg)
+		if err != nil {
+			return fmt.Errorf("failed to start postgres: %w", err)
+		}
+		defer func() {
+			fmt.Println("Stopping container...")
+			_ = docker.StopContainer(context.Background(), container.ID)
+		}()
+
+		include := make(map[string]bool)
+		for _, n := range squashed {
+			include[n] = true
+		}
+		schema, err := buildState(ctx, container, migrationsDir, func(name string) bool {
+			return include[name]
+		})
+		if err != nil {
+			return err
+		}
+
+		var sb strings.Builder
+		sb.WriteString(fmt.Sprintf("-- Squashed from %d migrations before %s.\n", len(squashed), squashBefore))
+		for _, r := range replaced {
+			sb.WriteString(fmt.Sprintf("%s %s\n", migrate.SquashDirective, r))
+		}
+		sb.WriteString("\n" + schema.ToSQL())
+		content := sb.String()
+
+		fmt.Println("Resetting database to verify the squashed migration...")
+		if err := docker.ResetDatabase(ctx, container); err != nil {
+			return fmt.Errorf("failed to reset database: %w", err)
+		}
+		conn, err := migrate.Connect(ctx, container.ConnectionString())
+		if err != nil {
+			return err
+		}
+		defer func() { _ = conn.Close(context.Background()) }()
+
+		if err := migrate.Execute(ctx, conn, name, content); err != nil {
+			return fmt.Errorf("failed to apply squashed migration: %w", err)
+		}
+		rebuilt, err := introspect.Database(ctx, container.ConnectionString())
+		if err != nil {
+			return fmt.Errorf("failed to introspect squashed migration: %w", err)
+		}
+		if changes := diff.Compare(rebuilt, schema); len(changes) > 0 {
+			fmt.Printf("\nThe squashed migration does not reproduce the schema; %d change(s) would still be needed:\n\n", len(changes))
+			for _, change := range changes {
+				fmt.Println(change.SQL())
+				fmt.Println()
+			}
+			return fmt.Errorf("refusing to squash migrations into one that does not match them")
+		}
+
+		if err := os.MkdirAll(archiveDir, 0755); err != nil {
+			return fmt.Errorf("failed to create archive directory: %w", err)
+		}
+		for _, f := range files {
+			if err := os.Rename(filepath.Join(migrationsDir, f), filepath.Join(archiveDir, f)); err != nil {
+				return fmt.Errorf("failed to archive %s: %w", f, err)
+			}
+		}
+
+		if err := os.WriteFile(path, []byte(content), 0644); err != nil {
+			return fmt.Errorf("failed to write squashed migration: %w", err)
+		}
+
+		if err := migrate.UpdateSum(migrationsDir); err != nil {
+			return fmt.Errorf("failed to update sum file: %w", err)
+		}
+
+		fmt.Printf("\nSquashed %d migration(s) into %s\n", len(squashed), path)
+		fmt.Printf("Moved the old files to %s\n", archiveDir)
+		return nil
+	},
+}
+
+func init() {
+	squashCmd.Flags().StringVar(&squashBefore, "before", "", "squash every migration before this one (file name or version)")
+	squashCmd.Flags().BoolVar(&squashAllowData, "allow-data-loss", false, "squash migrations that insert, update or delete rows, leaving those changes out")
+	_ = squashCmd.MarkFlagRequired("before")
+}
+
+func migrationsBefore(names []string, target string) ([]string, error) {
+	index := -1
+	for i, name := range names {
+		if !migrate.MatchesTarget(name, target) {
+			continue
+		}
+		if index != -1 {
+			return nil, fmt.Errorf("%q matches both %s and %s", target, names[index], name)
+		}
+		index = i
+	}
+	if index == -1 {
+		return nil, fmt.Errorf("no migration matches %q", target)
+	}
+	return names[:index], nil
+}
+
+func squashedFiles(migrationsDir, archiveDir string, squashed []string) ([]string, error) {
+	var files []string
+	for _, name := range squashed {
+		files = append(files, name)
+		down := strings.TrimSuffix(name, ".sql") + ".down.sql"
+		if _, err := os.Stat(filepath.Join(migrationsDir, down)); err == nil {
+			files = append(files, down)
+		}
+	}
+
+	for _, f := range files {
+		if _, err := os.Stat(filepath.Join(archiveDir, f)); err == nil {
+			return nil, fmt.Errorf("%s is already in %s", f, archiveDir)
+		}
+	}
+	return files, nil
+}
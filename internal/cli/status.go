@@ -2,16 +2,25 @@ package cli
 
 import (
 	"fmt"
+	"strings"
 
 	"github.com/spf13/cobra"
 
 	"github.com/terminally-online/shrugged/internal/migrate"
 )
 
+var showHistory bool
+
 var statusCmd = &cobra.Command{
 	Use:   "status",
 	Short: "Show migration status",
-	Long:  `Display the status of all migrations, showing which have been applied and which are pending.`,
+	Long: `Display the status of all migrations, showing which have been applied and which are pending.
+Pending migrations that sort before the latest applied migration are marked as
+out of order.
+
+Use --history to also print every apply and rollback recorded in the history
+table, with how long it took, who ran it from where, the shrugged version and
+the --label it was given.`,
 	RunE: func(cmd *cobra.Command, args []string) error {
 		dbURL, err := cfg.GetDatabaseURL(&flags)
 		if err != nil {
@@ -19,28 +28,45 @@ var statusCmd = &cobra.Command{
 		}
 		migrationsDir := cfg.GetMigrationsDir(&flags)
 
-		applied, err := migrate.GetAppliedWithStatus(cmd.Context(), dbURL, migrationsDir)
+		configureHistory()
+		conn, err := migrate.Connect(cmd.Context(), dbURL)
+		if err != nil {
+			return err
+		}
+		defer func() { _ = conn.Close(cmd.Context()) }()
+
+		applied, err := migrate.GetAppliedWithStatus(cmd.Context(), conn, migrationsDir)
 		if err != nil {
 			return fmt.Errorf("failed to get applied migrations: %w", err)
 		}
 
-		pending, err := migrate.GetPending(cmd.Context(), dbURL, migrationsDir)
+		pending, err := migrate.GetPending(cmd.Context(), conn, migrationsDir)
 		if err != nil {
 			return fmt.Errorf("failed to get pending migrations: %w", err)
 		}
 
+		if showHistory {
+			entries, err := migrate.GetHistory(cmd.Context(), conn)
+			if err != nil {
+				return fmt.Errorf("failed to get migration history: %w", err)
+			}
+			printHistory(entries)
+		}
+
 		if len(applied) == 0 && len(pending) == 0 {
 			fmt.Println("No migrations found.")
 			return nil
 		}
 
-		var modifiedCount int
+		var modifiedCount, outOfOrderCount int
 		if len(applied) > 0 {
 			fmt.Println("Applied migrations:")
 			for _, m := range applied {
 				if m.Modified {
 					fmt.Printf("  ⚠ %s (applied %s) MODIFIED\n", m.Name, m.AppliedAt.Format("2006-01-02 15:04:05"))
 					modifiedCount++
+				} else if m.SquashedInto != "" {
+					fmt.Printf("  ✓ %s (applied %s, squashed into %s)\n", m.Name, m.AppliedAt.Format("2006-01-02 15:04:05"), m.SquashedInto)
 				} else {
 					fmt.Printf("  ✓ %s (applied %s)\n", m.Name, m.AppliedAt.Format("2006-01-02 15:04:05"))
 				}
@@ -53,7 +79,12 @@ var statusCmd = &cobra.Command{
 			}
 			fmt.Println("Pending migrations:")
 			for _, m := range pending {
-				fmt.Printf("  ○ %s\n", m.Name)
+				if m.OutOfOrder {
+					fmt.Printf("  ⚠ %s OUT OF ORDER\n", m.Name)
+					outOfOrderCount++
+				} else {
+					fmt.Printf("  ○ %s\n", m.Name)
+				}
 			}
 		}
 
@@ -63,6 +94,53 @@ var statusCmd = &cobra.Command{
 			fmt.Println("This may indicate schema drift. Consider reviewing these changes.")
 		}
 
+		if outOfOrderCount > 0 {
+			fmt.Println()
+			fmt.Printf("WARNING: %d pending migration(s) sort before the latest applied migration.\n", outOfOrderCount)
+			fmt.Println("They were probably merged from a branch after newer migrations were applied.")
+		}
+
 		return nil
 	},
 }
+
+func init() {
+	statusCmd.Flags().BoolVar(&showHistory, "history", false, "print every apply and rollback recorded")
+	statusCmd.Flags().StringVar(&flags.HistoryTable, "history-table", "", "table migrations are recorded in (default shrugged_migrations)")
+	statusCmd.Flags().StringVar(&flags.HistorySchema, "history-schema", "", "schema of the history table (default: first schema on the search path)")
+}
+
+func printHistory(entries []migrate.HistoryEntry) {
+	if len(entries) == 0 {
+		fmt.Println("No migration history recorded.")
+		fmt.Println()
+		return
+	}
+
+	fmt.Println("History:")
+	for _, e := range entries {
+		var details []string
+		if e.Duration > 0 {
+			details = append(details, e.Duration.String())
+		}
+		switch {
+		case e.User != "" && e.Hostname != "":
+			details = append(details, "by "+e.User+"@"+e.Hostname)
+		case e.User != "":
+			details = append(details, "by "+e.User)
+		}
+		if e.Version != "" {
+			details = append(details, "shrugged "+e.Version)
+		}
+		if e.Label != "" {
+			details = append(details, "label "+e.Label)
+		}
+
+		line := fmt.Sprintf("  %s  %-8s  %s", e.At.Format("2006-01-02 15:04:05"), e.Action, e.Name)
+		if len(details) > 0 {
+			line += " (" + strings.Join(details, ", ") + ")"
+		}
+		fmt.Println(line)
+	}
+	fmt.Println()
+}
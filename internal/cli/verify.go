@@ -0,0 +1,180 @@
+package cli
+
+import (
+	"context"
+	"fmt"
+	"os"
+	"path/filepath"
+	"strings"
+
+	"github.com/jackc/pgx/v5"
+	"github.com/spf13/cobra"
+
+	"github.com/terminally-online/shrugged/internal/diff"
+	"github.com/terminally-online/shrugged/internal/docker"
+	"github.com/terminally-online/shrugged/internal/introspect"
+	"github.com/terminally-online/shrugged/internal/migrate"
+)
+
+var verifyMigrationsCmd = &cobra.Command{
+	Use:   "verify-migrations",
+	Short: "Check that every down migration restores the previous schema",
+	Long: `Apply each migration to a temporary Postgres container, roll it back with its
+.down.sql and check that the schema is back to what it was before the migration.
+
+Migrations are verified in order, each one applied again after its rollback so
+the next one starts from the right state. Objects that a down migration does
+not restore are listed with the SQL that would still be needed. Migrations
+without a .down.sql are skipped.`,
+	RunE: func(cmd *cobra.Command, args []string) error {
+		ctx := cmd.Context()
+
+		migrationsDir := cfg.GetMigrationsDir(&flags)
+
+		entries, err := os.ReadDir(migrationsDir)
+		if err != nil {
+			if os.IsNotExist(err) {
+				fmt.Println("No migrations found.")
+				return nil
+			}
+			return fmt.Errorf("failed to read migrations directory: %w", err)
+		}
+		if countSQLFiles(entries) == 0 {
+			fmt.Println("No migrations found.")
+			return nil
+		}
+
+		dockerCfg := docker.PostgresConfig{
+			Version:  cfg.GetPostgresVersion(&flags),
+			User:     "shrugged",
+			Password: "shrugged",
+			Database: "shrugged",
+		}
+
+		fmt.Println("Starting Postgres container...")
+		container, err := docker.StartPostgres(ctx, dockerCfg)
+		if err != nil {
+			return fmt.Errorf("failed to start postgres: %w", err)
+		}
+		defer func() {
+			fmt.Println("Stopping container...")
+			_ = docker.StopContainer(context.Background(), container.ID)
+		}()
+
+		conn, err := migrate.Connect(ctx, container.ConnectionString())
+		if err != nil {
+			return err
+		}
+		defer func() { _ = conn.Close(context.Background()) }()
+
+		sortMigrationEntries(entries)
+		fmt.Printf("Verifying %d migration(s)...\n", countSQLFiles(entries))
+
+		var failed int
+		for _, entry := range entries {
+			if !isUpMigration(entry) {
+				continue
+			}
+
+			ok, err := verifyRoundTrip(ctx, conn, container, migrationsDir, entry.Name())
+			if err != nil {
+				return err
+			}
+			if !ok {
+				failed++
+			}
+		}
+
+		if failed > 0 {
+			return fmt.Errorf("%d down migration(s) do not restore the previous schema", failed)
+		}
+
+		fmt.Println("\nAll down migrations restore the previous schema.")
+		return nil
+	},
+}
+
+// verifyRoundTrip applies the migration name, rolls it back and compares the
+// schema with the one from before it, then applies it again and compares the
+// schema with the one the first apply produced. It reports whether both
+// match; an error means a migration could not be run and verification cannot
+// continue.
+func verifyRoundTrip(ctx context.Context, conn *pgx.Conn, container *docker.Container, migrationsDir, name string) (bool, error) {
+	upPath := filepath.Join(migrationsDir, name)
+	up, err := os.ReadFile(upPath)
+	if err != nil {
+		return false, fmt.Errorf("failed to read migration %s: %w", name, err)
+	}
+
+	downPath := filepath.Join(migrationsDir, strings.TrimSuffix(name, ".sql")+".down.sql")
+	down, err := os.ReadFile(downPath)
+	if err != nil {
+		if !os.IsNotExist(err) {
+			return false, fmt.Errorf("failed to read down migration %s: %w", downPath, err)
+		}
+		if err := migrate.Execute(ctx, conn, upPath, string(up)); err != nil {
+			return false, fmt.Errorf("failed to apply migration %s: %w", name, err)
+		}
+		fmt.Printf("  - %s (skipped, no .down.sql)\n", name)
+		return true, nil
+	}
+
+	before, err := introspect.Database(ctx, container.ConnectionString())
+	if err != nil {
+		return false, fmt.Errorf("failed to introspect before %s: %w", name, err)
+	}
+
+	if err := migrate.Execute(ctx, conn, upPath, string(up)); err != nil {
+		fmt.Printf("  ✗ %s\n", name)
+		return false, fmt.Errorf("failed to apply migration %s: %w", name, err)
+	}
+	after, err := introspect.Database(ctx, container.ConnectionString())
+	if err != nil {
+		return false, fmt.Errorf("failed to introspect after %s: %w", name, err)
+	}
+
+	if err := migrate.Execute(ctx, conn, downPath, string(down)); err != nil {
+		fmt.Printf("  ✗ %s\n", name)
+		return false, fmt.Errorf("failed to roll back migration %s: %w", name, err)
+	}
+	restored, err := introspect.Database(ctx, container.ConnectionString())
+	if err != nil {
+		return false, fmt.Errorf("failed to introspect after rolling back %s: %w", name, err)
+	}
+
+	if err := migrate.Execute(ctx, conn, upPath, string(up)); err != nil {
+		fmt.Printf("  ✗ %s\n", name)
+		return false, fmt.Errorf("failed to reapply migration %s after rolling it back: %w", name, err)
+	}
+	reapplied, err := introspect.Database(ctx, container.ConnectionString())
+	if err != nil {
+		return false, fmt.Errorf("failed to introspect after reapplying %s: %w", name, err)
+	}
+
+	unrestored := diff.Compare(restored, before)
+	drifted := diff.Compare(reapplied, after)
+	if len(unrestored) == 0 && len(drifted) == 0 {
+		fmt.Printf("  ✓ %s\n", name)
+		return true, nil
+	}
+
+	fmt.Printf("  ✗ %s\n", name)
+	if len(unrestored) > 0 {
+		fmt.Printf("      %d object(s) not restored by its down migration:\n", len(unrestored))
+		printChangedObjects(unrestored)
+	}
+	if len(drifted) > 0 {
+		fmt.Printf("      %d object(s) differ when it is applied again after rolling back:\n", len(drifted))
+		printChangedObjects(drifted)
+	}
+	return false, nil
+}
+
+func printChangedObjects(changes []diff.Change) {
+	for _, change := range changes {
+		fmt.Printf("        %s:\n", change.ObjectName())
+		for _, line := range strings.Split(change.SQL(), "\n") {
+			fmt.Printf("            %s\n", line)
+		}
+	}
+}
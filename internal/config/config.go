@@ -4,6 +4,7 @@ import (
 	"fmt"
 	"os"
 	"strings"
+	"time"
 
 	"gopkg.in/yaml.v3"
 )
@@ -17,6 +18,26 @@ type Config struct {
 	Language        string `yaml:"language"`
 	Queries         string `yaml:"queries"`
 	QueriesOut      string `yaml:"queries_out"`
+
+	ConcurrentIndexes bool   `yaml:"concurrent_indexes"`
+	Online            bool   `yaml:"online"`
+	MigrationNaming   string `yaml:"migration_naming"`
+
+	LockKey         int64         `yaml:"lock_key"`
+	LockWaitTimeout time.Duration `yaml:"lock_wait_timeout"`
+
+	LockTimeout      time.Duration `yaml:"lock_timeout"`
+	StatementTimeout time.Duration `yaml:"statement_timeout"`
+	LockRetries      int           `yaml:"lock_retries"`
+	LockRetryBackoff time.Duration `yaml:"lock_retry_backoff"`
+
+	HistoryTable  string `yaml:"history_table"`
+	HistorySchema string `yaml:"history_schema"`
+
+	OutOfOrder string `yaml:"out_of_order"`
+
+	AllowHazards    []string `yaml:"allow_hazards"`
+	SkipHazardCheck bool     `yaml:"skip_hazard_check"`
 }
 
 type Flags struct {
@@ -29,6 +50,27 @@ type Flags struct {
 	Queries         string
 	QueriesOut      string
 	Clean           bool
+
+	ConcurrentIndexes bool
+	Online            bool
+	MigrationNaming   string
+
+	LockKey         int64
+	LockWaitTimeout time.Duration
+
+	LockTimeout      time.Duration
+	StatementTimeout time.Duration
+	LockRetries      int
+	LockRetryBackoff time.Duration
+
+	HistoryTable  string
+	HistorySchema string
+	Label         string
+
+	OutOfOrder string
+
+	AllowHazards    []string
+	SkipHazardCheck bool
 }
 
 func Load(path string) (*Config, error) {
@@ -50,6 +92,8 @@ func Load(path string) (*Config, error) {
 	cfg.Language = expandEnv(cfg.Language)
 	cfg.Queries = expandEnv(cfg.Queries)
 	cfg.QueriesOut = expandEnv(cfg.QueriesOut)
+	cfg.HistoryTable = expandEnv(cfg.HistoryTable)
+	cfg.HistorySchema = expandEnv(cfg.HistorySchema)
 
 	return &cfg, nil
 }
@@ -141,6 +185,126 @@ func (c *Config) GetClean(flags *Flags) bool {
 	return false
 }
 
+func (c *Config) GetConcurrentIndexes(flags *Flags) bool {
+	if flags != nil && flags.ConcurrentIndexes {
+		return true
+	}
+	return c.ConcurrentIndexes
+}
+
+func (c *Config) GetOnline(flags *Flags) bool {
+	if flags != nil && flags.Online {
+		return true
+	}
+	return c.Online
+}
+
+func (c *Config) GetMigrationNaming(flags *Flags) string {
+	if flags != nil && flags.MigrationNaming != "" {
+		return flags.MigrationNaming
+	}
+	if c.MigrationNaming != "" {
+		return c.MigrationNaming
+	}
+	return "timestamp"
+}
+
+func (c *Config) GetLockKey(flags *Flags) int64 {
+	if flags != nil && flags.LockKey != 0 {
+		return flags.LockKey
+	}
+	return c.LockKey
+}
+
+func (c *Config) GetLockWaitTimeout(flags *Flags) time.Duration {
+	if flags != nil && flags.LockWaitTimeout != 0 {
+		return flags.LockWaitTimeout
+	}
+	if c.LockWaitTimeout != 0 {
+		return c.LockWaitTimeout
+	}
+	return time.Minute
+}
+
+func (c *Config) GetLockTimeout(flags *Flags) time.Duration {
+	if flags != nil && flags.LockTimeout != 0 {
+		return flags.LockTimeout
+	}
+	return c.LockTimeout
+}
+
+func (c *Config) GetStatementTimeout(flags *Flags) time.Duration {
+	if flags != nil && flags.StatementTimeout != 0 {
+		return flags.StatementTimeout
+	}
+	return c.StatementTimeout
+}
+
+func (c *Config) GetLockRetries(flags *Flags) int {
+	if flags != nil && flags.LockRetries != 0 {
+		return flags.LockRetries
+	}
+	return c.LockRetries
+}
+
+func (c *Config) GetLockRetryBackoff(flags *Flags) time.Duration {
+	if flags != nil && flags.LockRetryBackoff != 0 {
+		return flags.LockRetryBackoff
+	}
+	if c.LockRetryBackoff != 0 {
+		return c.LockRetryBackoff
+	}
+	return time.Second
+}
+
+func (c *Config) GetHistoryTable(flags *Flags) string {
+	if flags != nil && flags.HistoryTable != "" {
+		return flags.HistoryTable
+	}
+	if c.HistoryTable != "" {
+		return c.HistoryTable
+	}
+	return "shrugged_migrations"
+}
+
+func (c *Config) GetHistorySchema(flags *Flags) string {
+	if flags != nil && flags.HistorySchema != "" {
+		return flags.HistorySchema
+	}
+	return c.HistorySchema
+}
+
+func (c *Config) GetLabel(flags *Flags) string {
+	if flags != nil {
+		return flags.Label
+	}
+	return ""
+}
+
+func (c *Config) GetOutOfOrder(flags *Flags) string {
+	if flags != nil && flags.OutOfOrder != "" {
+		return flags.OutOfOrder
+	}
+	if c.OutOfOrder != "" {
+		return c.OutOfOrder
+	}
+	return "warn"
+}
+
+func (c *Config) GetAllowHazards(flags *Flags) []string {
+	if flags != nil && len(flags.AllowHazards) > 0 {
+		return flags.AllowHazards
+	}
+	return c.AllowHazards
+}
+
+func (c *Config) GetSkipHazardCheck(flags *Flags) bool {
+	if flags != nil && flags.SkipHazardCheck {
+		return true
+	}
+	return c.SkipHazardCheck
+}
+
 func expandEnv(s string) string {
 	if strings.HasPrefix(s, "${") && strings.HasSuffix(s, "}") {
 		envVar := s[2 : len(s)-1]
@@ -4,6 +4,7 @@ import (
 	"os"
 	"path/filepath"
 	"testing"
+	"time"
 )
 
 func TestLoad_ValidConfig(t *testing.T) {
@@ -193,3 +194,193 @@ schema: $TEST_SCHEMA_PATH
 		t.Errorf("Schema = %q, want %q", cfg.Schema, "env_schema.sql")
 	}
 }
+
+func TestGetConcurrentIndexes(t *testing.T) {
+	cfg := &Config{}
+	if cfg.GetConcurrentIndexes(nil) {
+		t.Error("GetConcurrentIndexes default should be false")
+	}
+	if !cfg.GetConcurrentIndexes(&Flags{ConcurrentIndexes: true}) {
+		t.Error("GetConcurrentIndexes should honor flag")
+	}
+
+	cfg.ConcurrentIndexes = true
+	if !cfg.GetConcurrentIndexes(&Flags{}) {
+		t.Error("GetConcurrentIndexes should honor config")
+	}
+}
+
+func TestGetOnline(t *testing.T) {
+	cfg := &Config{}
+	if cfg.GetOnline(nil) {
+		t.Error("GetOnline default should be false")
+	}
+	if !cfg.GetOnline(&Flags{Online: true}) {
+		t.Error("GetOnline should honor flag")
+	}
+
+	cfg.Online = true
+	if !cfg.GetOnline(&Flags{}) {
+		t.Error("GetOnline should honor config")
+	}
+}
+
+func TestGetMigrationNaming(t *testing.T) {
+	cfg := &Config{}
+	if got := cfg.GetMigrationNaming(nil); got != "timestamp" {
+		t.Errorf("GetMigrationNaming() default = %q, want timestamp", got)
+	}
+
+	cfg.MigrationNaming = "sequential"
+	if got := cfg.GetMigrationNaming(&Flags{}); got != "sequential" {
+		t.Errorf("GetMigrationNaming() = %q, want sequential", got)
+	}
+	if got := cfg.GetMigrationNaming(&Flags{MigrationNaming: "timestamp"}); got != "timestamp" {
+		t.Errorf("GetMigrationNaming() with flag = %q, want timestamp", got)
+	}
+}
+
+func TestGetLockWaitTimeout(t *testing.T) {
+	tmpDir, err := os.MkdirTemp("", "config_test")
+	if err != nil {
+		t.Fatalf("failed to create temp dir: %v", err)
+	}
+	defer func() { _ = os.RemoveAll(tmpDir) }()
+
+	configPath := filepath.Join(tmpDir, "shrugged.yaml")
+	if err := os.WriteFile(configPath, []byte("lock_key: 42\nlock_wait_timeout: 30s\n"), 0644); err != nil {
+		t.Fatalf("failed to write config: %v", err)
+	}
+
+	cfg, err := Load(configPath)
+	if err != nil {
+		t.Fatalf("Load() error = %v", err)
+	}
+
+	if got := cfg.GetLockKey(&Flags{}); got != 42 {
+		t.Errorf("GetLockKey() = %d, want 42", got)
+	}
+	if got := cfg.GetLockWaitTimeout(&Flags{}); got != 30*time.Second {
+		t.Errorf("GetLockWaitTimeout() = %v, want 30s", got)
+	}
+	if got := cfg.GetLockWaitTimeout(&Flags{LockWaitTimeout: 5 * time.Second}); got != 5*time.Second {
+		t.Errorf("GetLockWaitTimeout() with flag = %v, want 5s", got)
+	}
+	if got := (&Config{}).GetLockWaitTimeout(nil); got != time.Minute {
+		t.Errorf("GetLockWaitTimeout() default = %v, want 1m", got)
+	}
+}
+
+func TestGetTimeouts(t *testing.T) {
+	tmpDir, err := os.MkdirTemp("", "config_test")
+	if err != nil {
+		t.Fatalf("failed to create temp dir: %v", err)
+	}
+	defer func() { _ = os.RemoveAll(tmpDir) }()
+
+	configPath := filepath.Join(tmpDir, "shrugged.yaml")
+	content := "lock_timeout: 5s\nstatement_timeout: 10m\nlock_retries: 3\nlock_retry_backoff: 2s\n"
+	if err := os.WriteFile(configPath, []byte(content), 0644); err != nil {
+		t.Fatalf("failed to write config: %v", err)
+	}
+
+	cfg, err := Load(configPath)
+	if err != nil {
+		t.Fatalf("Load() error = %v", err)
+	}
+
+	if got := cfg.GetLockTimeout(&Flags{}); got != 5*time.Second {
+		t.Errorf("GetLockTimeout() = %v, want 5s", got)
+	}
+	if got := cfg.GetLockTimeout(&Flags{LockTimeout: time.Second}); got != time.Second {
+		t.Errorf("GetLockTimeout() with flag = %v, want 1s", got)
+	}
+	if got := cfg.GetStatementTimeout(&Flags{}); got != 10*time.Minute {
+		t.Errorf("GetStatementTimeout() = %v, want 10m", got)
+	}
+	if got := cfg.GetLockRetries(&Flags{}); got != 3 {
+		t.Errorf("GetLockRetries() = %d, want 3", got)
+	}
+	if got := cfg.GetLockRetryBackoff(&Flags{}); got != 2*time.Second {
+		t.Errorf("GetLockRetryBackoff() = %v, want 2s", got)
+	}
+
+	empty := &Config{}
+	if got := empty.GetLockTimeout(nil); got != 0 {
+		t.Errorf("GetLockTimeout() default = %v, want 0", got)
+	}
+	if got := empty.GetLockRetries(nil); got != 0 {
+		t.Errorf("GetLockRetries() default = %d, want 0", got)
+	}
+	if got := empty.GetLockRetryBackoff(nil); got != time.Second {
+		t.Errorf("GetLockRetryBackoff() default = %v, want 1s", got)
+	}
+}
+
+func TestGetHistoryTable(t *testing.T) {
+	cfg := &Config{}
+	if got := cfg.GetHistoryTable(nil); got != "shrugged_migrations" {
+		t.Errorf("GetHistoryTable() default = %q, want shrugged_migrations", got)
+	}
+	if got := cfg.GetHistorySchema(nil); got != "" {
+		t.Errorf("GetHistorySchema() default = %q, want empty", got)
+	}
+
+	cfg.HistoryTable = "schema_history"
+	cfg.HistorySchema = "ops"
+	if got := cfg.GetHistoryTable(&Flags{}); got != "schema_history" {
+		t.Errorf("GetHistoryTable() = %q, want schema_history", got)
+	}
+	if got := cfg.GetHistorySchema(&Flags{}); got != "ops" {
+		t.Errorf("GetHistorySchema() = %q, want ops", got)
+	}
+	if got := cfg.GetHistoryTable(&Flags{HistoryTable: "deploys"}); got != "deploys" {
+		t.Errorf("GetHistoryTable() with flag = %q, want deploys", got)
+	}
+}
+
+func TestGetOutOfOrder(t *testing.T) {
+	cfg := &Config{}
+	if got := cfg.GetOutOfOrder(nil); got != "warn" {
+		t.Errorf("GetOutOfOrder() default = %q, want warn", got)
+	}
+
+	cfg.OutOfOrder = "error"
+	if got := cfg.GetOutOfOrder(&Flags{}); got != "error" {
+		t.Errorf("GetOutOfOrder() = %q, want error", got)
+	}
+	if got := cfg.GetOutOfOrder(&Flags{OutOfOrder: "allow"}); got != "allow" {
+		t.Errorf("GetOutOfOrder() with flag = %q, want allow", got)
+	}
+}
+
+func TestGetAllowHazards(t *testing.T) {
+	cfg := &Config{}
+	if got := cfg.GetAllowHazards(nil); len(got) != 0 {
+		t.Errorf("GetAllowHazards() default = %v, want none", got)
+	}
+
+	cfg.AllowHazards = []string{"index-build"}
+	if got := cfg.GetAllowHazards(&Flags{}); len(got) != 1 || got[0] != "index-build" {
+		t.Errorf("GetAllowHazards() = %v, want [index-build]", got)
+	}
+	got := cfg.GetAllowHazards(&Flags{AllowHazards: []string{"table-rewrite", "data-loss"}})
+	if len(got) != 2 || got[0] != "table-rewrite" {
+		t.Errorf("GetAllowHazards() with flag = %v, want [table-rewrite data-loss]", got)
+	}
+}
+
+func TestGetSkipHazardCheck(t *testing.T) {
+	cfg := &Config{}
+	if cfg.GetSkipHazardCheck(nil) {
+		t.Error("GetSkipHazardCheck default should be false")
+	}
+	if !cfg.GetSkipHazardCheck(&Flags{SkipHazardCheck: true}) {
+		t.Error("GetSkipHazardCheck should honor flag")
+	}
+
+	cfg.SkipHazardCheck = true
+	if !cfg.GetSkipHazardCheck(&Flags{}) {
+		t.Error("GetSkipHazardCheck should honor config")
+	}
+}
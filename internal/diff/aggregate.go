@@ -16,7 +16,7 @@ func (c *AggregateChange) SQL() string {
 	switch c.ChangeType {
 	case CreateAggregate:
 		sql := fmt.Sprintf("CREATE AGGREGATE %s(%s) (\n    SFUNC = %s,\n    STYPE = %s",
-			quoteIdent(c.Aggregate.Name), c.Aggregate.Args, c.Aggregate.SFunc, c.Aggregate.SType)
+			qualifiedName(c.Aggregate.Schema, c.Aggregate.Name), c.Aggregate.Args, c.Aggregate.SFunc, c.Aggregate.SType)
 		if c.Aggregate.FinalFunc != "" {
 			sql += fmt.Sprintf(",\n    FINALFUNC = %s", c.Aggregate.FinalFunc)
 		}
@@ -28,7 +28,7 @@ func (c *AggregateChange) SQL() string {
 		}
 		return sql + "\n);"
 	case DropAggregate:
-		return fmt.Sprintf("DROP AGGREGATE %s(%s);", quoteIdent(c.Aggregate.Name), c.Aggregate.Args)
+		return fmt.Sprintf("DROP AGGREGATE %s(%s);", qualifiedName(c.Aggregate.Schema, c.Aggregate.Name), c.Aggregate.Args)
 	}
 	return ""
 }
@@ -36,11 +36,11 @@ func (c *AggregateChange) SQL() string {
 func (c *AggregateChange) DownSQL() string {
 	switch c.ChangeType {
 	case CreateAggregate:
-		return fmt.Sprintf("DROP AGGREGATE %s(%s);", quoteIdent(c.Aggregate.Name), c.Aggregate.Args)
+		return fmt.Sprintf("DROP AGGREGATE %s(%s);", qualifiedName(c.Aggregate.Schema, c.Aggregate.Name), c.Aggregate.Args)
 	case DropAggregate:
 		if c.OldAggregate != nil {
 			sql := fmt.Sprintf("CREATE AGGREGATE %s(%s) (\n    SFUNC = %s,\n    STYPE = %s",
-				quoteIdent(c.OldAggregate.Name), c.OldAggregate.Args, c.OldAggregate.SFunc, c.OldAggregate.SType)
+				qualifiedName(c.OldAggregate.Schema, c.OldAggregate.Name), c.OldAggregate.Args, c.OldAggregate.SFunc, c.OldAggregate.SType)
 			if c.OldAggregate.FinalFunc != "" {
 				sql += fmt.Sprintf(",\n    FINALFUNC = %s", c.OldAggregate.FinalFunc)
 			}
@@ -71,22 +71,22 @@ func compareAggregates(current, desired []parser.Aggregate) []Change {
 
 	currentMap := make(map[string]parser.Aggregate)
 	for _, a := range current {
-		currentMap[a.Name] = a
+		currentMap[objectKey(a.Schema, a.Name)] = a
 	}
 
 	desiredMap := make(map[string]parser.Aggregate)
 	for _, a := range desired {
-		desiredMap[a.Name] = a
+		desiredMap[objectKey(a.Schema, a.Name)] = a
 	}
 
 	for _, a := range desired {
-		if _, exists := currentMap[a.Name]; !exists {
+		if _, exists := currentMap[objectKey(a.Schema, a.Name)]; !exists {
 			changes = append(changes, &AggregateChange{ChangeType: CreateAggregate, Aggregate: a})
 		}
 	}
 
 	for _, a := range current {
-		if _, exists := desiredMap[a.Name]; !exists {
+		if _, exists := desiredMap[objectKey(a.Schema, a.Name)]; !exists {
 			oldAgg := a
 			changes = append(changes, &AggregateChange{ChangeType: DropAggregate, Aggregate: a, OldAggregate: &oldAgg})
 		}
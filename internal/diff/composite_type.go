@@ -24,6 +24,10 @@ func (c *CompositeTypeChange) SQL() string {
 		return fmt.Sprintf("CREATE TYPE %s AS (%s);", typeName, strings.Join(attrs, ", "))
 	case DropCompositeType:
 		return fmt.Sprintf("DROP TYPE %s;", typeName)
+	case AlterCompositeType:
+		if c.OldCompositeType != nil {
+			return generateAlterCompositeType(*c.OldCompositeType, c.CompositeType)
+		}
 	}
 	return ""
 }
@@ -44,6 +48,11 @@ func (c *CompositeTypeChange) DownSQL() string {
 				strings.Join(attrs, ", "))
 		}
 		return fmt.Sprintf("-- IRREVERSIBLE: Cannot restore dropped type %s", c.CompositeType.Name)
+	case AlterCompositeType:
+		if c.OldCompositeType != nil {
+			return generateAlterCompositeType(c.CompositeType, *c.OldCompositeType)
+		}
+		return fmt.Sprintf("-- IRREVERSIBLE: Cannot restore previous type %s", c.CompositeType.Name)
 	}
 	return ""
 }
@@ -51,7 +60,18 @@ func (c *CompositeTypeChange) DownSQL() string {
 func (c *CompositeTypeChange) Type() ChangeType   { return c.ChangeType }
 func (c *CompositeTypeChange) ObjectName() string { return c.CompositeType.Name }
 func (c *CompositeTypeChange) IsReversible() bool {
-	if c.ChangeType == CreateCompositeType {
+	switch c.ChangeType {
+	case CreateCompositeType:
+		return true
+	case AlterCompositeType:
+		if c.OldCompositeType == nil {
+			return false
+		}
+		for _, a := range c.OldCompositeType.Attributes {
+			if findAttribute(c.CompositeType.Attributes, a.Name) == nil {
+				return false
+			}
+		}
 		return true
 	}
 	return c.OldCompositeType != nil
@@ -71,8 +91,11 @@ func compareCompositeTypes(current, desired []parser.CompositeType) []Change {
 	}
 
 	for _, t := range desired {
-		if _, exists := currentMap[objectKey(t.Schema, t.Name)]; !exists {
+		if existing, exists := currentMap[objectKey(t.Schema, t.Name)]; !exists {
 			changes = append(changes, &CompositeTypeChange{ChangeType: CreateCompositeType, CompositeType: t})
+		} else if generateAlterCompositeType(existing, t) != "" {
+			oldType := existing
+			changes = append(changes, &CompositeTypeChange{ChangeType: AlterCompositeType, CompositeType: t, OldCompositeType: &oldType})
 		}
 	}
 
@@ -85,3 +108,33 @@ func compareCompositeTypes(current, desired []parser.CompositeType) []Change {
 
 	return changes
 }
+
+func generateAlterCompositeType(from, to parser.CompositeType) string {
+	typeName := qualifiedName(to.Schema, to.Name)
+	var stmts []string
+
+	for _, a := range from.Attributes {
+		if findAttribute(to.Attributes, a.Name) == nil {
+			stmts = append(stmts, fmt.Sprintf("ALTER TYPE %s DROP ATTRIBUTE %s;", typeName, quoteIdent(a.Name)))
+		}
+	}
+	for _, a := range to.Attributes {
+		prev := findAttribute(from.Attributes, a.Name)
+		if prev == nil {
+			stmts = append(stmts, fmt.Sprintf("ALTER TYPE %s ADD ATTRIBUTE %s %s;", typeName, quoteIdent(a.Name), a.Type))
+		} else if normalizeType(prev.Type) != normalizeType(a.Type) {
+			stmts = append(stmts, fmt.Sprintf("ALTER TYPE %s ALTER ATTRIBUTE %s TYPE %s;", typeName, quoteIdent(a.Name), a.Type))
+		}
+	}
+
+	return strings.Join(stmts, "\n")
+}
+
+func findAttribute(attrs []parser.Column, name string) *parser.Column {
+	for i := range attrs {
+		if attrs[i].Name == name {
+			return &attrs[i]
+		}
+	}
+	return nil
+}
@@ -189,3 +189,45 @@ func TestCompositeTypeChange_IsReversible(t *testing.T) {
 		t.Error("DropCompositeType without OldCompositeType should not be reversible")
 	}
 }
+
+func TestCompare_AlterCompositeType(t *testing.T) {
+	current := &parser.Schema{
+		CompositeTypes: []parser.CompositeType{{
+			Name:       "address",
+			Attributes: []parser.Column{{Name: "street", Type: "text"}, {Name: "zip", Type: "integer"}, {Name: "legacy", Type: "text"}},
+		}},
+	}
+	desired := &parser.Schema{
+		CompositeTypes: []parser.CompositeType{{
+			Name:       "address",
+			Attributes: []parser.Column{{Name: "street", Type: "text"}, {Name: "zip", Type: "text"}, {Name: "country", Type: "text"}},
+		}},
+	}
+
+	changes := Compare(current, desired)
+	if len(changes) != 1 || changes[0].Type() != AlterCompositeType {
+		t.Fatalf("expected a single AlterCompositeType change, got %d", len(changes))
+	}
+
+	wantUp := strings.Join([]string{
+		"ALTER TYPE address DROP ATTRIBUTE legacy;",
+		"ALTER TYPE address ALTER ATTRIBUTE zip TYPE text;",
+		"ALTER TYPE address ADD ATTRIBUTE country text;",
+	}, "\n")
+	if got := changes[0].SQL(); got != wantUp {
+		t.Errorf("SQL =\n%s\nwant\n%s", got, wantUp)
+	}
+
+	wantDown := strings.Join([]string{
+		"ALTER TYPE address DROP ATTRIBUTE country;",
+		"ALTER TYPE address ALTER ATTRIBUTE zip TYPE integer;",
+		"ALTER TYPE address ADD ATTRIBUTE legacy text;",
+	}, "\n")
+	if got := changes[0].DownSQL(); got != wantDown {
+		t.Errorf("DownSQL =\n%s\nwant\n%s", got, wantDown)
+	}
+
+	if changes[0].IsReversible() {
+		t.Error("dropping an attribute loses data and should not be reversible")
+	}
+}
@@ -21,12 +21,15 @@ const (
 	AlterDomain
 	CreateCompositeType
 	DropCompositeType
+	AlterCompositeType
 	CreateSequence
 	DropSequence
 	AlterSequence
 	CreateTable
 	DropTable
 	AlterTable
+	RenameTable
+	RenameColumn
 	CreateIndex
 	DropIndex
 	CreateView
@@ -51,6 +54,7 @@ const (
 	DropRule
 	CreatePolicy
 	DropPolicy
+	AlterPolicy
 	CreateCollation
 	DropCollation
 	CreateTextSearchConfig
@@ -91,17 +95,31 @@ type Change interface {
 	IsReversible() bool
 }
 
+type Options struct {
+	ConcurrentIndexes bool
+	Online            bool
+	Renames           []parser.Rename
+}
+
 func Compare(current, desired *parser.Schema) []Change {
+	return CompareWithOptions(current, desired, Options{})
+}
+
+func CompareWithOptions(current, desired *parser.Schema, opts Options) []Change {
 	var changes []Change
 
+	renameChanges, current := compareRenames(current, desired, opts.Renames)
+
 	changes = append(changes, compareNamespaces(current.Namespaces, desired.Namespaces)...)
 	changes = append(changes, compareExtensions(current.Extensions, desired.Extensions)...)
-	changes = append(changes, compareEnums(current.Enums, desired.Enums)...)
+	changes = append(changes, compareEnums(current.Enums, desired.Enums, current.Tables, desired.Tables, opts.Renames)...)
 	changes = append(changes, compareDomains(current.Domains, desired.Domains)...)
 	changes = append(changes, compareCompositeTypes(current.CompositeTypes, desired.CompositeTypes)...)
 	changes = append(changes, compareSequences(current.Sequences, desired.Sequences)...)
-	changes = append(changes, compareTables(current.Tables, desired.Tables)...)
-	changes = append(changes, compareIndexes(current.Indexes, desired.Indexes)...)
+	changes = append(changes, renameChanges...)
+	changes = append(changes, compareTables(current.Tables, desired.Tables, opts)...)
+	changes = append(changes, compareIndexes(current.Indexes, desired.Indexes, opts)...)
+	changes = append(changes, compareReplicaIdentities(current.Tables, desired.Tables)...)
 	changes = append(changes, compareViews(current.Views, desired.Views)...)
 	changes = append(changes, compareMaterializedViews(current.MaterializedViews, desired.MaterializedViews)...)
 	changes = append(changes, compareFunctions(current.Functions, desired.Functions)...)
@@ -124,7 +142,7 @@ func Compare(current, desired *parser.Schema) []Change {
 	changes = append(changes, compareDefaultPrivileges(current.DefaultPrivileges, desired.DefaultPrivileges)...)
 	changes = append(changes, compareComments(current.Comments, desired.Comments)...)
 
-	return changes
+	return orderChanges(changes, current, desired)
 }
 
 func quoteIdent(s string) string {
@@ -186,6 +204,10 @@ func objectKey(schema, name string) string {
 	return schema + "." + name
 }
 
+func tableObjectKey(schema, table, name string) string {
+	return objectKey(schema, table) + ":" + name
+}
+
 func quoteIdents(names []string) []string {
 	result := make([]string, len(names))
 	for i, n := range names {
@@ -313,3 +313,207 @@ func TestObjectKey(t *testing.T) {
 		})
 	}
 }
+
+func TestTableObjectKey(t *testing.T) {
+	if got := tableObjectKey("", "users", "trg"); got != "public.users:trg" {
+		t.Errorf("tableObjectKey = %q, want %q", got, "public.users:trg")
+	}
+	if tableObjectKey("billing", "users", "trg") == tableObjectKey("auth", "users", "trg") {
+		t.Error("tableObjectKey should differ across schemas")
+	}
+	if tableObjectKey("public", "users", "trg") == tableObjectKey("public", "accounts", "trg") {
+		t.Error("tableObjectKey should differ across tables")
+	}
+}
+
+func TestCompare_SameNameDifferentSchemas(t *testing.T) {
+	tests := []struct {
+		name    string
+		schema  func(schemas ...string) *parser.Schema
+		created ChangeType
+		dropped ChangeType
+	}{
+		{
+			name: "enums",
+			schema: func(schemas ...string) *parser.Schema {
+				s := &parser.Schema{}
+				for _, sc := range schemas {
+					s.Enums = append(s.Enums, parser.Enum{Schema: sc, Name: "status", Values: []string{"a"}})
+				}
+				return s
+			},
+			created: CreateEnum,
+			dropped: DropEnum,
+		},
+		{
+			name: "views",
+			schema: func(schemas ...string) *parser.Schema {
+				s := &parser.Schema{}
+				for _, sc := range schemas {
+					s.Views = append(s.Views, parser.View{Schema: sc, Name: "summary", Definition: "SELECT 1"})
+				}
+				return s
+			},
+			created: CreateView,
+			dropped: DropView,
+		},
+		{
+			name: "functions",
+			schema: func(schemas ...string) *parser.Schema {
+				s := &parser.Schema{}
+				for _, sc := range schemas {
+					s.Functions = append(s.Functions, parser.Function{Schema: sc, Name: "now_utc", Returns: "timestamp", Language: "sql", Body: "SELECT now()"})
+				}
+				return s
+			},
+			created: CreateFunction,
+			dropped: DropFunction,
+		},
+		{
+			name: "sequences",
+			schema: func(schemas ...string) *parser.Schema {
+				s := &parser.Schema{}
+				for _, sc := range schemas {
+					s.Sequences = append(s.Sequences, parser.Sequence{Schema: sc, Name: "ids"})
+				}
+				return s
+			},
+			created: CreateSequence,
+			dropped: DropSequence,
+		},
+		{
+			name: "indexes",
+			schema: func(schemas ...string) *parser.Schema {
+				s := &parser.Schema{}
+				for _, sc := range schemas {
+					s.Indexes = append(s.Indexes, parser.Index{Schema: sc, Name: "idx_status", Table: "accounts", Columns: []string{"status"}})
+				}
+				return s
+			},
+			created: CreateIndex,
+			dropped: DropIndex,
+		},
+		{
+			name: "triggers",
+			schema: func(schemas ...string) *parser.Schema {
+				s := &parser.Schema{}
+				for _, sc := range schemas {
+					s.Triggers = append(s.Triggers, parser.Trigger{Schema: sc, Name: "touch", Table: "accounts"})
+				}
+				return s
+			},
+			created: CreateTrigger,
+			dropped: DropTrigger,
+		},
+		{
+			name: "rules",
+			schema: func(schemas ...string) *parser.Schema {
+				s := &parser.Schema{}
+				for _, sc := range schemas {
+					s.Rules = append(s.Rules, parser.Rule{Schema: sc, Name: "no_delete", Table: "accounts"})
+				}
+				return s
+			},
+			created: CreateRule,
+			dropped: DropRule,
+		},
+		{
+			name: "policies",
+			schema: func(schemas ...string) *parser.Schema {
+				s := &parser.Schema{}
+				for _, sc := range schemas {
+					s.Policies = append(s.Policies, parser.Policy{Schema: sc, Name: "tenant", Table: "accounts", Command: "ALL", Permissive: true})
+				}
+				return s
+			},
+			created: CreatePolicy,
+			dropped: DropPolicy,
+		},
+		{
+			name: "aggregates",
+			schema: func(schemas ...string) *parser.Schema {
+				s := &parser.Schema{}
+				for _, sc := range schemas {
+					s.Aggregates = append(s.Aggregates, parser.Aggregate{Schema: sc, Name: "total", Args: "integer", SFunc: "int4pl", SType: "integer"})
+				}
+				return s
+			},
+			created: CreateAggregate,
+			dropped: DropAggregate,
+		},
+		{
+			name: "text search configs",
+			schema: func(schemas ...string) *parser.Schema {
+				s := &parser.Schema{}
+				for _, sc := range schemas {
+					s.TextSearchConfigs = append(s.TextSearchConfigs, parser.TextSearchConfig{Schema: sc, Name: "english", Parser: "default"})
+				}
+				return s
+			},
+			created: CreateTextSearchConfig,
+			dropped: DropTextSearchConfig,
+		},
+		{
+			name: "foreign tables",
+			schema: func(schemas ...string) *parser.Schema {
+				s := &parser.Schema{}
+				for _, sc := range schemas {
+					s.ForeignTables = append(s.ForeignTables, parser.ForeignTable{Schema: sc, Name: "remote", Server: "srv"})
+				}
+				return s
+			},
+			created: CreateForeignTable,
+			dropped: DropForeignTable,
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			changes := Compare(tt.schema("billing"), tt.schema("billing", "auth"))
+			if len(changes) != 1 || changes[0].Type() != tt.created {
+				t.Fatalf("adding auth object: expected single create, got %d change(s)", len(changes))
+			}
+
+			changes = Compare(tt.schema("billing", "auth"), tt.schema("billing", "auth"))
+			if len(changes) != 0 {
+				t.Errorf("identical multi-schema objects: expected 0 changes, got %d", len(changes))
+			}
+
+			changes = Compare(tt.schema("billing"), tt.schema("auth"))
+			var creates, drops int
+			for _, c := range changes {
+				switch c.Type() {
+				case tt.created:
+					creates++
+				case tt.dropped:
+					drops++
+				}
+			}
+			if creates != 1 || drops != 1 {
+				t.Errorf("moving object between schemas: got %d create(s) and %d drop(s), want 1 and 1", creates, drops)
+			}
+		})
+	}
+}
+
+func TestCompare_TableScopedObjectsOnDifferentTables(t *testing.T) {
+	current := &parser.Schema{
+		Triggers: []parser.Trigger{{Name: "touch", Table: "accounts"}},
+		Policies: []parser.Policy{{Name: "tenant", Table: "accounts", Command: "ALL", Permissive: true}},
+	}
+	desired := &parser.Schema{
+		Triggers: []parser.Trigger{{Name: "touch", Table: "accounts"}, {Name: "touch", Table: "invoices"}},
+		Policies: []parser.Policy{{Name: "tenant", Table: "accounts", Command: "ALL", Permissive: true}, {Name: "tenant", Table: "invoices", Command: "ALL", Permissive: true}},
+	}
+
+	changes := Compare(current, desired)
+
+	if len(changes) != 2 {
+		t.Fatalf("expected 2 changes, got %d", len(changes))
+	}
+	for _, c := range changes {
+		if c.Type() != CreateTrigger && c.Type() != CreatePolicy {
+			t.Errorf("unexpected change type %v", c.Type())
+		}
+	}
+}
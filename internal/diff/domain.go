@@ -2,6 +2,7 @@ package diff
 
 import (
 	"fmt"
+	"strings"
 
 	"github.com/terminally-online/shrugged/internal/parser"
 )
@@ -13,52 +14,33 @@ type DomainChange struct {
 }
 
 func (c *DomainChange) SQL() string {
-	domainName := qualifiedName(c.Domain.Schema, c.Domain.Name)
 	switch c.ChangeType {
 	case CreateDomain:
-		sql := fmt.Sprintf("CREATE DOMAIN %s AS %s", domainName, c.Domain.Type)
-		if c.Domain.Collation != "" {
-			sql += fmt.Sprintf(" COLLATE %s", quoteIdent(c.Domain.Collation))
-		}
-		if c.Domain.Default != "" {
-			sql += fmt.Sprintf(" DEFAULT %s", c.Domain.Default)
-		}
-		if c.Domain.NotNull {
-			sql += " NOT NULL"
-		}
-		if c.Domain.Check != "" {
-			sql += fmt.Sprintf(" %s", c.Domain.Check)
-		}
-		return sql + ";"
+		return generateCreateDomain(c.Domain)
 	case DropDomain:
-		return fmt.Sprintf("DROP DOMAIN %s;", domainName)
+		return fmt.Sprintf("DROP DOMAIN %s;", qualifiedName(c.Domain.Schema, c.Domain.Name))
+	case AlterDomain:
+		if c.OldDomain != nil {
+			return generateAlterDomain(*c.OldDomain, c.Domain)
+		}
 	}
 	return ""
 }
 
 func (c *DomainChange) DownSQL() string {
-	domainName := qualifiedName(c.Domain.Schema, c.Domain.Name)
 	switch c.ChangeType {
 	case CreateDomain:
-		return fmt.Sprintf("DROP DOMAIN %s;", domainName)
+		return fmt.Sprintf("DROP DOMAIN %s;", qualifiedName(c.Domain.Schema, c.Domain.Name))
 	case DropDomain:
 		if c.OldDomain != nil {
-			sql := fmt.Sprintf("CREATE DOMAIN %s AS %s", qualifiedName(c.OldDomain.Schema, c.OldDomain.Name), c.OldDomain.Type)
-			if c.OldDomain.Collation != "" {
-				sql += fmt.Sprintf(" COLLATE %s", quoteIdent(c.OldDomain.Collation))
-			}
-			if c.OldDomain.Default != "" {
-				sql += fmt.Sprintf(" DEFAULT %s", c.OldDomain.Default)
-			}
-			if c.OldDomain.NotNull {
-				sql += " NOT NULL"
-			}
-			if c.OldDomain.Check != "" {
-				sql += fmt.Sprintf(" %s", c.OldDomain.Check)
-			}
-			return sql + ";"
+			return generateCreateDomain(*c.OldDomain)
 		}
 		return fmt.Sprintf("-- IRREVERSIBLE: Cannot restore dropped domain %s", c.Domain.Name)
+	case AlterDomain:
+		if c.OldDomain != nil {
+			return generateAlterDomain(c.Domain, *c.OldDomain)
+		}
+		return fmt.Sprintf("-- IRREVERSIBLE: Cannot restore previous domain %s", c.Domain.Name)
 	}
 	return ""
 }
@@ -86,8 +68,18 @@ func compareDomains(current, desired []parser.Domain) []Change {
 	}
 
 	for _, d := range desired {
-		if _, exists := currentMap[objectKey(d.Schema, d.Name)]; !exists {
+		existing, exists := currentMap[objectKey(d.Schema, d.Name)]
+		if !exists {
+			changes = append(changes, &DomainChange{ChangeType: CreateDomain, Domain: d})
+			continue
+		}
+
+		oldDom := existing
+		if normalizeType(existing.Type) != normalizeType(d.Type) || existing.Collation != d.Collation {
+			changes = append(changes, &DomainChange{ChangeType: DropDomain, Domain: existing, OldDomain: &oldDom})
 			changes = append(changes, &DomainChange{ChangeType: CreateDomain, Domain: d})
+		} else if generateAlterDomain(existing, d) != "" {
+			changes = append(changes, &DomainChange{ChangeType: AlterDomain, Domain: d, OldDomain: &oldDom})
 		}
 	}
 
@@ -100,3 +92,79 @@ func compareDomains(current, desired []parser.Domain) []Change {
 
 	return changes
 }
+
+func generateCreateDomain(d parser.Domain) string {
+	sql := fmt.Sprintf("CREATE DOMAIN %s AS %s", qualifiedName(d.Schema, d.Name), d.Type)
+	if d.Collation != "" {
+		sql += fmt.Sprintf(" COLLATE %s", quoteIdent(d.Collation))
+	}
+	if d.Default != "" {
+		sql += fmt.Sprintf(" DEFAULT %s", d.Default)
+	}
+	if d.NotNull {
+		sql += " NOT NULL"
+	}
+	if d.Check != "" {
+		sql += fmt.Sprintf(" %s", d.Check)
+	}
+	for _, c := range d.Constraints {
+		sql += fmt.Sprintf(" CONSTRAINT %s %s", quoteIdent(c.Name), domainConstraintDef(c))
+	}
+	return sql + ";"
+}
+
+// generateAlterDomain returns the statements that turn one version of a
+// domain into another, or an empty string when nothing alterable differs.
+func generateAlterDomain(from, to parser.Domain) string {
+	domainName := qualifiedName(to.Schema, to.Name)
+	var stmts []string
+
+	if from.Default != to.Default {
+		if to.Default == "" {
+			stmts = append(stmts, fmt.Sprintf("ALTER DOMAIN %s DROP DEFAULT;", domainName))
+		} else {
+			stmts = append(stmts, fmt.Sprintf("ALTER DOMAIN %s SET DEFAULT %s;", domainName, to.Default))
+		}
+	}
+
+	if from.NotNull != to.NotNull {
+		if to.NotNull {
+			stmts = append(stmts, fmt.Sprintf("ALTER DOMAIN %s SET NOT NULL;", domainName))
+		} else {
+			stmts = append(stmts, fmt.Sprintf("ALTER DOMAIN %s DROP NOT NULL;", domainName))
+		}
+	}
+
+	fromConstraints := make(map[string]parser.Constraint)
+	for _, c := range from.Constraints {
+		fromConstraints[c.Name] = c
+	}
+	toConstraints := make(map[string]parser.Constraint)
+	for _, c := range to.Constraints {
+		toConstraints[c.Name] = c
+	}
+
+	for _, c := range from.Constraints {
+		if next, exists := toConstraints[c.Name]; !exists || !domainConstraintsEqual(c, next) {
+			stmts = append(stmts, fmt.Sprintf("ALTER DOMAIN %s DROP CONSTRAINT %s;", domainName, quoteIdent(c.Name)))
+		}
+	}
+	for _, c := range to.Constraints {
+		if prev, exists := fromConstraints[c.Name]; !exists || !domainConstraintsEqual(prev, c) {
+			stmts = append(stmts, fmt.Sprintf("ALTER DOMAIN %s ADD CONSTRAINT %s %s;", domainName, quoteIdent(c.Name), domainConstraintDef(c)))
+		}
+	}
+
+	return strings.Join(stmts, "\n")
+}
+
+func domainConstraintDef(c parser.Constraint) string {
+	if c.Definition != "" {
+		return c.Definition
+	}
+	return fmt.Sprintf("CHECK (%s)", c.Check)
+}
+
+func domainConstraintsEqual(a, b parser.Constraint) bool {
+	return normalizeConstraintDef(domainConstraintDef(a)) == normalizeConstraintDef(domainConstraintDef(b))
+}
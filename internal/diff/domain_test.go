@@ -172,3 +172,67 @@ func TestDomainChange_IsReversible(t *testing.T) {
 		t.Error("DropDomain without OldDomain should not be reversible")
 	}
 }
+
+func TestCompare_AlterDomain(t *testing.T) {
+	current := &parser.Schema{
+		Domains: []parser.Domain{{
+			Name:    "price",
+			Type:    "numeric",
+			Default: "0",
+			Constraints: []parser.Constraint{
+				{Name: "price_positive", Type: "CHECK", Definition: "CHECK (VALUE >= 0)"},
+				{Name: "price_small", Type: "CHECK", Definition: "CHECK (VALUE < 1000)"},
+			},
+		}},
+	}
+	desired := &parser.Schema{
+		Domains: []parser.Domain{{
+			Name:    "price",
+			Type:    "numeric",
+			NotNull: true,
+			Constraints: []parser.Constraint{
+				{Name: "price_positive", Type: "CHECK", Definition: "CHECK (VALUE > 0)"},
+				{Name: "price_cap", Type: "CHECK", Definition: "CHECK (VALUE < 10000)"},
+			},
+		}},
+	}
+
+	changes := Compare(current, desired)
+	if len(changes) != 1 || changes[0].Type() != AlterDomain {
+		t.Fatalf("expected a single AlterDomain change, got %d", len(changes))
+	}
+
+	wantUp := strings.Join([]string{
+		"ALTER DOMAIN price DROP DEFAULT;",
+		"ALTER DOMAIN price SET NOT NULL;",
+		"ALTER DOMAIN price DROP CONSTRAINT price_positive;",
+		"ALTER DOMAIN price DROP CONSTRAINT price_small;",
+		"ALTER DOMAIN price ADD CONSTRAINT price_positive CHECK (VALUE > 0);",
+		"ALTER DOMAIN price ADD CONSTRAINT price_cap CHECK (VALUE < 10000);",
+	}, "\n")
+	if got := changes[0].SQL(); got != wantUp {
+		t.Errorf("SQL =\n%s\nwant\n%s", got, wantUp)
+	}
+
+	wantDown := strings.Join([]string{
+		"ALTER DOMAIN price SET DEFAULT 0;",
+		"ALTER DOMAIN price DROP NOT NULL;",
+		"ALTER DOMAIN price DROP CONSTRAINT price_positive;",
+		"ALTER DOMAIN price DROP CONSTRAINT price_cap;",
+		"ALTER DOMAIN price ADD CONSTRAINT price_positive CHECK (VALUE >= 0);",
+		"ALTER DOMAIN price ADD CONSTRAINT price_small CHECK (VALUE < 1000);",
+	}, "\n")
+	if got := changes[0].DownSQL(); got != wantDown {
+		t.Errorf("DownSQL =\n%s\nwant\n%s", got, wantDown)
+	}
+}
+
+func TestCompare_DomainBaseTypeChanged(t *testing.T) {
+	current := &parser.Schema{Domains: []parser.Domain{{Name: "code", Type: "varchar(10)"}}}
+	desired := &parser.Schema{Domains: []parser.Domain{{Name: "code", Type: "text"}}}
+
+	changes := Compare(current, desired)
+	if len(changes) != 2 || changes[0].Type() != DropDomain || changes[1].Type() != CreateDomain {
+		t.Fatalf("expected DropDomain then CreateDomain, got %d changes", len(changes))
+	}
+}
@@ -2,16 +2,35 @@ package diff
 
 import (
 	"fmt"
+	"slices"
 	"strings"
 
 	"github.com/terminally-online/shrugged/internal/parser"
 )
 
 type EnumChange struct {
-	ChangeType ChangeType
-	Enum       parser.Enum
-	OldEnum    *parser.Enum
-	AddValues  []string
+	ChangeType   ChangeType
+	Enum         parser.Enum
+	OldEnum      *parser.Enum
+	AddValues    []string
+	RenameValues []EnumValueRename
+	Recreate     bool
+	Columns      []EnumColumn
+}
+
+type EnumValueRename struct {
+	From string
+	To   string
+}
+
+// NewDefault is the column's default in the desired schema.
+type EnumColumn struct {
+	Schema     string
+	Table      string
+	Column     string
+	Default    string
+	NewDefault string
+	Array      bool
 }
 
 func (c *EnumChange) SQL() string {
@@ -23,11 +42,15 @@ func (c *EnumChange) SQL() string {
 	case DropEnum:
 		return fmt.Sprintf("DROP TYPE %s;", qualifiedName(c.Enum.Schema, c.Enum.Name))
 	case AlterEnum:
-		var stmts []string
+		stmts := c.renameValueStatements(false)
+		if c.Recreate {
+			return strings.Join(append(stmts, generateEnumSwap(c.Enum, c.Columns, false)), "\n")
+		}
 		for _, v := range c.AddValues {
-			stmts = append(stmts, fmt.Sprintf("ALTER TYPE %s ADD VALUE %s;",
+			stmts = append(stmts, fmt.Sprintf("ALTER TYPE %s ADD VALUE %s%s;",
 				qualifiedName(c.Enum.Schema, c.Enum.Name),
-				quoteLiteral(v)))
+				quoteLiteral(v),
+				enumValuePosition(c.Enum.Values, c.AddValues, v)))
 		}
 		return strings.Join(stmts, "\n")
 	}
@@ -46,11 +69,36 @@ func (c *EnumChange) DownSQL() string {
 		}
 		return fmt.Sprintf("-- IRREVERSIBLE: Cannot restore dropped enum %s", c.Enum.Name)
 	case AlterEnum:
+		if c.Recreate && c.OldEnum != nil {
+			renamed := *c.OldEnum
+			renamed.Values = renameEnumValues(c.OldEnum.Values, c.RenameValues)
+			return strings.Join(append([]string{generateEnumSwap(renamed, c.Columns, true)}, c.renameValueStatements(true)...), "\n")
+		}
+		if len(c.AddValues) == 0 {
+			return strings.Join(c.renameValueStatements(true), "\n")
+		}
 		return fmt.Sprintf("-- IRREVERSIBLE: Cannot remove enum values from %s", c.Enum.Name)
 	}
 	return ""
 }
 
+func (c *EnumChange) renameValueStatements(reverse bool) []string {
+	var stmts []string
+	for i := range c.RenameValues {
+		r := c.RenameValues[i]
+		from, to := r.From, r.To
+		if reverse {
+			r = c.RenameValues[len(c.RenameValues)-1-i]
+			from, to = r.To, r.From
+		}
+		stmts = append(stmts, fmt.Sprintf("ALTER TYPE %s RENAME VALUE %s TO %s;",
+			qualifiedName(c.Enum.Schema, c.Enum.Name),
+			quoteLiteral(from),
+			quoteLiteral(to)))
+	}
+	return stmts
+}
+
 func (c *EnumChange) Type() ChangeType {
 	return c.ChangeType
 }
@@ -66,54 +114,231 @@ func (c *EnumChange) IsReversible() bool {
 	case DropEnum:
 		return c.OldEnum != nil
 	case AlterEnum:
-		return false
+		if c.Recreate {
+			return c.OldEnum != nil
+		}
+		return len(c.AddValues) == 0
 	}
 	return false
 }
 
-func compareEnums(current, desired []parser.Enum) []Change {
+func compareEnums(current, desired []parser.Enum, currentTables, desiredTables []parser.Table, renames []parser.Rename) []Change {
 	var changes []Change
 
 	currentMap := make(map[string]parser.Enum)
 	for _, e := range current {
-		currentMap[e.Name] = e
+		currentMap[objectKey(e.Schema, e.Name)] = e
 	}
 
 	desiredMap := make(map[string]parser.Enum)
 	for _, e := range desired {
-		desiredMap[e.Name] = e
+		desiredMap[objectKey(e.Schema, e.Name)] = e
 	}
 
 	for _, e := range desired {
-		if existing, exists := currentMap[e.Name]; !exists {
+		existing, exists := currentMap[objectKey(e.Schema, e.Name)]
+		if !exists {
 			changes = append(changes, &EnumChange{ChangeType: CreateEnum, Enum: e})
+			continue
+		}
+		if change := compareEnumValues(existing, e, currentTables, desiredTables, renames); change != nil {
+			changes = append(changes, change)
+		}
+	}
+
+	for _, e := range current {
+		if _, exists := desiredMap[objectKey(e.Schema, e.Name)]; !exists {
+			oldEnum := e
+			changes = append(changes, &EnumChange{ChangeType: DropEnum, Enum: e, OldEnum: &oldEnum})
+		}
+	}
+
+	return changes
+}
+
+func compareEnumValues(existing, desired parser.Enum, currentTables, desiredTables []parser.Table, renames []parser.Rename) *EnumChange {
+	oldEnum := existing
+	change := &EnumChange{ChangeType: AlterEnum, Enum: desired, OldEnum: &oldEnum}
+	change.RenameValues = enumValueRenames(existing, desired, renames)
+	existingList := renameEnumValues(existing.Values, change.RenameValues)
+
+	desiredValues := make(map[string]bool)
+	for _, v := range desired.Values {
+		desiredValues[v] = true
+	}
+	existingValues := make(map[string]bool)
+	var kept []string
+	for _, v := range existingList {
+		existingValues[v] = true
+		if desiredValues[v] {
+			kept = append(kept, v)
+		}
+	}
+
+	var keptInDesiredOrder []string
+	for _, v := range desired.Values {
+		if existingValues[v] {
+			keptInDesiredOrder = append(keptInDesiredOrder, v)
 		} else {
-			existingValues := make(map[string]bool)
-			for _, v := range existing.Values {
-				existingValues[v] = true
-			}
+			change.AddValues = append(change.AddValues, v)
+		}
+	}
 
-			var newValues []string
-			for _, v := range e.Values {
-				if !existingValues[v] {
-					newValues = append(newValues, v)
-				}
+	if len(kept) < len(existingList) || strings.Join(kept, "\x00") != strings.Join(keptInDesiredOrder, "\x00") {
+		change.AddValues = nil
+		change.Recreate = true
+		change.Columns = enumColumns(existing, currentTables, desiredTables)
+		return change
+	}
+
+	if len(change.AddValues) == 0 && len(change.RenameValues) == 0 {
+		return nil
+	}
+	return change
+}
+
+// Renames only apply while the old value exists only in the current enum and
+// the new one only in the desired enum.
+func enumValueRenames(existing, desired parser.Enum, renames []parser.Rename) []EnumValueRename {
+	var result []EnumValueRename
+	for _, r := range renames {
+		if r.Enum == "" || objectKey(r.Schema, r.Enum) != objectKey(desired.Schema, desired.Name) {
+			continue
+		}
+		if !slices.Contains(existing.Values, r.From) || slices.Contains(existing.Values, r.Value) ||
+			!slices.Contains(desired.Values, r.Value) || slices.Contains(desired.Values, r.From) {
+			continue
+		}
+		result = append(result, EnumValueRename{From: r.From, To: r.Value})
+	}
+	return result
+}
+
+func renameEnumValues(values []string, renames []EnumValueRename) []string {
+	renamed := append([]string(nil), values...)
+	for _, r := range renames {
+		if i := slices.Index(renamed, r.From); i >= 0 {
+			renamed[i] = r.To
+		}
+	}
+	return renamed
+}
+
+func detectEnumValueRenames(existing, desired parser.Enum, known []parser.Rename) []parser.Rename {
+	old := renameEnumValues(existing.Values, enumValueRenames(existing, desired, known))
+	if len(old) != len(desired.Values) {
+		return nil
+	}
+
+	var candidates []parser.Rename
+	for i := range old {
+		if old[i] == desired.Values[i] {
+			continue
+		}
+		if slices.Contains(desired.Values, old[i]) || slices.Contains(old, desired.Values[i]) {
+			return nil
+		}
+		candidates = append(candidates, parser.Rename{Schema: desired.Schema, Enum: desired.Name, Value: desired.Values[i], From: old[i]})
+	}
+	return candidates
+}
+
+// Added values are emitted in declared order, so the predecessor an added
+// value is placed after always exists by then.
+func enumValuePosition(values, added []string, value string) string {
+	addedSet := make(map[string]bool)
+	for _, v := range added {
+		addedSet[v] = true
+	}
+
+	for i, v := range values {
+		if v != value {
+			continue
+		}
+		if i > 0 {
+			return " AFTER " + quoteLiteral(values[i-1])
+		}
+		for _, next := range values[i+1:] {
+			if !addedSet[next] {
+				return " BEFORE " + quoteLiteral(next)
 			}
+		}
+	}
+	return ""
+}
 
-			if len(newValues) > 0 {
-				changes = append(changes, &EnumChange{ChangeType: AlterEnum, Enum: e, AddValues: newValues})
+func enumColumns(e parser.Enum, tables, desiredTables []parser.Table) []EnumColumn {
+	var columns []EnumColumn
+	for _, t := range tables {
+		for _, col := range t.Columns {
+			if !usesEnum(t, col, e) {
+				continue
+			}
+			column := EnumColumn{
+				Schema:  t.Schema,
+				Table:   t.Name,
+				Column:  col.Name,
+				Default: col.Default,
+				Array:   strings.HasSuffix(col.Type, "[]"),
+			}
+			if dt := findTable(desiredTables, t.Schema, t.Name); dt != nil {
+				if dc := findColumn(dt, col.Name); dc != nil {
+					column.NewDefault = dc.Default
+				}
 			}
+			columns = append(columns, column)
 		}
 	}
+	return columns
+}
 
-	for _, e := range current {
-		if _, exists := desiredMap[e.Name]; !exists {
-			oldEnum := e
-			changes = append(changes, &EnumChange{ChangeType: DropEnum, Enum: e, OldEnum: &oldEnum})
+// A bare type name belongs to TypeSchema, or to the table's schema when that
+// is unknown.
+func usesEnum(t parser.Table, col parser.Column, e parser.Enum) bool {
+	colType := strings.TrimSuffix(col.Type, "[]")
+	typeSchema := col.TypeSchema
+	if i := strings.LastIndex(colType, "."); i >= 0 {
+		typeSchema, colType = strings.Trim(colType[:i], `"`), strings.Trim(colType[i+1:], `"`)
+	} else if typeSchema == "" {
+		typeSchema = t.Schema
+	}
+	return objectKey(typeSchema, colType) == objectKey(e.Schema, e.Name)
+}
+
+// Going up, columns get the default from the desired schema, since the current
+// one may use a removed value.
+func generateEnumSwap(e parser.Enum, columns []EnumColumn, down bool) string {
+	typeName := qualifiedName(e.Schema, e.Name)
+	oldName := e.Name + "_old"
+
+	var stmts []string
+	stmts = append(stmts, fmt.Sprintf("ALTER TYPE %s RENAME TO %s;", typeName, quoteIdent(oldName)))
+	stmts = append(stmts, fmt.Sprintf("CREATE TYPE %s AS ENUM (%s);", typeName, quoteLiterals(e.Values)))
+
+	for _, col := range columns {
+		tableName := qualifiedName(col.Schema, col.Table)
+		colName := quoteIdent(col.Column)
+		oldDefault, newDefault := col.Default, col.NewDefault
+		if down {
+			oldDefault, newDefault = col.NewDefault, col.Default
+		}
+		if oldDefault != "" {
+			stmts = append(stmts, fmt.Sprintf("ALTER TABLE %s ALTER COLUMN %s DROP DEFAULT;", tableName, colName))
+		}
+		if col.Array {
+			stmts = append(stmts, fmt.Sprintf("ALTER TABLE %s ALTER COLUMN %s TYPE %s[] USING %s::text[]::%s[];",
+				tableName, colName, typeName, colName, typeName))
+		} else {
+			stmts = append(stmts, fmt.Sprintf("ALTER TABLE %s ALTER COLUMN %s TYPE %s USING %s::text::%s;",
+				tableName, colName, typeName, colName, typeName))
+		}
+		if newDefault != "" {
+			stmts = append(stmts, fmt.Sprintf("ALTER TABLE %s ALTER COLUMN %s SET DEFAULT %s;", tableName, colName, newDefault))
 		}
 	}
 
-	return changes
+	stmts = append(stmts, fmt.Sprintf("DROP TYPE %s;", qualifiedName(e.Schema, oldName)))
+	return strings.Join(stmts, "\n")
 }
 
 func quoteLiterals(ss []string) string {
@@ -228,3 +228,209 @@ func TestQuoteLiterals(t *testing.T) {
 		t.Error("quoteLiterals should escape single quotes")
 	}
 }
+
+func TestCompare_AlterEnum_AddValuesInPosition(t *testing.T) {
+	current := &parser.Schema{
+		Enums: []parser.Enum{{Name: "status", Values: []string{"pending", "done"}}},
+	}
+	desired := &parser.Schema{
+		Enums: []parser.Enum{{Name: "status", Values: []string{"draft", "pending", "active", "done", "archived"}}},
+	}
+
+	changes := Compare(current, desired)
+	if len(changes) != 1 {
+		t.Fatalf("expected 1 change, got %d", len(changes))
+	}
+
+	want := strings.Join([]string{
+		"ALTER TYPE status ADD VALUE 'draft' BEFORE 'pending';",
+		"ALTER TYPE status ADD VALUE 'active' AFTER 'pending';",
+		"ALTER TYPE status ADD VALUE 'archived' AFTER 'done';",
+	}, "\n")
+	if got := changes[0].SQL(); got != want {
+		t.Errorf("SQL =\n%s\nwant\n%s", got, want)
+	}
+}
+
+func TestCompare_AlterEnum_RenameValue(t *testing.T) {
+	current := &parser.Schema{
+		Enums: []parser.Enum{{Name: "status", Values: []string{"pending", "complete"}}},
+	}
+	desired := &parser.Schema{
+		Enums: []parser.Enum{{Name: "status", Values: []string{"pending", "done"}}},
+	}
+
+	changes := CompareWithOptions(current, desired, Options{
+		Renames: []parser.Rename{{Enum: "status", Value: "done", From: "complete"}},
+	})
+	if len(changes) != 1 {
+		t.Fatalf("expected 1 change, got %d", len(changes))
+	}
+
+	if got := changes[0].SQL(); got != "ALTER TYPE status RENAME VALUE 'complete' TO 'done';" {
+		t.Errorf("SQL = %q", got)
+	}
+	if got := changes[0].DownSQL(); got != "ALTER TYPE status RENAME VALUE 'done' TO 'complete';" {
+		t.Errorf("DownSQL = %q", got)
+	}
+	if !changes[0].IsReversible() {
+		t.Error("renaming enum values should be reversible")
+	}
+}
+
+func TestCompare_AlterEnum_UnconfirmedRenameRecreatesType(t *testing.T) {
+	current := &parser.Schema{
+		Enums: []parser.Enum{{Name: "status", Values: []string{"pending", "complete"}}},
+	}
+	desired := &parser.Schema{
+		Enums: []parser.Enum{{Name: "status", Values: []string{"pending", "done"}}},
+	}
+
+	changes := Compare(current, desired)
+	if len(changes) != 1 {
+		t.Fatalf("expected 1 change, got %d", len(changes))
+	}
+	change := changes[0].(*EnumChange)
+	if !change.Recreate || len(change.RenameValues) != 0 {
+		t.Errorf("an unconfirmed rename should recreate the type, got %+v", change)
+	}
+}
+
+func TestCompare_AlterEnum_RenameAndRemoveValue(t *testing.T) {
+	current := &parser.Schema{
+		Enums: []parser.Enum{{Name: "status", Values: []string{"pending", "complete", "legacy"}}},
+	}
+	desired := &parser.Schema{
+		Enums: []parser.Enum{{Name: "status", Values: []string{"pending", "done"}}},
+	}
+
+	changes := CompareWithOptions(current, desired, Options{
+		Renames: []parser.Rename{{Enum: "status", Value: "done", From: "complete"}},
+	})
+	if len(changes) != 1 {
+		t.Fatalf("expected 1 change, got %d", len(changes))
+	}
+
+	up := changes[0].SQL()
+	if !strings.HasPrefix(up, "ALTER TYPE status RENAME VALUE 'complete' TO 'done';\nALTER TYPE status RENAME TO status_old;") {
+		t.Errorf("SQL should rename the value before recreating the type, got\n%s", up)
+	}
+	down := changes[0].DownSQL()
+	if !strings.Contains(down, "CREATE TYPE status AS ENUM ('pending', 'done', 'legacy');") ||
+		!strings.HasSuffix(down, "ALTER TYPE status RENAME VALUE 'done' TO 'complete';") {
+		t.Errorf("DownSQL should recreate the renamed values then rename back, got\n%s", down)
+	}
+}
+
+func TestCompare_AlterEnum_RemoveValueRecreatesType(t *testing.T) {
+	current := &parser.Schema{
+		Enums: []parser.Enum{{Name: "status", Values: []string{"pending", "active", "legacy"}}},
+		Tables: []parser.Table{
+			{Name: "orders", Columns: []parser.Column{
+				{Name: "status", Type: "status", Default: "'pending'::status"},
+				{Name: "history", Type: "status[]", Nullable: true},
+			}},
+		},
+	}
+	desired := &parser.Schema{
+		Enums: []parser.Enum{{Name: "status", Values: []string{"pending", "active"}}},
+		Tables: []parser.Table{
+			{Name: "orders", Columns: []parser.Column{
+				{Name: "status", Type: "status", Default: "'pending'::status"},
+				{Name: "history", Type: "status[]", Nullable: true},
+			}},
+		},
+	}
+
+	changes := Compare(current, desired)
+	if len(changes) != 1 {
+		t.Fatalf("expected 1 change, got %d", len(changes))
+	}
+
+	want := strings.Join([]string{
+		"ALTER TYPE status RENAME TO status_old;",
+		"CREATE TYPE status AS ENUM ('pending', 'active');",
+		"ALTER TABLE orders ALTER COLUMN status DROP DEFAULT;",
+		"ALTER TABLE orders ALTER COLUMN status TYPE status USING status::text::status;",
+		"ALTER TABLE orders ALTER COLUMN status SET DEFAULT 'pending'::status;",
+		"ALTER TABLE orders ALTER COLUMN history TYPE status[] USING history::text[]::status[];",
+		"DROP TYPE status_old;",
+	}, "\n")
+	if got := changes[0].SQL(); got != want {
+		t.Errorf("SQL =\n%s\nwant\n%s", got, want)
+	}
+
+	down := changes[0].DownSQL()
+	if !strings.Contains(down, "CREATE TYPE status AS ENUM ('pending', 'active', 'legacy');") {
+		t.Errorf("DownSQL should recreate the old values, got\n%s", down)
+	}
+	if !changes[0].IsReversible() {
+		t.Error("recreating an enum should be reversible")
+	}
+}
+
+func TestCompare_AlterEnum_RecreateMatchesColumnsBySchema(t *testing.T) {
+	enums := func(values ...string) []parser.Enum {
+		return []parser.Enum{
+			{Schema: "app", Name: "status", Values: values},
+			{Name: "status", Values: []string{"on", "off"}},
+		}
+	}
+	tables := func(def string) []parser.Table {
+		return []parser.Table{
+			{Schema: "app", Name: "orders", Columns: []parser.Column{{Name: "status", Type: "status", TypeSchema: "app", Default: def}}},
+			{Name: "switches", Columns: []parser.Column{{Name: "status", Type: "status", TypeSchema: "public"}}},
+			{Name: "legacy", Columns: []parser.Column{{Name: "state", Type: "status"}}},
+		}
+	}
+	current := &parser.Schema{Enums: enums("pending", "legacy"), Tables: tables("'legacy'::app.status")}
+	desired := &parser.Schema{Enums: enums("pending"), Tables: tables("'pending'::app.status")}
+
+	changes := Compare(current, desired)
+	var change *EnumChange
+	for _, c := range changes {
+		if ec, ok := c.(*EnumChange); ok {
+			change = ec
+		}
+	}
+	if change == nil {
+		t.Fatalf("expected an enum change, got %d changes", len(changes))
+	}
+	if len(change.Columns) != 1 || change.Columns[0].Table != "orders" {
+		t.Fatalf("Columns = %+v, want only app.orders.status", change.Columns)
+	}
+
+	up := change.SQL()
+	if strings.Contains(up, "'legacy'::app.status") || !strings.Contains(up, "SET DEFAULT 'pending'::app.status;") {
+		t.Errorf("SQL should set the desired default, got\n%s", up)
+	}
+	if down := change.DownSQL(); !strings.Contains(down, "SET DEFAULT 'legacy'::app.status;") {
+		t.Errorf("DownSQL should restore the current default, got\n%s", down)
+	}
+}
+
+func TestCompare_AlterEnum_ReorderRecreatesType(t *testing.T) {
+	current := &parser.Schema{
+		Enums: []parser.Enum{{Name: "size", Values: []string{"small", "large", "medium"}}},
+	}
+	desired := &parser.Schema{
+		Enums: []parser.Enum{{Name: "size", Values: []string{"small", "medium", "large"}}},
+	}
+
+	changes := Compare(current, desired)
+	if len(changes) != 1 {
+		t.Fatalf("expected 1 change, got %d", len(changes))
+	}
+	if !changes[0].(*EnumChange).Recreate {
+		t.Error("reordering existing values should recreate the type")
+	}
+}
+
+func TestCompare_AlterEnum_Unchanged(t *testing.T) {
+	schema := &parser.Schema{
+		Enums: []parser.Enum{{Name: "status", Values: []string{"a", "b"}}},
+	}
+	if changes := Compare(schema, schema); len(changes) != 0 {
+		t.Errorf("expected no changes, got %d", len(changes))
+	}
+}
@@ -247,19 +247,19 @@ func compareForeignTables(current, desired []parser.ForeignTable) []Change {
 	var changes []Change
 	currentMap := make(map[string]parser.ForeignTable)
 	for _, ft := range current {
-		currentMap[ft.Name] = ft
+		currentMap[objectKey(ft.Schema, ft.Name)] = ft
 	}
 	for _, ft := range desired {
-		if _, exists := currentMap[ft.Name]; !exists {
+		if _, exists := currentMap[objectKey(ft.Schema, ft.Name)]; !exists {
 			changes = append(changes, &ForeignTableChange{ChangeType: CreateForeignTable, ForeignTable: ft})
 		}
 	}
 	desiredMap := make(map[string]bool)
 	for _, ft := range desired {
-		desiredMap[ft.Name] = true
+		desiredMap[objectKey(ft.Schema, ft.Name)] = true
 	}
 	for _, ft := range current {
-		if !desiredMap[ft.Name] {
+		if !desiredMap[objectKey(ft.Schema, ft.Name)] {
 			oldFT := ft
 			changes = append(changes, &ForeignTableChange{ChangeType: DropForeignTable, ForeignTable: ft, OldForeignTable: &oldFT})
 		}
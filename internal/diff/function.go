@@ -18,7 +18,7 @@ func (c *FunctionChange) SQL() string {
 	case CreateFunction, AlterFunction:
 		return generateCreateFunction(c.Function)
 	case DropFunction:
-		return fmt.Sprintf("DROP FUNCTION %s;", qualifiedName(c.Function.Schema, c.Function.Name))
+		return generateDropFunction(c.Function)
 	}
 	return ""
 }
@@ -26,7 +26,7 @@ func (c *FunctionChange) SQL() string {
 func (c *FunctionChange) DownSQL() string {
 	switch c.ChangeType {
 	case CreateFunction:
-		return fmt.Sprintf("DROP FUNCTION %s;", qualifiedName(c.Function.Schema, c.Function.Name))
+		return generateDropFunction(c.Function)
 	case DropFunction:
 		if c.OldFunction != nil {
 			return generateCreateFunction(*c.OldFunction)
@@ -64,25 +64,32 @@ func compareFunctions(current, desired []parser.Function) []Change {
 
 	currentMap := make(map[string]parser.Function)
 	for _, f := range current {
-		currentMap[f.Name] = f
+		currentMap[functionKey(f)] = f
 	}
 
 	desiredMap := make(map[string]parser.Function)
 	for _, f := range desired {
-		desiredMap[f.Name] = f
+		desiredMap[functionKey(f)] = f
 	}
 
 	for _, f := range desired {
-		if existing, exists := currentMap[f.Name]; !exists {
+		existing, exists := currentMap[functionKey(f)]
+		if !exists {
 			changes = append(changes, &FunctionChange{ChangeType: CreateFunction, Function: f})
-		} else if existing.Body != f.Body {
-			oldFunc := existing
+			continue
+		}
+
+		oldFunc := existing
+		if normalizeType(existing.Returns) != normalizeType(f.Returns) {
+			changes = append(changes, &FunctionChange{ChangeType: DropFunction, Function: existing, OldFunction: &oldFunc})
+			changes = append(changes, &FunctionChange{ChangeType: CreateFunction, Function: f})
+		} else if !functionsEqual(existing, f) {
 			changes = append(changes, &FunctionChange{ChangeType: AlterFunction, Function: f, OldFunction: &oldFunc})
 		}
 	}
 
 	for _, f := range current {
-		if _, exists := desiredMap[f.Name]; !exists {
+		if _, exists := desiredMap[functionKey(f)]; !exists {
 			oldFunc := f
 			changes = append(changes, &FunctionChange{ChangeType: DropFunction, Function: f, OldFunction: &oldFunc})
 		}
@@ -91,6 +98,70 @@ func compareFunctions(current, desired []parser.Function) []Change {
 	return changes
 }
 
+func functionKey(f parser.Function) string {
+	return objectKey(f.Schema, f.Name) + "(" + normalizeType(routineSignature(f.Args, f.IdentityArgs)) + ")"
+}
+
+func functionsEqual(a, b parser.Function) bool {
+	if a.Definition != "" && b.Definition != "" {
+		return normalizeSQL(a.Definition) == normalizeSQL(b.Definition)
+	}
+	return a.Body == b.Body
+}
+
+func generateDropFunction(f parser.Function) string {
+	return fmt.Sprintf("DROP FUNCTION %s(%s);", qualifiedName(f.Schema, f.Name), routineSignature(f.Args, f.IdentityArgs))
+}
+
+// routineSignature returns the argument list that identifies a function or
+// procedure among its overloads. Introspected routines carry the identity
+// arguments directly; parsed ones fall back to the declared arguments with
+// their defaults removed, since DROP does not accept them.
+func routineSignature(args, identityArgs string) string {
+	if identityArgs != "" {
+		return identityArgs
+	}
+
+	var parts []string
+	for _, arg := range splitRoutineArgs(args) {
+		lower := strings.ToLower(arg)
+		if idx := strings.Index(lower, " default "); idx >= 0 {
+			arg = arg[:idx]
+		} else if idx := strings.Index(arg, "="); idx >= 0 {
+			arg = arg[:idx]
+		}
+		if arg = strings.TrimSpace(arg); arg != "" {
+			parts = append(parts, arg)
+		}
+	}
+	return strings.Join(parts, ", ")
+}
+
+func splitRoutineArgs(args string) []string {
+	var parts []string
+	depth := 0
+	var quote rune
+	start := 0
+	for i, r := range args {
+		switch {
+		case quote != 0:
+			if r == quote {
+				quote = 0
+			}
+		case r == '\'' || r == '"':
+			quote = r
+		case r == '(' || r == '[':
+			depth++
+		case r == ')' || r == ']':
+			depth--
+		case r == ',' && depth == 0:
+			parts = append(parts, args[start:i])
+			start = i + 1
+		}
+	}
+	return append(parts, args[start:])
+}
+
 func generateCreateFunction(f parser.Function) string {
 	if f.Definition != "" {
 		return f.Definition + ";"
@@ -98,7 +169,7 @@ func generateCreateFunction(f parser.Function) string {
 
 	var sb strings.Builder
 
-	sb.WriteString(fmt.Sprintf("CREATE OR REPLACE FUNCTION %s(%s)", quoteIdent(f.Name), f.Args))
+	sb.WriteString(fmt.Sprintf("CREATE OR REPLACE FUNCTION %s(%s)", qualifiedName(f.Schema, f.Name), f.Args))
 	sb.WriteString(fmt.Sprintf(" RETURNS %s", f.Returns))
 	sb.WriteString(fmt.Sprintf(" LANGUAGE %s", f.Language))
 	sb.WriteString(fmt.Sprintf(" AS $$%s$$;", f.Body))
@@ -275,3 +275,78 @@ func TestGenerateCreateFunction(t *testing.T) {
 		t.Error("should contain function body")
 	}
 }
+
+func TestCompare_FunctionOverloads(t *testing.T) {
+	current := &parser.Schema{
+		Functions: []parser.Function{
+			{Name: "area", Args: "r integer", IdentityArgs: "r integer", Returns: "integer", Language: "sql", Body: "SELECT r * r"},
+			{Name: "area", Args: "w integer, h integer", IdentityArgs: "w integer, h integer", Returns: "integer", Language: "sql", Body: "SELECT w * h"},
+		},
+	}
+	desired := &parser.Schema{
+		Functions: []parser.Function{
+			{Name: "area", Args: "r integer", IdentityArgs: "r integer", Returns: "integer", Language: "sql", Body: "SELECT r * r"},
+			{Name: "area", Args: "r numeric", IdentityArgs: "r numeric", Returns: "numeric", Language: "sql", Body: "SELECT r * r"},
+		},
+	}
+
+	changes := Compare(current, desired)
+
+	if len(changes) != 2 {
+		t.Fatalf("expected 2 changes, got %d", len(changes))
+	}
+	if changes[0].Type() != CreateFunction || !strings.Contains(changes[0].SQL(), "r numeric") {
+		t.Errorf("expected create of area(r numeric), got %s", changes[0].SQL())
+	}
+	if got := changes[1].SQL(); got != "DROP FUNCTION area(w integer, h integer);" {
+		t.Errorf("SQL = %q, want drop of area(w integer, h integer)", got)
+	}
+}
+
+func TestCompare_FunctionReturnTypeChanged(t *testing.T) {
+	current := &parser.Schema{
+		Functions: []parser.Function{
+			{Name: "total", Args: "x integer", Returns: "integer", Language: "sql", Body: "SELECT x"},
+		},
+	}
+	desired := &parser.Schema{
+		Functions: []parser.Function{
+			{Name: "total", Args: "x integer", Returns: "bigint", Language: "sql", Body: "SELECT x"},
+		},
+	}
+
+	changes := Compare(current, desired)
+
+	if len(changes) != 2 {
+		t.Fatalf("expected 2 changes, got %d", len(changes))
+	}
+	if changes[0].Type() != DropFunction || changes[0].SQL() != "DROP FUNCTION total(x integer);" {
+		t.Errorf("expected drop first, got %s", changes[0].SQL())
+	}
+	if changes[1].Type() != CreateFunction || !strings.Contains(changes[1].SQL(), "RETURNS bigint") {
+		t.Errorf("expected create with new return type, got %s", changes[1].SQL())
+	}
+	if !strings.Contains(changes[0].DownSQL(), "RETURNS integer") {
+		t.Errorf("DownSQL for drop should restore old return type, got %s", changes[0].DownSQL())
+	}
+}
+
+func TestRoutineSignature(t *testing.T) {
+	tests := []struct {
+		args         string
+		identityArgs string
+		want         string
+	}{
+		{"", "", ""},
+		{"a integer, b integer", "", "a integer, b integer"},
+		{"a integer, b integer DEFAULT 1", "", "a integer, b integer"},
+		{"amount numeric(10,2) = 0, label text DEFAULT 'a, b'", "", "amount numeric(10,2), label text"},
+		{"a integer DEFAULT 1", "a integer", "a integer"},
+	}
+
+	for _, tt := range tests {
+		if got := routineSignature(tt.args, tt.identityArgs); got != tt.want {
+			t.Errorf("routineSignature(%q, %q) = %q, want %q", tt.args, tt.identityArgs, got, tt.want)
+		}
+	}
+}
@@ -0,0 +1,267 @@
+package diff
+
+import (
+	"fmt"
+	"regexp"
+	"strconv"
+	"strings"
+)
+
+type HazardKind string
+
+const (
+	HazardDataLoss        HazardKind = "data-loss"
+	HazardTableRewrite    HazardKind = "table-rewrite"
+	HazardAccessExclusive HazardKind = "access-exclusive"
+	HazardValidationScan  HazardKind = "validation-scan"
+	HazardIndexBuild      HazardKind = "index-build"
+)
+
+var HazardKinds = []HazardKind{
+	HazardDataLoss,
+	HazardTableRewrite,
+	HazardAccessExclusive,
+	HazardValidationScan,
+	HazardIndexBuild,
+}
+
+type Hazard struct {
+	Kind    HazardKind
+	Message string
+}
+
+func (h Hazard) String() string {
+	return fmt.Sprintf("%s: %s", h.Kind, h.Message)
+}
+
+func ParseHazardKind(s string) (HazardKind, error) {
+	for _, kind := range HazardKinds {
+		if string(kind) == s {
+			return kind, nil
+		}
+	}
+	var names []string
+	for _, kind := range HazardKinds {
+		names = append(names, string(kind))
+	}
+	return "", fmt.Errorf("unknown hazard %q (expected one of %s)", s, strings.Join(names, ", "))
+}
+
+func Hazards(c Change) []Hazard {
+	return changeHazards(c, nil)
+}
+
+// HazardsFor leaves out hazards on tables created by the same changes, since
+// those tables are empty.
+func HazardsFor(changes []Change) [][]Hazard {
+	created := make(map[string]bool)
+	for _, c := range changes {
+		if tc, ok := c.(*TableChange); ok && tc.ChangeType == CreateTable {
+			created[objectKey(tc.Table.Schema, tc.Table.Name)] = true
+		}
+	}
+
+	hazards := make([][]Hazard, len(changes))
+	for i, c := range changes {
+		hazards[i] = changeHazards(c, created)
+	}
+	return hazards
+}
+
+func changeHazards(c Change, created map[string]bool) []Hazard {
+	switch c := c.(type) {
+	case *TableChange:
+		return tableHazards(c)
+	case *IndexChange:
+		if created[objectKey(c.Index.Schema, c.Index.Table)] {
+			return nil
+		}
+		return indexHazards(c)
+	case *EnumChange:
+		return enumHazards(c)
+	}
+	return nil
+}
+
+func tableHazards(c *TableChange) []Hazard {
+	table := displayName(c.Table.Schema, c.Table.Name)
+
+	switch c.ChangeType {
+	case DropTable:
+		return []Hazard{{HazardDataLoss, fmt.Sprintf("drops table %s and all of its rows", table)}}
+	case AlterTable:
+	default:
+		return nil
+	}
+
+	var hazards []Hazard
+
+	for _, col := range c.DropColumns {
+		hazards = append(hazards, Hazard{HazardDataLoss, fmt.Sprintf("drops column %s.%s and its data", table, col)})
+	}
+
+	rewrite := func(reason string) {
+		hazards = append(hazards,
+			Hazard{HazardTableRewrite, fmt.Sprintf("%s rewrites %s", reason, table)},
+			Hazard{HazardAccessExclusive, fmt.Sprintf("%s is locked against reads and writes during the rewrite", table)})
+	}
+
+	for _, col := range c.AddColumns {
+		switch {
+		case col.GeneratedAs != "" && !strings.EqualFold(col.GeneratedType, "VIRTUAL"):
+			rewrite(fmt.Sprintf("adding stored generated column %s", col.Name))
+		case col.Identity != "":
+			rewrite(fmt.Sprintf("adding identity column %s", col.Name))
+		case isVolatile(col.Default):
+			rewrite(fmt.Sprintf("adding column %s with volatile default %s", col.Name, col.Default))
+		}
+	}
+
+	for _, alt := range c.AlterColumns {
+		for _, change := range alt.Changes {
+			switch change {
+			case "type":
+				if typeChangeRewrites(alt.OldColumn.Type, alt.Column.Type) {
+					rewrite(fmt.Sprintf("changing the type of %s from %s to %s", alt.Column.Name, alt.OldColumn.Type, alt.Column.Type))
+				}
+			case "collation":
+				hazards = append(hazards,
+					Hazard{HazardIndexBuild, fmt.Sprintf("changing the collation of %s rebuilds the indexes on it", alt.Column.Name)},
+					Hazard{HazardAccessExclusive, fmt.Sprintf("%s is locked against reads and writes while its indexes rebuild", table)})
+			case "generated":
+				if alt.Column.GeneratedAs != "" {
+					rewrite(fmt.Sprintf("changing the expression of generated column %s", alt.Column.Name))
+				}
+			case "nullable":
+				if !alt.Column.Nullable && !c.Online {
+					hazards = append(hazards,
+						Hazard{HazardValidationScan, fmt.Sprintf("SET NOT NULL on %s scans %s for nulls (use --online)", alt.Column.Name, table)},
+						Hazard{HazardAccessExclusive, fmt.Sprintf("%s is locked against reads and writes during the scan", table)})
+				}
+			}
+		}
+	}
+
+	for _, con := range c.AddConstraints {
+		if c.Online && isOnlineConstraint(con) {
+			continue
+		}
+		name := con.Name
+		if name == "" {
+			name = strings.ToLower(con.Type)
+		}
+		switch con.Type {
+		case "CHECK":
+			if !con.NotValid {
+				hazards = append(hazards,
+					Hazard{HazardValidationScan, fmt.Sprintf("adding check constraint %s scans %s", name, table)},
+					Hazard{HazardAccessExclusive, fmt.Sprintf("%s is locked against reads and writes during the scan", table)})
+			}
+		case "FOREIGN KEY":
+			if !con.NotValid {
+				hazards = append(hazards, Hazard{HazardValidationScan,
+					fmt.Sprintf("adding foreign key %s scans %s, blocking writes to it and to %s (use --online)", name, table, con.RefTable)})
+			}
+		case "PRIMARY KEY", "UNIQUE", "EXCLUSION":
+			msg := fmt.Sprintf("adding %s constraint %s builds an index on %s", strings.ToLower(con.Type), name, table)
+			if con.Type == "UNIQUE" {
+				msg += " (use --online)"
+			}
+			hazards = append(hazards,
+				Hazard{HazardIndexBuild, msg},
+				Hazard{HazardAccessExclusive, fmt.Sprintf("%s is locked against reads and writes while the index builds", table)})
+		}
+	}
+
+	return dedupeHazards(hazards)
+}
+
+func indexHazards(c *IndexChange) []Hazard {
+	if c.Concurrently {
+		return nil
+	}
+	table := displayName(c.Index.Schema, c.Index.Table)
+
+	switch c.ChangeType {
+	case CreateIndex:
+		return []Hazard{{HazardIndexBuild, fmt.Sprintf("building index %s blocks writes to %s (use --concurrent-indexes)", c.Index.Name, table)}}
+	case DropIndex:
+		return []Hazard{{HazardAccessExclusive, fmt.Sprintf("dropping index %s locks %s against reads and writes (use --concurrent-indexes)", c.Index.Name, table)}}
+	}
+	return nil
+}
+
+func enumHazards(c *EnumChange) []Hazard {
+	if c.ChangeType != AlterEnum || !c.Recreate {
+		return nil
+	}
+
+	var hazards []Hazard
+	for _, col := range c.Columns {
+		table := displayName(col.Schema, col.Table)
+		hazards = append(hazards,
+			Hazard{HazardTableRewrite, fmt.Sprintf("recreating enum %s rewrites %s to convert column %s", c.Enum.Name, table, col.Column)},
+			Hazard{HazardAccessExclusive, fmt.Sprintf("%s is locked against reads and writes during the rewrite", table)})
+	}
+	return dedupeHazards(hazards)
+}
+
+func dedupeHazards(hazards []Hazard) []Hazard {
+	seen := make(map[Hazard]bool)
+	var out []Hazard
+	for _, h := range hazards {
+		if !seen[h] {
+			seen[h] = true
+			out = append(out, h)
+		}
+	}
+	return out
+}
+
+var volatileCallRegex = regexp.MustCompile(`(?i)\b(random|gen_random_uuid|uuid_generate_v[14]|clock_timestamp|timeofday|nextval)\s*\(`)
+
+// Postgres evaluates a volatile default for every existing row when the column
+// is added.
+func isVolatile(expr string) bool {
+	return volatileCallRegex.MatchString(expr)
+}
+
+var sizedTypeRegex = regexp.MustCompile(`^(varchar|numeric|decimal)(?:\((\d+)(?:,\s*(\d+))?\))?$`)
+
+// Widening varchar and numeric columns, and dropping their limits, is binary
+// compatible and doesn't rewrite the table.
+func typeChangeRewrites(from, to string) bool {
+	from, to = normalizeType(from), normalizeType(to)
+	if from == to {
+		return false
+	}
+	if to == "text" && (from == "varchar" || strings.HasPrefix(from, "varchar(")) {
+		return false
+	}
+
+	f := sizedTypeRegex.FindStringSubmatch(from)
+	t := sizedTypeRegex.FindStringSubmatch(to)
+	if f == nil || t == nil || f[1] != t[1] {
+		return true
+	}
+	if t[2] == "" {
+		return false
+	}
+	if f[2] == "" {
+		return true
+	}
+
+	fromSize, _ := strconv.Atoi(f[2])
+	toSize, _ := strconv.Atoi(t[2])
+	if f[1] == "varchar" {
+		return toSize < fromSize
+	}
+	return toSize < fromSize || f[3] != t[3]
+}
+
+func displayName(schema, name string) string {
+	if schema == "" || schema == "public" {
+		return name
+	}
+	return schema + "." + name
+}
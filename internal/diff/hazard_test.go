@@ -0,0 +1,246 @@
+package diff
+
+import (
+	"testing"
+
+	"github.com/terminally-online/shrugged/internal/parser"
+)
+
+func hazardKindsOf(hazards []Hazard) map[HazardKind]bool {
+	kinds := make(map[HazardKind]bool)
+	for _, h := range hazards {
+		kinds[h.Kind] = true
+	}
+	return kinds
+}
+
+func TestHazards_Table(t *testing.T) {
+	users := parser.Table{
+		Name: "users",
+		Columns: []parser.Column{
+			{Name: "id", Type: "integer"},
+			{Name: "email", Type: "character varying(100)", Nullable: true},
+			{Name: "score", Type: "integer", Nullable: true},
+		},
+	}
+
+	tests := []struct {
+		name    string
+		desired func(t parser.Table) parser.Table
+		want    []HazardKind
+	}{
+		{
+			name: "type change rewrites",
+			desired: func(t parser.Table) parser.Table {
+				t.Columns[2].Type = "bigint"
+				return t
+			},
+			want: []HazardKind{HazardTableRewrite, HazardAccessExclusive},
+		},
+		{
+			name: "widening varchar does not rewrite",
+			desired: func(t parser.Table) parser.Table {
+				t.Columns[1].Type = "character varying(255)"
+				return t
+			},
+		},
+		{
+			name: "varchar to text does not rewrite",
+			desired: func(t parser.Table) parser.Table {
+				t.Columns[1].Type = "text"
+				return t
+			},
+		},
+		{
+			name: "narrowing varchar rewrites",
+			desired: func(t parser.Table) parser.Table {
+				t.Columns[1].Type = "character varying(50)"
+				return t
+			},
+			want: []HazardKind{HazardTableRewrite, HazardAccessExclusive},
+		},
+		{
+			name: "collation change rebuilds indexes",
+			desired: func(t parser.Table) parser.Table {
+				t.Columns[1].Collation = "C"
+				return t
+			},
+			want: []HazardKind{HazardIndexBuild, HazardAccessExclusive},
+		},
+		{
+			name: "set not null scans",
+			desired: func(t parser.Table) parser.Table {
+				t.Columns[1].Nullable = false
+				return t
+			},
+			want: []HazardKind{HazardValidationScan, HazardAccessExclusive},
+		},
+		{
+			name: "drop not null is safe",
+			desired: func(t parser.Table) parser.Table {
+				t.Columns[0].Nullable = true
+				return t
+			},
+		},
+		{
+			name: "volatile default rewrites",
+			desired: func(t parser.Table) parser.Table {
+				t.Columns = append(t.Columns, parser.Column{Name: "token", Type: "uuid", Default: "gen_random_uuid()"})
+				return t
+			},
+			want: []HazardKind{HazardTableRewrite, HazardAccessExclusive},
+		},
+		{
+			name: "stable default is safe",
+			desired: func(t parser.Table) parser.Table {
+				t.Columns = append(t.Columns, parser.Column{Name: "created_at", Type: "timestamp with time zone", Default: "now()"})
+				return t
+			},
+		},
+		{
+			name: "drop column loses data",
+			desired: func(t parser.Table) parser.Table {
+				t.Columns = t.Columns[:2]
+				return t
+			},
+			want: []HazardKind{HazardDataLoss},
+		},
+		{
+			name: "check constraint scans",
+			desired: func(t parser.Table) parser.Table {
+				t.Constraints = append(t.Constraints, parser.Constraint{Name: "score_positive", Type: "CHECK", Check: "score > 0"})
+				return t
+			},
+			want: []HazardKind{HazardValidationScan, HazardAccessExclusive},
+		},
+		{
+			name: "not valid check is safe",
+			desired: func(t parser.Table) parser.Table {
+				t.Constraints = append(t.Constraints, parser.Constraint{Name: "score_positive", Type: "CHECK", Check: "score > 0", NotValid: true})
+				return t
+			},
+		},
+		{
+			name: "unique constraint builds an index",
+			desired: func(t parser.Table) parser.Table {
+				t.Constraints = append(t.Constraints, parser.Constraint{Name: "users_email_key", Type: "UNIQUE", Columns: []string{"email"}})
+				return t
+			},
+			want: []HazardKind{HazardIndexBuild, HazardAccessExclusive},
+		},
+		{
+			name: "foreign key scans",
+			desired: func(t parser.Table) parser.Table {
+				t.Constraints = append(t.Constraints, parser.Constraint{Name: "users_score_fkey", Type: "FOREIGN KEY", Columns: []string{"score"}, RefTable: "scores", RefColumns: []string{"id"}})
+				return t
+			},
+			want: []HazardKind{HazardValidationScan},
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			desired := users
+			desired.Columns = append([]parser.Column(nil), users.Columns...)
+			desired = tt.desired(desired)
+
+			changes := Compare(&parser.Schema{Tables: []parser.Table{users}}, &parser.Schema{Tables: []parser.Table{desired}})
+			if len(changes) != 1 {
+				t.Fatalf("expected 1 change, got %d", len(changes))
+			}
+
+			got := hazardKindsOf(Hazards(changes[0]))
+			if len(got) != len(tt.want) {
+				t.Fatalf("Hazards() = %v, want %v", Hazards(changes[0]), tt.want)
+			}
+			for _, kind := range tt.want {
+				if !got[kind] {
+					t.Errorf("Hazards() = %v, missing %s", Hazards(changes[0]), kind)
+				}
+			}
+		})
+	}
+}
+
+func TestHazards_DropTable(t *testing.T) {
+	current := &parser.Schema{Tables: []parser.Table{{Name: "users"}}}
+	changes := Compare(current, &parser.Schema{})
+
+	hazards := Hazards(changes[0])
+	if len(hazards) != 1 || hazards[0].Kind != HazardDataLoss {
+		t.Errorf("Hazards() = %v, want data-loss", hazards)
+	}
+}
+
+func TestHazards_Index(t *testing.T) {
+	current := &parser.Schema{Tables: []parser.Table{{Name: "users"}}}
+	desired := &parser.Schema{
+		Tables:  []parser.Table{{Name: "users"}},
+		Indexes: []parser.Index{{Name: "idx_users_email", Table: "users", Columns: []string{"email"}}},
+	}
+
+	changes := Compare(current, desired)
+	if hazards := Hazards(changes[0]); len(hazards) != 1 || hazards[0].Kind != HazardIndexBuild {
+		t.Errorf("Hazards() = %v, want index-build", hazards)
+	}
+
+	changes = CompareWithOptions(current, desired, Options{ConcurrentIndexes: true})
+	if hazards := Hazards(changes[0]); len(hazards) != 0 {
+		t.Errorf("Hazards() with concurrent indexes = %v, want none", hazards)
+	}
+
+	changes = Compare(desired, current)
+	if hazards := Hazards(changes[0]); len(hazards) != 1 || hazards[0].Kind != HazardAccessExclusive {
+		t.Errorf("Hazards() for drop = %v, want access-exclusive", hazards)
+	}
+}
+
+func TestHazardsFor_NewTable(t *testing.T) {
+	desired := &parser.Schema{
+		Tables:  []parser.Table{{Name: "users", Columns: []parser.Column{{Name: "email", Type: "text"}}}},
+		Indexes: []parser.Index{{Name: "idx_users_email", Table: "users", Columns: []string{"email"}}},
+	}
+
+	changes := Compare(&parser.Schema{}, desired)
+	for i, hazards := range HazardsFor(changes) {
+		if len(hazards) > 0 {
+			t.Errorf("%s: hazards on a new table = %v, want none", changes[i].ObjectName(), hazards)
+		}
+	}
+}
+
+func TestTypeChangeRewrites(t *testing.T) {
+	tests := []struct {
+		from, to string
+		want     bool
+	}{
+		{"character varying(10)", "character varying(20)", false},
+		{"character varying(20)", "character varying(10)", true},
+		{"character varying(10)", "character varying", false},
+		{"character varying", "character varying(10)", true},
+		{"character varying(10)", "text", false},
+		{"numeric(10,2)", "numeric(12,2)", false},
+		{"numeric(10,2)", "numeric(12,4)", true},
+		{"numeric(10,2)", "numeric", false},
+		{"integer", "bigint", true},
+		{"text", "character varying(10)", true},
+		{"timestamp without time zone", "timestamp with time zone", true},
+	}
+
+	for _, tt := range tests {
+		if got := typeChangeRewrites(tt.from, tt.to); got != tt.want {
+			t.Errorf("typeChangeRewrites(%q, %q) = %v, want %v", tt.from, tt.to, got, tt.want)
+		}
+	}
+}
+
+func TestParseHazardKind(t *testing.T) {
+	for _, kind := range HazardKinds {
+		if got, err := ParseHazardKind(string(kind)); err != nil || got != kind {
+			t.Errorf("ParseHazardKind(%q) = %q, %v", kind, got, err)
+		}
+	}
+	if _, err := ParseHazardKind("rewrite"); err == nil {
+		t.Error("expected an error for an unknown hazard")
+	}
+}
@@ -8,17 +8,18 @@ import (
 )
 
 type IndexChange struct {
-	ChangeType ChangeType
-	Index      parser.Index
-	OldIndex   *parser.Index
+	ChangeType   ChangeType
+	Index        parser.Index
+	OldIndex     *parser.Index
+	Concurrently bool
 }
 
 func (c *IndexChange) SQL() string {
 	switch c.ChangeType {
 	case CreateIndex:
-		return generateCreateIndex(c.Index)
+		return c.createIndex(c.Index)
 	case DropIndex:
-		return fmt.Sprintf("DROP INDEX %s;", qualifiedName(c.Index.Schema, c.Index.Name))
+		return c.dropIndex(c.Index)
 	}
 	return ""
 }
@@ -26,10 +27,10 @@ func (c *IndexChange) SQL() string {
 func (c *IndexChange) DownSQL() string {
 	switch c.ChangeType {
 	case CreateIndex:
-		return fmt.Sprintf("DROP INDEX %s;", qualifiedName(c.Index.Schema, c.Index.Name))
+		return c.dropIndex(c.Index)
 	case DropIndex:
 		if c.OldIndex != nil {
-			return generateCreateIndex(*c.OldIndex)
+			return c.createIndex(*c.OldIndex)
 		}
 		return fmt.Sprintf("-- IRREVERSIBLE: Cannot restore dropped index %s", c.Index.Name)
 	}
@@ -51,35 +52,102 @@ func (c *IndexChange) IsReversible() bool {
 	return true
 }
 
-func compareIndexes(current, desired []parser.Index) []Change {
+func (c *IndexChange) createIndex(i parser.Index) string {
+	sql := generateCreateIndex(i)
+	if c.Concurrently {
+		sql = strings.Replace(sql, "INDEX ", "INDEX CONCURRENTLY ", 1)
+	}
+	return sql
+}
+
+func (c *IndexChange) dropIndex(i parser.Index) string {
+	if c.Concurrently {
+		return fmt.Sprintf("DROP INDEX CONCURRENTLY %s;", qualifiedName(i.Schema, i.Name))
+	}
+	return fmt.Sprintf("DROP INDEX %s;", qualifiedName(i.Schema, i.Name))
+}
+
+// NeedsNoTransaction reports whether the changes include statements Postgres
+// refuses to run in a transaction block, or online steps that each commit.
+func NeedsNoTransaction(changes []Change) bool {
+	for _, c := range changes {
+		if needsNoTransaction(c) {
+			return true
+		}
+	}
+	return false
+}
+
+func needsNoTransaction(c Change) bool {
+	if ic, ok := c.(*IndexChange); ok && ic.Concurrently {
+		return true
+	}
+	if tc, ok := c.(*TableChange); ok && tc.usesOnlineSteps() {
+		return true
+	}
+	return false
+}
+
+// SplitByTransaction splits the changes, in order, into runs that either all
+// need to run outside a transaction or can all share one, so a single
+// concurrent index build doesn't take the rest of a migration out of its
+// transaction.
+func SplitByTransaction(changes []Change) [][]Change {
+	var runs [][]Change
+	for i, c := range changes {
+		if i == 0 || needsNoTransaction(c) != needsNoTransaction(changes[i-1]) {
+			runs = append(runs, nil)
+		}
+		runs[len(runs)-1] = append(runs[len(runs)-1], c)
+	}
+	return runs
+}
+
+func compareIndexes(current, desired []parser.Index, opts Options) []Change {
 	var changes []Change
 
 	currentMap := make(map[string]parser.Index)
 	for _, i := range current {
-		currentMap[i.Name] = i
+		currentMap[objectKey(i.Schema, i.Name)] = i
 	}
 
 	desiredMap := make(map[string]parser.Index)
 	for _, i := range desired {
-		desiredMap[i.Name] = i
+		desiredMap[objectKey(i.Schema, i.Name)] = i
 	}
 
-	for _, i := range desired {
-		if _, exists := currentMap[i.Name]; !exists {
-			changes = append(changes, &IndexChange{ChangeType: CreateIndex, Index: i})
+	for _, i := range current {
+		if desiredIdx, exists := desiredMap[objectKey(i.Schema, i.Name)]; !exists || !indexesEqual(i, desiredIdx) {
+			oldIdx := i
+			changes = append(changes, &IndexChange{ChangeType: DropIndex, Index: i, OldIndex: &oldIdx, Concurrently: opts.ConcurrentIndexes})
 		}
 	}
 
-	for _, i := range current {
-		if _, exists := desiredMap[i.Name]; !exists {
-			oldIdx := i
-			changes = append(changes, &IndexChange{ChangeType: DropIndex, Index: i, OldIndex: &oldIdx})
+	for _, i := range desired {
+		if currentIdx, exists := currentMap[objectKey(i.Schema, i.Name)]; !exists || !indexesEqual(currentIdx, i) {
+			changes = append(changes, &IndexChange{ChangeType: CreateIndex, Index: i, Concurrently: opts.ConcurrentIndexes})
 		}
 	}
 
 	return changes
 }
 
+func indexesEqual(a, b parser.Index) bool {
+	if a.Definition != "" && b.Definition != "" {
+		return normalizeIndexDef(a.Definition) == normalizeIndexDef(b.Definition)
+	}
+	return normalizeIndexDef(generateCreateIndex(a)) == normalizeIndexDef(generateCreateIndex(b))
+}
+
+func normalizeIndexDef(def string) string {
+	def = normalizeSQL(def)
+	def = strings.ReplaceAll(def, `"`, "")
+	def = strings.ReplaceAll(def, " using btree ", " ")
+	def = strings.ReplaceAll(def, " on public.", " on ")
+	def = strings.ReplaceAll(def, " (", "(")
+	return def
+}
+
 func generateCreateIndex(i parser.Index) string {
 	var sb strings.Builder
 
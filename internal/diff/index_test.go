@@ -270,3 +270,159 @@ func TestGenerateCreateIndex(t *testing.T) {
 		t.Error("btree index should not include USING btree (it's the default)")
 	}
 }
+
+func TestCompare_IndexDefinitionChanged(t *testing.T) {
+	current := &parser.Schema{
+		Indexes: []parser.Index{
+			{Name: "idx_posts_user", Table: "posts", Columns: []string{"user_id"},
+				Definition: "CREATE INDEX idx_posts_user ON public.posts USING btree (user_id)"},
+		},
+	}
+	desired := &parser.Schema{
+		Indexes: []parser.Index{
+			{Name: "idx_posts_user", Table: "posts", Columns: []string{"user_id", "created_at"},
+				Definition: "CREATE INDEX idx_posts_user ON public.posts USING btree (user_id, created_at)"},
+		},
+	}
+
+	changes := Compare(current, desired)
+
+	if len(changes) != 2 {
+		t.Fatalf("expected 2 changes, got %d", len(changes))
+	}
+	if changes[0].Type() != DropIndex || changes[1].Type() != CreateIndex {
+		t.Fatalf("expected DropIndex then CreateIndex, got %v then %v", changes[0].Type(), changes[1].Type())
+	}
+	if !strings.Contains(changes[1].SQL(), "(user_id, created_at)") {
+		t.Errorf("create SQL = %q, should use new columns", changes[1].SQL())
+	}
+	if !strings.Contains(changes[0].DownSQL(), "(user_id)") {
+		t.Errorf("drop DownSQL = %q, should restore original columns", changes[0].DownSQL())
+	}
+}
+
+func TestCompare_IndexUnchanged(t *testing.T) {
+	tests := []struct {
+		name    string
+		current parser.Index
+		desired parser.Index
+	}{
+		{
+			name:    "same definition",
+			current: parser.Index{Name: "idx", Table: "t", Definition: "CREATE INDEX idx ON public.t USING btree (a)"},
+			desired: parser.Index{Name: "idx", Table: "t", Definition: "CREATE INDEX idx ON public.t USING btree (a)"},
+		},
+		{
+			name:    "default btree method",
+			current: parser.Index{Name: "idx", Table: "t", Columns: []string{"a"}, Using: "btree"},
+			desired: parser.Index{Name: "idx", Table: "t", Columns: []string{"a"}},
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			changes := Compare(&parser.Schema{Indexes: []parser.Index{tt.current}}, &parser.Schema{Indexes: []parser.Index{tt.desired}})
+			if len(changes) != 0 {
+				t.Errorf("expected 0 changes, got %d", len(changes))
+			}
+		})
+	}
+}
+
+func TestCompare_IndexChangedPredicateAndUniqueness(t *testing.T) {
+	tests := []struct {
+		name    string
+		current parser.Index
+		desired parser.Index
+	}{
+		{
+			name:    "where predicate",
+			current: parser.Index{Name: "idx", Table: "t", Columns: []string{"a"}},
+			desired: parser.Index{Name: "idx", Table: "t", Columns: []string{"a"}, Where: "a IS NOT NULL"},
+		},
+		{
+			name:    "uniqueness",
+			current: parser.Index{Name: "idx", Table: "t", Columns: []string{"a"}},
+			desired: parser.Index{Name: "idx", Table: "t", Columns: []string{"a"}, Unique: true},
+		},
+		{
+			name:    "using method",
+			current: parser.Index{Name: "idx", Table: "t", Columns: []string{"a"}},
+			desired: parser.Index{Name: "idx", Table: "t", Columns: []string{"a"}, Using: "hash"},
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			changes := Compare(&parser.Schema{Indexes: []parser.Index{tt.current}}, &parser.Schema{Indexes: []parser.Index{tt.desired}})
+			if len(changes) != 2 {
+				t.Errorf("expected 2 changes, got %d", len(changes))
+			}
+		})
+	}
+}
+
+func TestCompareWithOptions_ConcurrentIndexes(t *testing.T) {
+	current := &parser.Schema{
+		Indexes: []parser.Index{{Name: "idx_old", Table: "users", Columns: []string{"name"}}},
+	}
+	desired := &parser.Schema{
+		Indexes: []parser.Index{{Name: "idx_new", Table: "users", Columns: []string{"email"}, Unique: true}},
+	}
+
+	changes := CompareWithOptions(current, desired, Options{ConcurrentIndexes: true})
+
+	if len(changes) != 2 {
+		t.Fatalf("expected 2 changes, got %d", len(changes))
+	}
+	if sql := changes[0].SQL(); sql != "DROP INDEX CONCURRENTLY idx_old;" {
+		t.Errorf("drop SQL = %q", sql)
+	}
+	if sql := changes[1].SQL(); !strings.HasPrefix(sql, "CREATE UNIQUE INDEX CONCURRENTLY idx_new ON users") {
+		t.Errorf("create SQL = %q", sql)
+	}
+	if sql := changes[1].DownSQL(); sql != "DROP INDEX CONCURRENTLY idx_new;" {
+		t.Errorf("create DownSQL = %q", sql)
+	}
+	if sql := changes[0].DownSQL(); !strings.HasPrefix(sql, "CREATE INDEX CONCURRENTLY idx_old") {
+		t.Errorf("drop DownSQL = %q", sql)
+	}
+}
+
+func TestNeedsNoTransaction(t *testing.T) {
+	current := &parser.Schema{}
+	desired := &parser.Schema{
+		Indexes: []parser.Index{{Name: "idx_users_email", Table: "users", Columns: []string{"email"}}},
+	}
+
+	if NeedsNoTransaction(Compare(current, desired)) {
+		t.Error("plain index builds should run in a transaction")
+	}
+	if !NeedsNoTransaction(CompareWithOptions(current, desired, Options{ConcurrentIndexes: true})) {
+		t.Error("concurrent index builds need the no-transaction directive")
+	}
+}
+
+func TestSplitByTransaction(t *testing.T) {
+	current := &parser.Schema{}
+	desired := &parser.Schema{
+		Tables:  []parser.Table{{Name: "users", Columns: []parser.Column{{Name: "email", Type: "text", Nullable: true}}}},
+		Indexes: []parser.Index{{Name: "idx_users_email", Table: "users", Columns: []string{"email"}}},
+	}
+
+	changes := CompareWithOptions(current, desired, Options{ConcurrentIndexes: true})
+	runs := SplitByTransaction(changes)
+	if len(runs) != 2 {
+		t.Fatalf("SplitByTransaction() = %d runs, want 2", len(runs))
+	}
+	if NeedsNoTransaction(runs[0]) {
+		t.Error("the table should be created in a transaction")
+	}
+	if !NeedsNoTransaction(runs[1]) || len(runs[1]) != 1 {
+		t.Errorf("the concurrent index build should run alone outside a transaction, got %d change(s)", len(runs[1]))
+	}
+
+	if runs := SplitByTransaction(Compare(current, desired)); len(runs) != 1 {
+		t.Errorf("SplitByTransaction() without concurrent indexes = %d runs, want 1", len(runs))
+	}
+}
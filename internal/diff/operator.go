@@ -86,22 +86,22 @@ func compareOperators(current, desired []parser.Operator) []Change {
 	var changes []Change
 	currentMap := make(map[string]parser.Operator)
 	for _, o := range current {
-		key := fmt.Sprintf("%s(%s,%s)", o.Name, o.LeftType, o.RightType)
+		key := fmt.Sprintf("%s(%s,%s)", objectKey(o.Schema, o.Name), o.LeftType, o.RightType)
 		currentMap[key] = o
 	}
 	for _, o := range desired {
-		key := fmt.Sprintf("%s(%s,%s)", o.Name, o.LeftType, o.RightType)
+		key := fmt.Sprintf("%s(%s,%s)", objectKey(o.Schema, o.Name), o.LeftType, o.RightType)
 		if _, exists := currentMap[key]; !exists {
 			changes = append(changes, &OperatorChange{ChangeType: CreateOperator, Operator: o})
 		}
 	}
 	desiredMap := make(map[string]bool)
 	for _, o := range desired {
-		key := fmt.Sprintf("%s(%s,%s)", o.Name, o.LeftType, o.RightType)
+		key := fmt.Sprintf("%s(%s,%s)", objectKey(o.Schema, o.Name), o.LeftType, o.RightType)
 		desiredMap[key] = true
 	}
 	for _, o := range current {
-		key := fmt.Sprintf("%s(%s,%s)", o.Name, o.LeftType, o.RightType)
+		key := fmt.Sprintf("%s(%s,%s)", objectKey(o.Schema, o.Name), o.LeftType, o.RightType)
 		if !desiredMap[key] {
 			oldOp := o
 			changes = append(changes, &OperatorChange{ChangeType: DropOperator, Operator: o, OldOperator: &oldOp})
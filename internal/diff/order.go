@@ -0,0 +1,327 @@
+package diff
+
+import (
+	"strings"
+
+	"github.com/terminally-online/shrugged/internal/parser"
+)
+
+// Changes with no dependency between them keep their original relative order.
+func orderChanges(changes []Change, current, desired *parser.Schema) []Change {
+	changes = rebuildDependentViews(changes, current, desired)
+
+	byNode := make(map[string][]int)
+	for i, c := range changes {
+		if node := changeNode(c); node != "" {
+			byNode[node] = append(byNode[node], i)
+		}
+	}
+
+	after := make([][]int, len(changes))
+	inDegree := make([]int, len(changes))
+	seen := make(map[[2]int]bool)
+	addEdge := func(from, to int) {
+		if from == to || seen[[2]int{from, to}] {
+			return
+		}
+		seen[[2]int{from, to}] = true
+		after[from] = append(after[from], to)
+		inDegree[to]++
+	}
+
+	for _, indices := range byNode {
+		for k := 1; k < len(indices); k++ {
+			addEdge(indices[k-1], indices[k])
+		}
+	}
+
+	dropped := make(map[string]bool)
+	for _, c := range changes {
+		if isDropChange(c.Type()) {
+			dropped[changeNode(c)] = true
+		}
+	}
+
+	for _, dep := range current.Dependencies {
+		node := dependencyNode(dep.Kind, dep.Schema, dep.Name)
+		referenced := byNode[dependencyNode(dep.RefKind, dep.RefSchema, dep.RefName)]
+		for _, x := range byNode[node] {
+			// Once the dependent object is dropped, recreating it is
+			// governed by the desired schema's dependencies instead.
+			xDrop := isDropChange(changes[x].Type())
+			if dropped[node] && !xDrop {
+				continue
+			}
+			for _, y := range referenced {
+				if xDrop || isDropChange(changes[y].Type()) {
+					addEdge(x, y)
+				}
+			}
+		}
+	}
+
+	for _, dep := range desired.Dependencies {
+		dependents := byNode[dependencyNode(dep.Kind, dep.Schema, dep.Name)]
+		referenced := byNode[dependencyNode(dep.RefKind, dep.RefSchema, dep.RefName)]
+		for _, x := range dependents {
+			for _, y := range referenced {
+				if !isDropChange(changes[x].Type()) && !isDropChange(changes[y].Type()) {
+					addEdge(y, x)
+				}
+			}
+		}
+	}
+
+	for i, c := range changes {
+		rc, ok := c.(*ReplicaIdentityChange)
+		if !ok {
+			continue
+		}
+		for _, j := range byNode["table:"+objectKey(rc.Table.Schema, rc.Table.Name)] {
+			addEdge(j, i)
+		}
+		if rc.Table.ReplicaIdentityIndex != "" {
+			for _, j := range byNode["index:"+objectKey(rc.Table.Schema, rc.Table.ReplicaIdentityIndex)] {
+				addEdge(j, i)
+			}
+		}
+	}
+
+	// An enum swap changes its columns' types without a table change, so the
+	// views rebuilt around it need their own edges.
+	for i, c := range changes {
+		ec, ok := c.(*EnumChange)
+		if !ok {
+			continue
+		}
+		columns := make(map[string]bool)
+		for _, col := range enumSwapColumns(ec) {
+			columns[col] = true
+		}
+		for _, dep := range current.Dependencies {
+			if dep.RefKind != "table" || !columns[objectKey(dep.RefSchema, dep.RefName)+"."+dep.RefColumn] {
+				continue
+			}
+			for _, j := range byNode[dependencyNode(dep.Kind, dep.Schema, dep.Name)] {
+				if isDropChange(changes[j].Type()) {
+					addEdge(j, i)
+				} else {
+					addEdge(i, j)
+				}
+			}
+		}
+	}
+
+	ordered := make([]Change, 0, len(changes))
+	done := make([]bool, len(changes))
+	for len(ordered) < len(changes) {
+		next := -1
+		for i := range changes {
+			if !done[i] && inDegree[i] == 0 {
+				next = i
+				break
+			}
+		}
+		if next == -1 {
+			// A dependency cycle; fall back to the original order for the
+			// first remaining change.
+			for i := range changes {
+				if !done[i] {
+					next = i
+					break
+				}
+			}
+		}
+
+		done[next] = true
+		ordered = append(ordered, changes[next])
+		for _, j := range after[next] {
+			inDegree[j]--
+		}
+	}
+
+	return ordered
+}
+
+// Postgres refuses to drop a column, or change its type or collation, while a
+// view still references it. Recreating an enum changes the type of its columns.
+func rebuildDependentViews(changes []Change, current, desired *parser.Schema) []Change {
+	columns := make(map[string]bool)
+	for _, c := range changes {
+		switch c := c.(type) {
+		case *TableChange:
+			if c.ChangeType != AlterTable {
+				continue
+			}
+			for _, name := range c.DropColumns {
+				columns[objectKey(c.Table.Schema, c.Table.Name)+"."+name] = true
+			}
+			for _, alt := range c.AlterColumns {
+				if normalizeType(alt.Column.Type) != normalizeType(alt.OldColumn.Type) || alt.Column.Collation != alt.OldColumn.Collation {
+					columns[objectKey(c.Table.Schema, c.Table.Name)+"."+alt.Column.Name] = true
+				}
+			}
+		case *EnumChange:
+			for _, col := range enumSwapColumns(c) {
+				columns[col] = true
+			}
+		}
+	}
+	if len(columns) == 0 {
+		return changes
+	}
+
+	affected := make(map[string]bool)
+	for {
+		grew := false
+		for _, dep := range current.Dependencies {
+			if dep.Kind != "view" && dep.Kind != "materialized view" {
+				continue
+			}
+			node := dependencyNode(dep.Kind, dep.Schema, dep.Name)
+			if affected[node] {
+				continue
+			}
+			refNode := dependencyNode(dep.RefKind, dep.RefSchema, dep.RefName)
+			if (dep.RefKind == "table" && columns[objectKey(dep.RefSchema, dep.RefName)+"."+dep.RefColumn]) || affected[refNode] {
+				affected[node] = true
+				grew = true
+			}
+		}
+		if !grew {
+			break
+		}
+	}
+	if len(affected) == 0 {
+		return changes
+	}
+
+	var result []Change
+	handled := make(map[string]bool)
+	for _, c := range changes {
+		node := changeNode(c)
+		if !affected[node] {
+			result = append(result, c)
+			continue
+		}
+		handled[node] = true
+		switch vc := c.(type) {
+		case *ViewChange:
+			if vc.ChangeType == AlterView {
+				result = append(result,
+					&ViewChange{ChangeType: DropView, View: *vc.OldView, OldView: vc.OldView},
+					&ViewChange{ChangeType: CreateView, View: vc.View})
+				continue
+			}
+		case *MaterializedViewChange:
+			if vc.ChangeType == AlterMaterializedView {
+				result = append(result,
+					&MaterializedViewChange{ChangeType: DropMaterializedView, MaterializedView: *vc.OldMaterializedView, OldMaterializedView: vc.OldMaterializedView},
+					&MaterializedViewChange{ChangeType: CreateMaterializedView, MaterializedView: vc.MaterializedView})
+				continue
+			}
+		}
+		result = append(result, c)
+	}
+
+	for _, v := range current.Views {
+		node := "view:" + objectKey(v.Schema, v.Name)
+		if !affected[node] || handled[node] {
+			continue
+		}
+		oldView := v
+		result = append(result, &ViewChange{ChangeType: DropView, View: v, OldView: &oldView})
+		for _, d := range desired.Views {
+			if objectKey(d.Schema, d.Name) == objectKey(v.Schema, v.Name) {
+				result = append(result, &ViewChange{ChangeType: CreateView, View: d})
+			}
+		}
+	}
+
+	for _, mv := range current.MaterializedViews {
+		node := "materialized view:" + objectKey(mv.Schema, mv.Name)
+		if !affected[node] || handled[node] {
+			continue
+		}
+		oldMV := mv
+		result = append(result, &MaterializedViewChange{ChangeType: DropMaterializedView, MaterializedView: mv, OldMaterializedView: &oldMV})
+		for _, d := range desired.MaterializedViews {
+			if objectKey(d.Schema, d.Name) == objectKey(mv.Schema, mv.Name) {
+				result = append(result, &MaterializedViewChange{ChangeType: CreateMaterializedView, MaterializedView: d})
+			}
+		}
+	}
+
+	return result
+}
+
+func enumSwapColumns(c *EnumChange) []string {
+	if !c.Recreate {
+		return nil
+	}
+	columns := make([]string, len(c.Columns))
+	for i, col := range c.Columns {
+		columns[i] = objectKey(col.Schema, col.Table) + "." + col.Column
+	}
+	return columns
+}
+
+func changeNode(c Change) string {
+	switch c := c.(type) {
+	case *TableChange:
+		return "table:" + objectKey(c.Table.Schema, c.Table.Name)
+	case *RenameChange:
+		return "table:" + objectKey(c.Schema, c.Table)
+	case *IndexChange:
+		return "index:" + objectKey(c.Index.Schema, c.Index.Name)
+	case *ViewChange:
+		return "view:" + objectKey(c.View.Schema, c.View.Name)
+	case *MaterializedViewChange:
+		return "materialized view:" + objectKey(c.MaterializedView.Schema, c.MaterializedView.Name)
+	case *SequenceChange:
+		return "sequence:" + objectKey(c.Sequence.Schema, c.Sequence.Name)
+	case *FunctionChange:
+		return "function:" + functionKey(c.Function)
+	case *ProcedureChange:
+		return "procedure:" + procedureKey(c.Procedure)
+	case *AggregateChange:
+		return "aggregate:" + objectKey(c.Aggregate.Schema, c.Aggregate.Name) + "(" + normalizeType(c.Aggregate.Args) + ")"
+	case *EnumChange:
+		return "type:" + objectKey(c.Enum.Schema, c.Enum.Name)
+	case *DomainChange:
+		return "type:" + objectKey(c.Domain.Schema, c.Domain.Name)
+	case *CompositeTypeChange:
+		return "type:" + objectKey(c.CompositeType.Schema, c.CompositeType.Name)
+	case *TriggerChange:
+		return "trigger:" + tableObjectKey(c.Trigger.Schema, c.Trigger.Table, c.Trigger.Name)
+	case *PolicyChange:
+		return "policy:" + tableObjectKey(c.Policy.Schema, c.Policy.Table, c.Policy.Name)
+	case *ForeignTableChange:
+		return "foreign table:" + objectKey(c.ForeignTable.Schema, c.ForeignTable.Name)
+	}
+	return ""
+}
+
+func dependencyNode(kind, schema, name string) string {
+	switch kind {
+	case "function", "procedure", "aggregate":
+		if idx := strings.Index(name, "("); idx >= 0 {
+			args := strings.TrimSuffix(name[idx+1:], ")")
+			return kind + ":" + objectKey(schema, name[:idx]) + "(" + normalizeType(args) + ")"
+		}
+	}
+	return kind + ":" + objectKey(schema, name)
+}
+
+func isDropChange(t ChangeType) bool {
+	switch t {
+	case DropNamespace, DropExtension, DropEnum, DropDomain, DropCompositeType, DropSequence,
+		DropTable, DropIndex, DropView, DropMaterializedView, DropFunction, DropProcedure,
+		DropAggregate, DropTrigger, DropEventTrigger, DropRule, DropPolicy, DropCollation,
+		DropTextSearchConfig, DropPublication, DropSubscription, DropForeignDataWrapper,
+		DropForeignServer, DropForeignTable, DropOperator, DropRole, DropRoleGrant,
+		DropDefaultPrivilege, DropComment:
+		return true
+	}
+	return false
+}
@@ -0,0 +1,206 @@
+package diff
+
+import (
+	"testing"
+
+	"github.com/terminally-online/shrugged/internal/parser"
+)
+
+func changeTypes(changes []Change) []ChangeType {
+	types := make([]ChangeType, len(changes))
+	for i, c := range changes {
+		types[i] = c.Type()
+	}
+	return types
+}
+
+func indexOfChange(changes []Change, t ChangeType, name string) int {
+	for i, c := range changes {
+		if c.Type() == t && c.ObjectName() == name {
+			return i
+		}
+	}
+	return -1
+}
+
+func TestCompare_ViewRebuiltAroundColumnTypeChange(t *testing.T) {
+	view := parser.View{Name: "adults", Definition: "SELECT users.id, users.age FROM users WHERE users.age >= 18"}
+	current := &parser.Schema{
+		Tables: []parser.Table{
+			{Name: "users", Columns: []parser.Column{{Name: "id", Type: "integer"}, {Name: "age", Type: "integer", Nullable: true}}},
+		},
+		Views: []parser.View{view},
+		Dependencies: []parser.Dependency{
+			{Kind: "view", Schema: "public", Name: "adults", RefKind: "table", RefSchema: "public", RefName: "users", RefColumn: "age"},
+		},
+	}
+	desired := &parser.Schema{
+		Tables: []parser.Table{
+			{Name: "users", Columns: []parser.Column{{Name: "id", Type: "integer"}, {Name: "age", Type: "bigint", Nullable: true}}},
+		},
+		Views: []parser.View{view},
+		Dependencies: []parser.Dependency{
+			{Kind: "view", Schema: "public", Name: "adults", RefKind: "table", RefSchema: "public", RefName: "users", RefColumn: "age"},
+		},
+	}
+
+	changes := Compare(current, desired)
+
+	want := []ChangeType{DropView, AlterTable, CreateView}
+	got := changeTypes(changes)
+	if len(got) != len(want) {
+		t.Fatalf("change types = %v, want %v", got, want)
+	}
+	for i := range want {
+		if got[i] != want[i] {
+			t.Fatalf("change types = %v, want %v", got, want)
+		}
+	}
+	if changes[0].DownSQL() == "" || !changes[0].IsReversible() {
+		t.Error("dropped view should be restorable in the down migration")
+	}
+}
+
+func TestCompare_DependentViewsRebuiltTransitively(t *testing.T) {
+	current := &parser.Schema{
+		Tables: []parser.Table{
+			{Name: "users", Columns: []parser.Column{{Name: "id", Type: "integer"}, {Name: "legacy", Type: "text"}}},
+		},
+		Views: []parser.View{
+			{Name: "base_view", Definition: "SELECT users.id, users.legacy FROM users"},
+			{Name: "top_view", Definition: "SELECT base_view.id FROM base_view"},
+		},
+		Dependencies: []parser.Dependency{
+			{Kind: "view", Schema: "public", Name: "base_view", RefKind: "table", RefSchema: "public", RefName: "users", RefColumn: "legacy"},
+			{Kind: "view", Schema: "public", Name: "top_view", RefKind: "view", RefSchema: "public", RefName: "base_view", RefColumn: "id"},
+		},
+	}
+	desired := &parser.Schema{
+		Tables: []parser.Table{
+			{Name: "users", Columns: []parser.Column{{Name: "id", Type: "integer"}}},
+		},
+		Views: []parser.View{
+			{Name: "base_view", Definition: "SELECT users.id FROM users"},
+			{Name: "top_view", Definition: "SELECT base_view.id FROM base_view"},
+		},
+		Dependencies: []parser.Dependency{
+			{Kind: "view", Schema: "public", Name: "base_view", RefKind: "table", RefSchema: "public", RefName: "users", RefColumn: "id"},
+			{Kind: "view", Schema: "public", Name: "top_view", RefKind: "view", RefSchema: "public", RefName: "base_view", RefColumn: "id"},
+		},
+	}
+
+	changes := Compare(current, desired)
+
+	dropTop := indexOfChange(changes, DropView, "top_view")
+	dropBase := indexOfChange(changes, DropView, "base_view")
+	alter := indexOfChange(changes, AlterTable, "users")
+	createBase := indexOfChange(changes, CreateView, "base_view")
+	createTop := indexOfChange(changes, CreateView, "top_view")
+
+	if dropTop < 0 || dropBase < 0 || alter < 0 || createBase < 0 || createTop < 0 {
+		t.Fatalf("missing expected changes, got %v", changeTypes(changes))
+	}
+	if !(dropTop < dropBase && dropBase < alter && alter < createBase && createBase < createTop) {
+		t.Errorf("unexpected order %v", changeTypes(changes))
+	}
+}
+
+func TestCompare_ViewRebuiltAroundEnumSwap(t *testing.T) {
+	current := &parser.Schema{
+		Enums: []parser.Enum{{Name: "mood", Values: []string{"happy", "sad", "meh"}}},
+		Tables: []parser.Table{
+			{Name: "users", Columns: []parser.Column{{Name: "id", Type: "integer"}, {Name: "status", Type: "mood"}}},
+		},
+		Views: []parser.View{
+			{Name: "user_moods", Definition: "SELECT users.id, users.status FROM users"},
+		},
+		Dependencies: []parser.Dependency{
+			{Kind: "view", Schema: "public", Name: "user_moods", RefKind: "table", RefSchema: "public", RefName: "users", RefColumn: "status"},
+		},
+	}
+	desired := &parser.Schema{
+		Enums: []parser.Enum{{Name: "mood", Values: []string{"happy", "sad"}}},
+		Tables: []parser.Table{
+			{Name: "users", Columns: []parser.Column{{Name: "id", Type: "integer"}, {Name: "status", Type: "mood"}}},
+		},
+		Views: []parser.View{
+			{Name: "user_moods", Definition: "SELECT users.id, users.status FROM users"},
+		},
+		Dependencies: current.Dependencies,
+	}
+
+	changes := Compare(current, desired)
+
+	drop := indexOfChange(changes, DropView, "user_moods")
+	swap := indexOfChange(changes, AlterEnum, "mood")
+	create := indexOfChange(changes, CreateView, "user_moods")
+
+	if drop < 0 || swap < 0 || create < 0 {
+		t.Fatalf("missing expected changes, got %v", changeTypes(changes))
+	}
+	if !(drop < swap && swap < create) {
+		t.Errorf("unexpected order %v", changeTypes(changes))
+	}
+}
+
+func TestCompare_TypeDroppedAfterColumnStopsUsingIt(t *testing.T) {
+	current := &parser.Schema{
+		Enums: []parser.Enum{{Name: "mood", Values: []string{"happy", "sad"}}},
+		Tables: []parser.Table{
+			{Name: "users", Columns: []parser.Column{{Name: "status", Type: "mood"}}},
+		},
+		Dependencies: []parser.Dependency{
+			{Kind: "table", Schema: "public", Name: "users", RefKind: "type", RefSchema: "public", RefName: "mood"},
+		},
+	}
+	desired := &parser.Schema{
+		Tables: []parser.Table{
+			{Name: "users", Columns: []parser.Column{{Name: "status", Type: "text"}}},
+		},
+	}
+
+	changes := Compare(current, desired)
+
+	alter := indexOfChange(changes, AlterTable, "users")
+	drop := indexOfChange(changes, DropEnum, "mood")
+	if alter < 0 || drop < 0 || alter > drop {
+		t.Errorf("expected AlterTable before DropEnum, got %v", changeTypes(changes))
+	}
+}
+
+func TestCompare_FunctionCreatedBeforeTableUsingIt(t *testing.T) {
+	current := &parser.Schema{}
+	desired := &parser.Schema{
+		Tables: []parser.Table{
+			{Name: "orders", Columns: []parser.Column{{Name: "code", Type: "text", Default: "next_code()"}}},
+		},
+		Functions: []parser.Function{
+			{Schema: "public", Name: "next_code", Returns: "text", Language: "sql", Body: "SELECT 'x'"},
+		},
+		Dependencies: []parser.Dependency{
+			{Kind: "table", Schema: "public", Name: "orders", RefKind: "function", RefSchema: "public", RefName: "next_code()"},
+		},
+	}
+
+	changes := Compare(current, desired)
+
+	fn := indexOfChange(changes, CreateFunction, "next_code")
+	table := indexOfChange(changes, CreateTable, "orders")
+	if fn < 0 || table < 0 || fn > table {
+		t.Errorf("expected CreateFunction before CreateTable, got %v", changeTypes(changes))
+	}
+}
+
+func TestCompare_UnrelatedChangesKeepCategoryOrder(t *testing.T) {
+	current := &parser.Schema{}
+	desired := &parser.Schema{
+		Tables:    []parser.Table{{Name: "users", Columns: []parser.Column{{Name: "id", Type: "integer"}}}},
+		Functions: []parser.Function{{Name: "noop", Returns: "void", Language: "sql", Body: "SELECT"}},
+	}
+
+	changes := Compare(current, desired)
+
+	if indexOfChange(changes, CreateTable, "users") > indexOfChange(changes, CreateFunction, "noop") {
+		t.Errorf("expected tables before functions without dependencies, got %v", changeTypes(changes))
+	}
+}
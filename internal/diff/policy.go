@@ -2,6 +2,7 @@ package diff
 
 import (
 	"fmt"
+	"sort"
 	"strings"
 
 	"github.com/terminally-online/shrugged/internal/parser"
@@ -17,25 +18,13 @@ func (c *PolicyChange) SQL() string {
 	tableName := qualifiedName(c.Policy.Schema, c.Policy.Table)
 	switch c.ChangeType {
 	case CreatePolicy:
-		sql := fmt.Sprintf("CREATE POLICY %s ON %s", quoteIdent(c.Policy.Name), tableName)
-		if !c.Policy.Permissive {
-			sql += " AS RESTRICTIVE"
-		}
-		if c.Policy.Command != "ALL" {
-			sql += fmt.Sprintf(" FOR %s", c.Policy.Command)
-		}
-		if len(c.Policy.Roles) > 0 {
-			sql += fmt.Sprintf(" TO %s", strings.Join(c.Policy.Roles, ", "))
-		}
-		if c.Policy.Using != "" {
-			sql += fmt.Sprintf(" USING (%s)", c.Policy.Using)
-		}
-		if c.Policy.WithCheck != "" {
-			sql += fmt.Sprintf(" WITH CHECK (%s)", c.Policy.WithCheck)
-		}
-		return sql + ";"
+		return generateCreatePolicy(c.Policy)
 	case DropPolicy:
 		return fmt.Sprintf("DROP POLICY %s ON %s;", quoteIdent(c.Policy.Name), tableName)
+	case AlterPolicy:
+		if c.OldPolicy != nil {
+			return generateAlterPolicy(*c.OldPolicy, c.Policy)
+		}
 	}
 	return ""
 }
@@ -47,26 +36,14 @@ func (c *PolicyChange) DownSQL() string {
 		return fmt.Sprintf("DROP POLICY %s ON %s;", quoteIdent(c.Policy.Name), tableName)
 	case DropPolicy:
 		if c.OldPolicy != nil {
-			oldTableName := qualifiedName(c.OldPolicy.Schema, c.OldPolicy.Table)
-			sql := fmt.Sprintf("CREATE POLICY %s ON %s", quoteIdent(c.OldPolicy.Name), oldTableName)
-			if !c.OldPolicy.Permissive {
-				sql += " AS RESTRICTIVE"
-			}
-			if c.OldPolicy.Command != "ALL" {
-				sql += fmt.Sprintf(" FOR %s", c.OldPolicy.Command)
-			}
-			if len(c.OldPolicy.Roles) > 0 {
-				sql += fmt.Sprintf(" TO %s", strings.Join(c.OldPolicy.Roles, ", "))
-			}
-			if c.OldPolicy.Using != "" {
-				sql += fmt.Sprintf(" USING (%s)", c.OldPolicy.Using)
-			}
-			if c.OldPolicy.WithCheck != "" {
-				sql += fmt.Sprintf(" WITH CHECK (%s)", c.OldPolicy.WithCheck)
-			}
-			return sql + ";"
+			return generateCreatePolicy(*c.OldPolicy)
 		}
 		return fmt.Sprintf("-- IRREVERSIBLE: Cannot restore dropped policy %s", c.Policy.Name)
+	case AlterPolicy:
+		if c.OldPolicy != nil {
+			return generateAlterPolicy(c.Policy, *c.OldPolicy)
+		}
+		return fmt.Sprintf("-- IRREVERSIBLE: Cannot restore previous policy %s", c.Policy.Name)
 	}
 	return ""
 }
@@ -85,22 +62,32 @@ func comparePolicies(current, desired []parser.Policy) []Change {
 
 	currentMap := make(map[string]parser.Policy)
 	for _, p := range current {
-		currentMap[p.Name] = p
+		currentMap[tableObjectKey(p.Schema, p.Table, p.Name)] = p
 	}
 
 	desiredMap := make(map[string]parser.Policy)
 	for _, p := range desired {
-		desiredMap[p.Name] = p
+		desiredMap[tableObjectKey(p.Schema, p.Table, p.Name)] = p
 	}
 
 	for _, p := range desired {
-		if _, exists := currentMap[p.Name]; !exists {
+		existing, exists := currentMap[tableObjectKey(p.Schema, p.Table, p.Name)]
+		if !exists {
 			changes = append(changes, &PolicyChange{ChangeType: CreatePolicy, Policy: p})
+			continue
+		}
+
+		oldPol := existing
+		if !policyAlterable(existing, p) {
+			changes = append(changes, &PolicyChange{ChangeType: DropPolicy, Policy: existing, OldPolicy: &oldPol})
+			changes = append(changes, &PolicyChange{ChangeType: CreatePolicy, Policy: p})
+		} else if generateAlterPolicy(existing, p) != "" {
+			changes = append(changes, &PolicyChange{ChangeType: AlterPolicy, Policy: p, OldPolicy: &oldPol})
 		}
 	}
 
 	for _, p := range current {
-		if _, exists := desiredMap[p.Name]; !exists {
+		if _, exists := desiredMap[tableObjectKey(p.Schema, p.Table, p.Name)]; !exists {
 			oldPol := p
 			changes = append(changes, &PolicyChange{ChangeType: DropPolicy, Policy: p, OldPolicy: &oldPol})
 		}
@@ -108,3 +95,64 @@ func comparePolicies(current, desired []parser.Policy) []Change {
 
 	return changes
 }
+
+func generateCreatePolicy(p parser.Policy) string {
+	sql := fmt.Sprintf("CREATE POLICY %s ON %s", quoteIdent(p.Name), qualifiedName(p.Schema, p.Table))
+	if !p.Permissive {
+		sql += " AS RESTRICTIVE"
+	}
+	if p.Command != "ALL" {
+		sql += fmt.Sprintf(" FOR %s", p.Command)
+	}
+	if len(p.Roles) > 0 {
+		sql += fmt.Sprintf(" TO %s", strings.Join(p.Roles, ", "))
+	}
+	if p.Using != "" {
+		sql += fmt.Sprintf(" USING (%s)", p.Using)
+	}
+	if p.WithCheck != "" {
+		sql += fmt.Sprintf(" WITH CHECK (%s)", p.WithCheck)
+	}
+	return sql + ";"
+}
+
+// policyAlterable reports whether ALTER POLICY can turn one policy into the
+// other and back. The command and permissiveness are fixed at creation, and
+// a USING or WITH CHECK expression can be replaced but never removed.
+func policyAlterable(from, to parser.Policy) bool {
+	if from.Command != to.Command || from.Permissive != to.Permissive {
+		return false
+	}
+	if (from.Using == "") != (to.Using == "") {
+		return false
+	}
+	return (from.WithCheck == "") == (to.WithCheck == "")
+}
+
+func generateAlterPolicy(from, to parser.Policy) string {
+	var clauses []string
+
+	if policyRoles(from) != policyRoles(to) {
+		clauses = append(clauses, fmt.Sprintf(" TO %s", policyRoles(to)))
+	}
+	if normalizeSQL(from.Using) != normalizeSQL(to.Using) {
+		clauses = append(clauses, fmt.Sprintf(" USING (%s)", to.Using))
+	}
+	if normalizeSQL(from.WithCheck) != normalizeSQL(to.WithCheck) {
+		clauses = append(clauses, fmt.Sprintf(" WITH CHECK (%s)", to.WithCheck))
+	}
+
+	if len(clauses) == 0 {
+		return ""
+	}
+	return fmt.Sprintf("ALTER POLICY %s ON %s%s;", quoteIdent(to.Name), qualifiedName(to.Schema, to.Table), strings.Join(clauses, ""))
+}
+
+func policyRoles(p parser.Policy) string {
+	if len(p.Roles) == 0 {
+		return "PUBLIC"
+	}
+	roles := append([]string(nil), p.Roles...)
+	sort.Strings(roles)
+	return strings.Join(roles, ", ")
+}
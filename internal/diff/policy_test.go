@@ -201,3 +201,44 @@ func TestPolicyChange_IsReversible(t *testing.T) {
 		t.Error("DropPolicy without OldPolicy should not be reversible")
 	}
 }
+
+func TestCompare_AlterPolicy(t *testing.T) {
+	current := &parser.Schema{
+		Policies: []parser.Policy{{
+			Name: "own_rows", Table: "docs", Command: "ALL", Permissive: true,
+			Roles: []string{"app"}, Using: "(owner = CURRENT_USER)",
+		}},
+	}
+	desired := &parser.Schema{
+		Policies: []parser.Policy{{
+			Name: "own_rows", Table: "docs", Command: "ALL", Permissive: true,
+			Roles: []string{"app", "admin"}, Using: "(owner = CURRENT_USER OR shared)",
+		}},
+	}
+
+	changes := Compare(current, desired)
+	if len(changes) != 1 || changes[0].Type() != AlterPolicy {
+		t.Fatalf("expected a single AlterPolicy change, got %d", len(changes))
+	}
+
+	if got := changes[0].SQL(); got != "ALTER POLICY own_rows ON docs TO admin, app USING ((owner = CURRENT_USER OR shared));" {
+		t.Errorf("SQL = %q", got)
+	}
+	if got := changes[0].DownSQL(); got != "ALTER POLICY own_rows ON docs TO app USING ((owner = CURRENT_USER));" {
+		t.Errorf("DownSQL = %q", got)
+	}
+}
+
+func TestCompare_PolicyCommandChanged(t *testing.T) {
+	current := &parser.Schema{
+		Policies: []parser.Policy{{Name: "read", Table: "docs", Command: "SELECT", Permissive: true, Using: "true"}},
+	}
+	desired := &parser.Schema{
+		Policies: []parser.Policy{{Name: "read", Table: "docs", Command: "ALL", Permissive: true, Using: "true"}},
+	}
+
+	changes := Compare(current, desired)
+	if len(changes) != 2 || changes[0].Type() != DropPolicy || changes[1].Type() != CreatePolicy {
+		t.Fatalf("expected DropPolicy then CreatePolicy, got %d changes", len(changes))
+	}
+}
@@ -24,12 +24,9 @@ func (c *ProcedureChange) SQL() string {
 			c.Procedure.Language,
 			c.Procedure.Body)
 	case DropProcedure:
-		if c.Procedure.Args != "" {
-			return fmt.Sprintf("DROP PROCEDURE %s(%s);",
-				qualifiedName(c.Procedure.Schema, c.Procedure.Name),
-				c.Procedure.Args)
-		}
-		return fmt.Sprintf("DROP PROCEDURE %s;", qualifiedName(c.Procedure.Schema, c.Procedure.Name))
+		return fmt.Sprintf("DROP PROCEDURE %s(%s);",
+			qualifiedName(c.Procedure.Schema, c.Procedure.Name),
+			routineSignature(c.Procedure.Args, c.Procedure.IdentityArgs))
 	}
 	return ""
 }
@@ -37,12 +34,9 @@ func (c *ProcedureChange) SQL() string {
 func (c *ProcedureChange) DownSQL() string {
 	switch c.ChangeType {
 	case CreateProcedure:
-		if c.Procedure.Args != "" {
-			return fmt.Sprintf("DROP PROCEDURE %s(%s);",
-				qualifiedName(c.Procedure.Schema, c.Procedure.Name),
-				c.Procedure.Args)
-		}
-		return fmt.Sprintf("DROP PROCEDURE %s;", qualifiedName(c.Procedure.Schema, c.Procedure.Name))
+		return fmt.Sprintf("DROP PROCEDURE %s(%s);",
+			qualifiedName(c.Procedure.Schema, c.Procedure.Name),
+			routineSignature(c.Procedure.Args, c.Procedure.IdentityArgs))
 	case DropProcedure:
 		if c.OldProcedure != nil {
 			if c.OldProcedure.Definition != "" {
@@ -88,29 +82,36 @@ func compareProcedures(current, desired []parser.Procedure) []Change {
 	var changes []Change
 	currentMap := make(map[string]parser.Procedure)
 	for _, p := range current {
-		key := objectKey(p.Schema, p.Name) + "(" + p.Args + ")"
-		currentMap[key] = p
+		currentMap[procedureKey(p)] = p
 	}
 	for _, p := range desired {
-		key := objectKey(p.Schema, p.Name) + "(" + p.Args + ")"
-		if existing, exists := currentMap[key]; !exists {
+		if existing, exists := currentMap[procedureKey(p)]; !exists {
 			changes = append(changes, &ProcedureChange{ChangeType: CreateProcedure, Procedure: p})
-		} else if existing.Body != p.Body {
+		} else if !proceduresEqual(existing, p) {
 			oldProc := existing
 			changes = append(changes, &ProcedureChange{ChangeType: AlterProcedure, Procedure: p, OldProcedure: &oldProc})
 		}
 	}
 	desiredMap := make(map[string]bool)
 	for _, p := range desired {
-		key := objectKey(p.Schema, p.Name) + "(" + p.Args + ")"
-		desiredMap[key] = true
+		desiredMap[procedureKey(p)] = true
 	}
 	for _, p := range current {
-		key := objectKey(p.Schema, p.Name) + "(" + p.Args + ")"
-		if !desiredMap[key] {
+		if !desiredMap[procedureKey(p)] {
 			oldProc := p
 			changes = append(changes, &ProcedureChange{ChangeType: DropProcedure, Procedure: p, OldProcedure: &oldProc})
 		}
 	}
 	return changes
 }
+
+func procedureKey(p parser.Procedure) string {
+	return objectKey(p.Schema, p.Name) + "(" + normalizeType(routineSignature(p.Args, p.IdentityArgs)) + ")"
+}
+
+func proceduresEqual(a, b parser.Procedure) bool {
+	if a.Definition != "" && b.Definition != "" {
+		return normalizeSQL(a.Definition) == normalizeSQL(b.Definition)
+	}
+	return a.Body == b.Body
+}
@@ -132,6 +132,18 @@ func TestProcedureChange_SQL(t *testing.T) {
 			},
 			want: []string{"DROP PROCEDURE", "simple_proc"},
 		},
+		{
+			name: "drop procedure uses identity args",
+			change: &ProcedureChange{
+				ChangeType: DropProcedure,
+				Procedure: parser.Procedure{
+					Name:         "archive",
+					Args:         "p_id integer, p_force boolean DEFAULT false",
+					IdentityArgs: "p_id integer, p_force boolean",
+				},
+			},
+			want: []string{"DROP PROCEDURE archive(p_id integer, p_force boolean);"},
+		},
 	}
 
 	for _, tt := range tests {
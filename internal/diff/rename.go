@@ -0,0 +1,301 @@
+package diff
+
+import (
+	"fmt"
+	"regexp"
+	"strings"
+
+	"github.com/terminally-online/shrugged/internal/parser"
+)
+
+type RenameChange struct {
+	ChangeType ChangeType
+	Schema     string
+	Table      string
+	Column     string
+	From       string
+}
+
+func (c *RenameChange) SQL() string {
+	switch c.ChangeType {
+	case RenameTable:
+		return fmt.Sprintf("ALTER TABLE %s RENAME TO %s;", qualifiedName(c.Schema, c.From), quoteIdent(c.Table))
+	case RenameColumn:
+		return fmt.Sprintf("ALTER TABLE %s RENAME COLUMN %s TO %s;", qualifiedName(c.Schema, c.Table), quoteIdent(c.From), quoteIdent(c.Column))
+	}
+	return ""
+}
+
+func (c *RenameChange) DownSQL() string {
+	switch c.ChangeType {
+	case RenameTable:
+		return fmt.Sprintf("ALTER TABLE %s RENAME TO %s;", qualifiedName(c.Schema, c.Table), quoteIdent(c.From))
+	case RenameColumn:
+		return fmt.Sprintf("ALTER TABLE %s RENAME COLUMN %s TO %s;", qualifiedName(c.Schema, c.Table), quoteIdent(c.Column), quoteIdent(c.From))
+	}
+	return ""
+}
+
+func (c *RenameChange) Type() ChangeType   { return c.ChangeType }
+func (c *RenameChange) ObjectName() string { return c.Table }
+func (c *RenameChange) IsReversible() bool { return true }
+
+// compareRenames turns the confirmed renames into rename changes and returns a
+// copy of the current schema with those renames already applied, so the rest
+// of the comparison sees the renamed objects as unchanged.
+func compareRenames(current, desired *parser.Schema, renames []parser.Rename) ([]Change, *parser.Schema) {
+	if len(renames) == 0 {
+		return nil, current
+	}
+
+	var changes []Change
+	renamed := *current
+	renamed.Tables = append([]parser.Table(nil), current.Tables...)
+	renamed.Indexes = append([]parser.Index(nil), current.Indexes...)
+	renamed.Triggers = append([]parser.Trigger(nil), current.Triggers...)
+	renamed.Policies = append([]parser.Policy(nil), current.Policies...)
+	renamed.Dependencies = append([]parser.Dependency(nil), current.Dependencies...)
+
+	for _, r := range renames {
+		if r.Column != "" {
+			continue
+		}
+		if findTable(renamed.Tables, r.Schema, r.From) == nil ||
+			findTable(renamed.Tables, r.Schema, r.Table) != nil ||
+			findTable(desired.Tables, r.Schema, r.Table) == nil ||
+			findTable(desired.Tables, r.Schema, r.From) != nil {
+			continue
+		}
+		renameTable(&renamed, r.Schema, r.From, r.Table)
+		changes = append(changes, &RenameChange{ChangeType: RenameTable, Schema: r.Schema, Table: r.Table, From: r.From})
+	}
+
+	for _, r := range renames {
+		if r.Column == "" {
+			continue
+		}
+		currentTable := findTable(renamed.Tables, r.Schema, r.Table)
+		desiredTable := findTable(desired.Tables, r.Schema, r.Table)
+		if currentTable == nil || desiredTable == nil ||
+			findColumn(currentTable, r.From) == nil || findColumn(currentTable, r.Column) != nil ||
+			findColumn(desiredTable, r.Column) == nil || findColumn(desiredTable, r.From) != nil {
+			continue
+		}
+		renameColumn(&renamed, r.Schema, r.Table, r.From, r.Column)
+		changes = append(changes, &RenameChange{ChangeType: RenameColumn, Schema: r.Schema, Table: r.Table, Column: r.Column, From: r.From})
+	}
+
+	return changes, &renamed
+}
+
+// DetectRenames suggests likely renames that the caller has not confirmed
+// yet: a dropped table whose columns match a created table, a dropped
+// column with the same type as a column added to the same table, and an enum
+// value replaced by a new value in the same position.
+func DetectRenames(current, desired *parser.Schema, known []parser.Rename) []parser.Rename {
+	_, current = compareRenames(current, desired, known)
+
+	var candidates []parser.Rename
+	claimed := make(map[string]bool)
+
+	for _, d := range desired.Tables {
+		if findTable(current.Tables, d.Schema, d.Name) != nil {
+			continue
+		}
+		for _, c := range current.Tables {
+			key := objectKey(c.Schema, c.Name)
+			if claimed[key] || objectKey(c.Schema, d.Name) != objectKey(d.Schema, d.Name) ||
+				findTable(desired.Tables, c.Schema, c.Name) != nil || !sameColumns(c, d) {
+				continue
+			}
+			claimed[key] = true
+			candidates = append(candidates, parser.Rename{Schema: d.Schema, Table: d.Name, From: c.Name})
+			break
+		}
+	}
+
+	for _, d := range desired.Tables {
+		c := findTable(current.Tables, d.Schema, d.Name)
+		if c == nil {
+			continue
+		}
+		used := make(map[string]bool)
+		for _, added := range d.Columns {
+			if findColumn(c, added.Name) != nil {
+				continue
+			}
+			for _, dropped := range c.Columns {
+				if used[dropped.Name] || findColumn(&d, dropped.Name) != nil ||
+					normalizeType(dropped.Type) != normalizeType(added.Type) {
+					continue
+				}
+				used[dropped.Name] = true
+				candidates = append(candidates, parser.Rename{Schema: d.Schema, Table: d.Name, Column: added.Name, From: dropped.Name})
+				break
+			}
+		}
+	}
+
+	for _, d := range desired.Enums {
+		for _, c := range current.Enums {
+			if objectKey(c.Schema, c.Name) == objectKey(d.Schema, d.Name) {
+				candidates = append(candidates, detectEnumValueRenames(c, d, known)...)
+			}
+		}
+	}
+
+	return candidates
+}
+
+func sameColumns(a, b parser.Table) bool {
+	if len(a.Columns) != len(b.Columns) {
+		return false
+	}
+	for i := range a.Columns {
+		if a.Columns[i].Name != b.Columns[i].Name || normalizeType(a.Columns[i].Type) != normalizeType(b.Columns[i].Type) {
+			return false
+		}
+	}
+	return true
+}
+
+func findTable(tables []parser.Table, schema, name string) *parser.Table {
+	for i := range tables {
+		if objectKey(tables[i].Schema, tables[i].Name) == objectKey(schema, name) {
+			return &tables[i]
+		}
+	}
+	return nil
+}
+
+func findColumn(t *parser.Table, name string) *parser.Column {
+	for i := range t.Columns {
+		if t.Columns[i].Name == name {
+			return &t.Columns[i]
+		}
+	}
+	return nil
+}
+
+func renameTable(s *parser.Schema, schema, from, to string) {
+	key := objectKey(schema, from)
+	for i := range s.Tables {
+		t := &s.Tables[i]
+		if objectKey(t.Schema, t.Name) == key {
+			t.Name = to
+		}
+		t.Constraints = append([]parser.Constraint(nil), t.Constraints...)
+		for j := range t.Constraints {
+			c := &t.Constraints[j]
+			if c.RefTable != "" && refTableKey(t.Schema, c.RefTable) == key {
+				c.RefTable = replaceIdent(c.RefTable, from, to)
+				c.Definition = replaceIdent(c.Definition, from, to)
+			}
+		}
+	}
+	for i := range s.Indexes {
+		if objectKey(s.Indexes[i].Schema, s.Indexes[i].Table) == key {
+			s.Indexes[i].Table = to
+			s.Indexes[i].Definition = replaceIdent(s.Indexes[i].Definition, from, to)
+		}
+	}
+	for i := range s.Triggers {
+		if objectKey(s.Triggers[i].Schema, s.Triggers[i].Table) == key {
+			s.Triggers[i].Table = to
+			s.Triggers[i].Definition = replaceIdent(s.Triggers[i].Definition, from, to)
+		}
+	}
+	for i := range s.Policies {
+		if objectKey(s.Policies[i].Schema, s.Policies[i].Table) == key {
+			s.Policies[i].Table = to
+			s.Policies[i].Definition = replaceIdent(s.Policies[i].Definition, from, to)
+		}
+	}
+	for i := range s.Dependencies {
+		d := &s.Dependencies[i]
+		if d.Kind == "table" && objectKey(d.Schema, d.Name) == key {
+			d.Name = to
+		}
+		if d.RefKind == "table" && objectKey(d.RefSchema, d.RefName) == key {
+			d.RefName = to
+		}
+	}
+}
+
+func renameColumn(s *parser.Schema, schema, table, from, to string) {
+	key := objectKey(schema, table)
+	for i := range s.Tables {
+		t := &s.Tables[i]
+		isTable := objectKey(t.Schema, t.Name) == key
+		if isTable {
+			t.Columns = append([]parser.Column(nil), t.Columns...)
+			for j := range t.Columns {
+				if t.Columns[j].Name == from {
+					t.Columns[j].Name = to
+				}
+			}
+		}
+		t.Constraints = append([]parser.Constraint(nil), t.Constraints...)
+		for j := range t.Constraints {
+			c := &t.Constraints[j]
+			references := c.RefTable != "" && refTableKey(t.Schema, c.RefTable) == key
+			if isTable {
+				c.Columns = renameInList(c.Columns, from, to)
+				c.Check = replaceIdent(c.Check, from, to)
+			}
+			if references {
+				c.RefColumns = renameInList(c.RefColumns, from, to)
+			}
+			if isTable || references {
+				c.Definition = replaceIdent(c.Definition, from, to)
+			}
+		}
+	}
+	for i := range s.Indexes {
+		if objectKey(s.Indexes[i].Schema, s.Indexes[i].Table) == key {
+			s.Indexes[i].Columns = renameInList(s.Indexes[i].Columns, from, to)
+			s.Indexes[i].Definition = replaceIdent(s.Indexes[i].Definition, from, to)
+		}
+	}
+	for i := range s.Dependencies {
+		d := &s.Dependencies[i]
+		if d.RefKind == "table" && objectKey(d.RefSchema, d.RefName) == key && d.RefColumn == from {
+			d.RefColumn = to
+		}
+	}
+}
+
+func refTableKey(schema, refTable string) string {
+	if idx := strings.LastIndex(refTable, "."); idx >= 0 {
+		return objectKey(refTable[:idx], refTable[idx+1:])
+	}
+	return objectKey(schema, refTable)
+}
+
+func renameInList(names []string, from, to string) []string {
+	result := make([]string, len(names))
+	for i, n := range names {
+		if n == from {
+			n = to
+		}
+		result[i] = n
+	}
+	return result
+}
+
+// replaceIdent swaps whole-word occurrences of an identifier, quoted or not,
+// inside a definition returned by the catalog.
+func replaceIdent(s, from, to string) string {
+	if s == "" {
+		return s
+	}
+	re := regexp.MustCompile(`(^|[^\w"])(` + regexp.QuoteMeta(quoteIdent(from)) + `|` + regexp.QuoteMeta(from) + `)([^\w"]|$)`)
+	replacement := "${1}" + quoteIdent(to) + "${3}"
+	for {
+		next := re.ReplaceAllString(s, replacement)
+		if next == s {
+			return s
+		}
+		s = next
+	}
+}
@@ -0,0 +1,204 @@
+package diff
+
+import (
+	"reflect"
+	"strings"
+	"testing"
+
+	"github.com/terminally-online/shrugged/internal/parser"
+)
+
+func TestCompare_RenameColumn(t *testing.T) {
+	current := &parser.Schema{
+		Tables: []parser.Table{
+			{Name: "users", Columns: []parser.Column{{Name: "id", Type: "integer"}, {Name: "email", Type: "text"}}},
+		},
+		Indexes: []parser.Index{
+			{Name: "users_email_idx", Table: "users", Columns: []string{"email"}, Definition: "CREATE INDEX users_email_idx ON public.users USING btree (email)"},
+		},
+	}
+	desired := &parser.Schema{
+		Tables: []parser.Table{
+			{Name: "users", Columns: []parser.Column{{Name: "id", Type: "integer"}, {Name: "email_address", Type: "text"}}},
+		},
+		Indexes: []parser.Index{
+			{Name: "users_email_idx", Table: "users", Columns: []string{"email_address"}, Definition: "CREATE INDEX users_email_idx ON public.users USING btree (email_address)"},
+		},
+	}
+
+	changes := CompareWithOptions(current, desired, Options{
+		Renames: []parser.Rename{{Table: "users", Column: "email_address", From: "email"}},
+	})
+
+	if len(changes) != 1 {
+		for _, c := range changes {
+			t.Log(c.SQL())
+		}
+		t.Fatalf("expected 1 change, got %d", len(changes))
+	}
+	if got := changes[0].SQL(); got != "ALTER TABLE users RENAME COLUMN email TO email_address;" {
+		t.Errorf("SQL = %q", got)
+	}
+	if got := changes[0].DownSQL(); got != "ALTER TABLE users RENAME COLUMN email_address TO email;" {
+		t.Errorf("DownSQL = %q", got)
+	}
+	if !changes[0].IsReversible() {
+		t.Error("column rename should be reversible")
+	}
+}
+
+func TestCompare_RenameTable(t *testing.T) {
+	current := &parser.Schema{
+		Tables: []parser.Table{
+			{Name: "users", Columns: []parser.Column{{Name: "id", Type: "integer"}}},
+			{
+				Name:    "posts",
+				Columns: []parser.Column{{Name: "user_id", Type: "integer"}},
+				Constraints: []parser.Constraint{
+					{Name: "posts_user_id_fkey", Type: "FOREIGN KEY", Columns: []string{"user_id"}, RefTable: "users", RefColumns: []string{"id"}, Definition: "FOREIGN KEY (user_id) REFERENCES users(id)"},
+				},
+			},
+		},
+	}
+	desired := &parser.Schema{
+		Tables: []parser.Table{
+			{Name: "accounts", Columns: []parser.Column{{Name: "id", Type: "integer"}}},
+			{
+				Name:    "posts",
+				Columns: []parser.Column{{Name: "user_id", Type: "integer"}},
+				Constraints: []parser.Constraint{
+					{Name: "posts_user_id_fkey", Type: "FOREIGN KEY", Columns: []string{"user_id"}, RefTable: "accounts", RefColumns: []string{"id"}, Definition: "FOREIGN KEY (user_id) REFERENCES accounts(id)"},
+				},
+			},
+		},
+	}
+
+	changes := CompareWithOptions(current, desired, Options{
+		Renames: []parser.Rename{{Table: "accounts", From: "users"}},
+	})
+
+	if len(changes) != 1 {
+		for _, c := range changes {
+			t.Log(c.SQL())
+		}
+		t.Fatalf("expected 1 change, got %d", len(changes))
+	}
+	if got := changes[0].SQL(); got != "ALTER TABLE users RENAME TO accounts;" {
+		t.Errorf("SQL = %q", got)
+	}
+	if got := changes[0].DownSQL(); got != "ALTER TABLE accounts RENAME TO users;" {
+		t.Errorf("DownSQL = %q", got)
+	}
+}
+
+func TestCompare_RenameIgnoredWhenNotApplicable(t *testing.T) {
+	current := &parser.Schema{
+		Tables: []parser.Table{
+			{Name: "users", Columns: []parser.Column{{Name: "email", Type: "text"}}},
+		},
+	}
+	desired := &parser.Schema{
+		Tables: []parser.Table{
+			{Name: "users", Columns: []parser.Column{{Name: "email", Type: "text"}}},
+		},
+	}
+
+	changes := CompareWithOptions(current, desired, Options{
+		Renames: []parser.Rename{{Table: "users", Column: "email", From: "mail"}},
+	})
+
+	if len(changes) != 0 {
+		t.Errorf("expected no changes for an already-applied rename, got %d", len(changes))
+	}
+}
+
+func TestCompareRenames_Dependencies(t *testing.T) {
+	current := &parser.Schema{
+		Tables: []parser.Table{
+			{Name: "users", Columns: []parser.Column{{Name: "id", Type: "integer"}, {Name: "mail", Type: "text"}}},
+		},
+		Dependencies: []parser.Dependency{
+			{Kind: "view", Name: "user_emails", RefKind: "table", RefName: "users", RefColumn: "mail"},
+			{Kind: "table", Name: "users", RefKind: "type", RefName: "status"},
+		},
+	}
+	desired := &parser.Schema{
+		Tables: []parser.Table{
+			{Name: "accounts", Columns: []parser.Column{{Name: "id", Type: "integer"}, {Name: "email", Type: "text"}}},
+		},
+	}
+
+	_, renamed := compareRenames(current, desired, []parser.Rename{
+		{Table: "accounts", From: "users"},
+		{Table: "accounts", Column: "email", From: "mail"},
+	})
+
+	want := []parser.Dependency{
+		{Kind: "view", Name: "user_emails", RefKind: "table", RefName: "accounts", RefColumn: "email"},
+		{Kind: "table", Name: "accounts", RefKind: "type", RefName: "status"},
+	}
+	if !reflect.DeepEqual(renamed.Dependencies, want) {
+		t.Errorf("Dependencies = %+v, want %+v", renamed.Dependencies, want)
+	}
+	if current.Dependencies[0].RefName != "users" {
+		t.Error("compareRenames should not modify the current schema")
+	}
+}
+
+func TestDetectRenames(t *testing.T) {
+	current := &parser.Schema{
+		Tables: []parser.Table{
+			{Name: "users", Columns: []parser.Column{{Name: "id", Type: "integer"}, {Name: "email", Type: "text"}, {Name: "age", Type: "integer"}}},
+			{Name: "old_logs", Columns: []parser.Column{{Name: "id", Type: "bigint"}, {Name: "message", Type: "text"}}},
+		},
+	}
+	desired := &parser.Schema{
+		Tables: []parser.Table{
+			{Name: "users", Columns: []parser.Column{{Name: "id", Type: "integer"}, {Name: "email_address", Type: "text"}, {Name: "nickname", Type: "varchar(20)"}}},
+			{Name: "logs", Columns: []parser.Column{{Name: "id", Type: "int8"}, {Name: "message", Type: "text"}}},
+		},
+	}
+
+	got := DetectRenames(current, desired, nil)
+
+	if len(got) != 2 {
+		t.Fatalf("expected 2 candidates, got %+v", got)
+	}
+	if got[0] != (parser.Rename{Table: "logs", From: "old_logs"}) {
+		t.Errorf("candidate[0] = %+v, want logs renamed from old_logs", got[0])
+	}
+	if got[1] != (parser.Rename{Table: "users", Column: "email_address", From: "email"}) {
+		t.Errorf("candidate[1] = %+v, want users.email renamed to email_address", got[1])
+	}
+
+	known := DetectRenames(current, desired, got)
+	if len(known) != 0 {
+		t.Errorf("expected confirmed renames not to be suggested again, got %+v", known)
+	}
+}
+
+func TestDetectRenames_EnumValues(t *testing.T) {
+	current := &parser.Schema{
+		Enums: []parser.Enum{{Name: "status", Values: []string{"pending", "complete"}}},
+	}
+	desired := &parser.Schema{
+		Enums: []parser.Enum{{Name: "status", Values: []string{"pending", "done"}}},
+	}
+
+	got := DetectRenames(current, desired, nil)
+	want := []parser.Rename{{Enum: "status", Value: "done", From: "complete"}}
+	if !reflect.DeepEqual(got, want) {
+		t.Fatalf("DetectRenames() = %+v, want %+v", got, want)
+	}
+
+	if known := DetectRenames(current, desired, got); len(known) != 0 {
+		t.Errorf("expected confirmed renames not to be suggested again, got %+v", known)
+	}
+}
+
+func TestReplaceIdent(t *testing.T) {
+	got := replaceIdent("CREATE INDEX users_id_idx ON public.users USING btree (id, user_id)", "id", "uid")
+	if !strings.Contains(got, "(uid, user_id)") || !strings.Contains(got, "users_id_idx") {
+		t.Errorf("replaceIdent() = %q", got)
+	}
+}
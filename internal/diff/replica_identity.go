@@ -0,0 +1,47 @@
+package diff
+
+import (
+	"github.com/terminally-online/shrugged/internal/parser"
+)
+
+// ReplicaIdentityChange is kept apart from TableChange because an identity
+// using an index can only be set once that index exists, and indexes are
+// created after their tables.
+type ReplicaIdentityChange struct {
+	Table    parser.Table
+	OldTable parser.Table
+}
+
+func (c *ReplicaIdentityChange) SQL() string {
+	return parser.ReplicaIdentitySQL(c.Table, quoteIdent)
+}
+
+func (c *ReplicaIdentityChange) DownSQL() string {
+	return parser.ReplicaIdentitySQL(c.OldTable, quoteIdent)
+}
+
+func (c *ReplicaIdentityChange) Type() ChangeType   { return AlterTable }
+func (c *ReplicaIdentityChange) ObjectName() string { return c.Table.Name }
+func (c *ReplicaIdentityChange) IsReversible() bool { return true }
+
+func compareReplicaIdentities(current, desired []parser.Table) []Change {
+	var changes []Change
+
+	currentMap := make(map[string]parser.Table)
+	for _, t := range current {
+		currentMap[objectKey(t.Schema, t.Name)] = t
+	}
+
+	for _, d := range desired {
+		c, exists := currentMap[objectKey(d.Schema, d.Name)]
+		if !exists {
+			c = parser.Table{Schema: d.Schema, Name: d.Name}
+		}
+		if c.ReplicaIdentity == d.ReplicaIdentity && c.ReplicaIdentityIndex == d.ReplicaIdentityIndex {
+			continue
+		}
+		changes = append(changes, &ReplicaIdentityChange{Table: d, OldTable: c})
+	}
+
+	return changes
+}
@@ -49,22 +49,22 @@ func compareRules(current, desired []parser.Rule) []Change {
 
 	currentMap := make(map[string]parser.Rule)
 	for _, r := range current {
-		currentMap[r.Name] = r
+		currentMap[tableObjectKey(r.Schema, r.Table, r.Name)] = r
 	}
 
 	desiredMap := make(map[string]parser.Rule)
 	for _, r := range desired {
-		desiredMap[r.Name] = r
+		desiredMap[tableObjectKey(r.Schema, r.Table, r.Name)] = r
 	}
 
 	for _, r := range desired {
-		if _, exists := currentMap[r.Name]; !exists {
+		if _, exists := currentMap[tableObjectKey(r.Schema, r.Table, r.Name)]; !exists {
 			changes = append(changes, &RuleChange{ChangeType: CreateRule, Rule: r})
 		}
 	}
 
 	for _, r := range current {
-		if _, exists := desiredMap[r.Name]; !exists {
+		if _, exists := desiredMap[tableObjectKey(r.Schema, r.Table, r.Name)]; !exists {
 			oldRule := r
 			changes = append(changes, &RuleChange{ChangeType: DropRule, Rule: r, OldRule: &oldRule})
 		}
@@ -20,7 +20,10 @@ func (c *SequenceChange) SQL() string {
 	case DropSequence:
 		return fmt.Sprintf("DROP SEQUENCE %s;", qualifiedName(c.Sequence.Schema, c.Sequence.Name))
 	case AlterSequence:
-		return fmt.Sprintf("ALTER SEQUENCE %s;", qualifiedName(c.Sequence.Schema, c.Sequence.Name))
+		if c.OldSequence != nil {
+			return generateAlterSequence(*c.OldSequence, c.Sequence)
+		}
+		return generateAlterSequence(parser.Sequence{}, c.Sequence)
 	}
 	return ""
 }
@@ -36,7 +39,7 @@ func (c *SequenceChange) DownSQL() string {
 		return fmt.Sprintf("-- IRREVERSIBLE: Cannot restore dropped sequence %s", c.Sequence.Name)
 	case AlterSequence:
 		if c.OldSequence != nil {
-			return generateCreateSequence(*c.OldSequence)
+			return generateAlterSequence(c.Sequence, *c.OldSequence)
 		}
 		return fmt.Sprintf("-- IRREVERSIBLE: Cannot restore previous sequence %s", c.Sequence.Name)
 	}
@@ -66,22 +69,25 @@ func compareSequences(current, desired []parser.Sequence) []Change {
 
 	currentMap := make(map[string]parser.Sequence)
 	for _, s := range current {
-		currentMap[s.Name] = s
+		currentMap[objectKey(s.Schema, s.Name)] = s
 	}
 
 	desiredMap := make(map[string]parser.Sequence)
 	for _, s := range desired {
-		desiredMap[s.Name] = s
+		desiredMap[objectKey(s.Schema, s.Name)] = s
 	}
 
 	for _, s := range desired {
-		if _, exists := currentMap[s.Name]; !exists {
+		if existing, exists := currentMap[objectKey(s.Schema, s.Name)]; !exists {
 			changes = append(changes, &SequenceChange{ChangeType: CreateSequence, Sequence: s})
+		} else if !sequencesEqual(existing, s) {
+			oldSeq := existing
+			changes = append(changes, &SequenceChange{ChangeType: AlterSequence, Sequence: s, OldSequence: &oldSeq})
 		}
 	}
 
 	for _, s := range current {
-		if _, exists := desiredMap[s.Name]; !exists {
+		if _, exists := desiredMap[objectKey(s.Schema, s.Name)]; !exists {
 			oldSeq := s
 			changes = append(changes, &SequenceChange{ChangeType: DropSequence, Sequence: s, OldSequence: &oldSeq})
 		}
@@ -117,3 +123,44 @@ func generateCreateSequence(s parser.Sequence) string {
 	sb.WriteString(";")
 	return sb.String()
 }
+
+func sequencesEqual(a, b parser.Sequence) bool {
+	return a.Start == b.Start &&
+		a.Increment == b.Increment &&
+		a.MinValue == b.MinValue &&
+		a.MaxValue == b.MaxValue &&
+		a.Cache == b.Cache &&
+		a.Cycle == b.Cycle
+}
+
+func generateAlterSequence(from, to parser.Sequence) string {
+	var sb strings.Builder
+
+	sb.WriteString(fmt.Sprintf("ALTER SEQUENCE %s", qualifiedName(to.Schema, to.Name)))
+
+	if to.Increment != from.Increment {
+		sb.WriteString(fmt.Sprintf(" INCREMENT BY %d", to.Increment))
+	}
+	if to.MinValue != from.MinValue {
+		sb.WriteString(fmt.Sprintf(" MINVALUE %d", to.MinValue))
+	}
+	if to.MaxValue != from.MaxValue {
+		sb.WriteString(fmt.Sprintf(" MAXVALUE %d", to.MaxValue))
+	}
+	if to.Start != from.Start {
+		sb.WriteString(fmt.Sprintf(" START WITH %d", to.Start))
+	}
+	if to.Cache != from.Cache {
+		sb.WriteString(fmt.Sprintf(" CACHE %d", to.Cache))
+	}
+	if to.Cycle != from.Cycle {
+		if to.Cycle {
+			sb.WriteString(" CYCLE")
+		} else {
+			sb.WriteString(" NO CYCLE")
+		}
+	}
+
+	sb.WriteString(";")
+	return sb.String()
+}
@@ -170,3 +170,33 @@ func TestSequenceChange_IsReversible(t *testing.T) {
 		t.Error("DropSequence without OldSequence should not be reversible")
 	}
 }
+
+func TestCompare_AlterSequence(t *testing.T) {
+	current := &parser.Schema{
+		Sequences: []parser.Sequence{{Name: "order_seq", Start: 1, Increment: 1, MinValue: 1, MaxValue: 1000, Cache: 1}},
+	}
+	desired := &parser.Schema{
+		Sequences: []parser.Sequence{{Name: "order_seq", Start: 1, Increment: 5, MinValue: 1, MaxValue: 5000, Cache: 20, Cycle: true}},
+	}
+
+	changes := Compare(current, desired)
+	if len(changes) != 1 || changes[0].Type() != AlterSequence {
+		t.Fatalf("expected a single AlterSequence change, got %d", len(changes))
+	}
+
+	if got := changes[0].SQL(); got != "ALTER SEQUENCE order_seq INCREMENT BY 5 MAXVALUE 5000 CACHE 20 CYCLE;" {
+		t.Errorf("SQL = %q", got)
+	}
+	if got := changes[0].DownSQL(); got != "ALTER SEQUENCE order_seq INCREMENT BY 1 MAXVALUE 1000 CACHE 1 NO CYCLE;" {
+		t.Errorf("DownSQL = %q", got)
+	}
+}
+
+func TestCompare_SequenceUnchanged(t *testing.T) {
+	schema := &parser.Schema{
+		Sequences: []parser.Sequence{{Name: "order_seq", Start: 1, Increment: 1}},
+	}
+	if changes := Compare(schema, schema); len(changes) != 0 {
+		t.Errorf("expected no changes, got %d", len(changes))
+	}
+}
@@ -2,6 +2,7 @@ package diff
 
 import (
 	"fmt"
+	"slices"
 	"strings"
 
 	"github.com/terminally-online/shrugged/internal/parser"
@@ -16,8 +17,23 @@ type TableChange struct {
 	AlterColumns    []ColumnAlteration
 	AddConstraints  []parser.Constraint
 	DropConstraints []string
+	// UnnamedDrops are constraints to drop that have no name to drop them by,
+	// which only happens when the current schema was not introspected.
+	UnnamedDrops []parser.Constraint
+	AlterOptions []TableOption
+	Online       bool
 }
 
+type TableOption int
+
+const (
+	OptionRowSecurity TableOption = iota
+	OptionForceRowSecurity
+	OptionPersistence
+	OptionStorageParams
+	OptionInherits
+)
+
 type ColumnAlteration struct {
 	Column    parser.Column
 	OldColumn parser.Column
@@ -67,10 +83,15 @@ func (c *TableChange) IsReversible() bool {
 		_ = col
 		return false
 	}
+	for _, name := range c.DropConstraints {
+		if findConstraint(c.OldTable, name) == nil {
+			return false
+		}
+	}
 	return true
 }
 
-func compareTables(current, desired []parser.Table) []Change {
+func compareTables(current, desired []parser.Table, opts Options) []Change {
 	var changes []Change
 
 	currentMap := make(map[string]parser.Table)
@@ -95,20 +116,21 @@ func compareTables(current, desired []parser.Table) []Change {
 		changes = append(changes, &TableChange{ChangeType: CreateTable, Table: t})
 	}
 
-	for _, t := range current {
-		if _, exists := desiredMap[objectKey(t.Schema, t.Name)]; !exists {
-			changes = append(changes, &TableChange{ChangeType: DropTable, Table: t})
-		}
-	}
-
-	for key, desiredTable := range desiredMap {
-		if currentTable, exists := currentMap[key]; exists {
+	for _, desiredTable := range desired {
+		if currentTable, exists := currentMap[objectKey(desiredTable.Schema, desiredTable.Name)]; exists {
 			if tableChanges := compareTableColumns(currentTable, desiredTable); tableChanges != nil {
+				tableChanges.Online = opts.Online
 				changes = append(changes, tableChanges)
 			}
 		}
 	}
 
+	for _, t := range current {
+		if _, exists := desiredMap[objectKey(t.Schema, t.Name)]; !exists {
+			changes = append(changes, &TableChange{ChangeType: DropTable, Table: t})
+		}
+	}
+
 	return changes
 }
 
@@ -136,6 +158,13 @@ func sortTablesByDependency(tables []parser.Table) []parser.Table {
 				}
 			}
 		}
+		for _, parent := range t.Inherits {
+			if refKey := refTableKey(t.Schema, parent); refKey != key {
+				if _, inSet := tableMap[refKey]; inSet {
+					deps[key] = append(deps[key], refKey)
+				}
+			}
+		}
 	}
 
 	var sorted []parser.Table
@@ -210,13 +239,85 @@ func compareTableColumns(current, desired parser.Table) *TableChange {
 		}
 	}
 
-	if len(change.AddColumns) == 0 && len(change.DropColumns) == 0 && len(change.AlterColumns) == 0 {
+	compareTableConstraints(current, desired, change)
+	change.AlterOptions = compareTableOptions(current, desired)
+
+	if len(change.AddColumns) == 0 && len(change.DropColumns) == 0 && len(change.AlterColumns) == 0 &&
+		len(change.AddConstraints) == 0 && len(change.DropConstraints) == 0 && len(change.UnnamedDrops) == 0 &&
+		len(change.AlterOptions) == 0 {
 		return nil
 	}
 
 	return change
 }
 
+func compareTableConstraints(current, desired parser.Table, change *TableChange) {
+	currentCons := make(map[string]parser.Constraint)
+	for _, c := range current.Constraints {
+		currentCons[constraintKey(c)] = c
+	}
+
+	desiredCons := make(map[string]parser.Constraint)
+	for _, c := range desired.Constraints {
+		desiredCons[constraintKey(c)] = c
+	}
+
+	for _, c := range current.Constraints {
+		desiredCon, exists := desiredCons[constraintKey(c)]
+		if exists && constraintsEqual(c, desiredCon) {
+			continue
+		}
+		if c.Name == "" {
+			change.UnnamedDrops = append(change.UnnamedDrops, c)
+		} else {
+			change.DropConstraints = append(change.DropConstraints, c.Name)
+		}
+	}
+
+	for _, c := range desired.Constraints {
+		currentCon, exists := currentCons[constraintKey(c)]
+		if !exists || !constraintsEqual(currentCon, c) {
+			change.AddConstraints = append(change.AddConstraints, c)
+		}
+	}
+}
+
+func constraintKey(c parser.Constraint) string {
+	if c.Name != "" {
+		return c.Name
+	}
+	return c.Type + ":" + normalizeSQL(generateConstraintDef(c))
+}
+
+func constraintsEqual(a, b parser.Constraint) bool {
+	if a.Type != b.Type {
+		return false
+	}
+	if a.Definition != "" && b.Definition != "" {
+		return normalizeConstraintDef(a.Definition) == normalizeConstraintDef(b.Definition)
+	}
+	return normalizeConstraintDef(generateConstraintDef(a)) == normalizeConstraintDef(generateConstraintDef(b))
+}
+
+func normalizeConstraintDef(def string) string {
+	def = normalizeSQL(def)
+	def = strings.TrimSuffix(def, " not valid")
+	def = strings.ReplaceAll(def, `"`, "")
+	return def
+}
+
+func findConstraint(t *parser.Table, name string) *parser.Constraint {
+	if t == nil {
+		return nil
+	}
+	for i := range t.Constraints {
+		if t.Constraints[i].Name == name {
+			return &t.Constraints[i]
+		}
+	}
+	return nil
+}
+
 func compareColumn(current, desired parser.Column) *ColumnAlteration {
 	var changes []string
 
@@ -249,6 +350,19 @@ func compareColumn(current, desired parser.Column) *ColumnAlteration {
 		changes = append(changes, "generated_type")
 	}
 
+	if current.Collation != desired.Collation {
+		changes = append(changes, "collation")
+	}
+	if current.Storage != desired.Storage {
+		changes = append(changes, "storage")
+	}
+	if current.Compression != desired.Compression {
+		changes = append(changes, "compression")
+	}
+	if statisticsTarget(current) != statisticsTarget(desired) {
+		changes = append(changes, "statistics")
+	}
+
 	if len(changes) == 0 {
 		return nil
 	}
@@ -271,10 +385,14 @@ func generateCreateTable(t parser.Table) string {
 		return sb.String()
 	}
 
-	sb.WriteString(fmt.Sprintf("CREATE TABLE %s (\n", qualifiedName(t.Schema, t.Name)))
+	if t.Unlogged {
+		sb.WriteString(fmt.Sprintf("CREATE UNLOGGED TABLE %s (\n", qualifiedName(t.Schema, t.Name)))
+	} else {
+		sb.WriteString(fmt.Sprintf("CREATE TABLE %s (\n", qualifiedName(t.Schema, t.Name)))
+	}
 
 	for i, col := range t.Columns {
-		sb.WriteString(fmt.Sprintf("    %s %s", quoteIdent(col.Name), col.Type))
+		sb.WriteString(fmt.Sprintf("    %s %s", quoteIdent(col.Name), parser.ColumnType(col)))
 
 		if col.Identity != "" {
 			sb.WriteString(fmt.Sprintf(" GENERATED %s AS IDENTITY", col.Identity))
@@ -330,80 +448,10 @@ func generateCreateTable(t parser.Table) string {
 	}
 
 	for i, constraint := range t.Constraints {
-		switch constraint.Type {
-		case "PRIMARY KEY":
-			cols := strings.Join(quoteIdents(constraint.Columns), ", ")
-			if constraint.WithoutOverlaps && constraint.PeriodColumn != "" {
-				cols = fmt.Sprintf("%s, %s WITHOUT OVERLAPS", cols, quoteIdent(constraint.PeriodColumn))
-			}
-			sb.WriteString(fmt.Sprintf("    CONSTRAINT %s PRIMARY KEY (%s)",
-				quoteIdent(constraint.Name), cols))
-
-		case "FOREIGN KEY":
-			refTable := constraint.RefTable
-			if !strings.Contains(refTable, ".") {
-				refTable = quoteIdent(refTable)
-			}
-			sb.WriteString(fmt.Sprintf("    CONSTRAINT %s FOREIGN KEY (%s) REFERENCES %s (%s)",
-				quoteIdent(constraint.Name),
-				strings.Join(quoteIdents(constraint.Columns), ", "),
-				refTable,
-				strings.Join(quoteIdents(constraint.RefColumns), ", ")))
-			if constraint.OnDelete != "" && constraint.OnDelete != "NO ACTION" {
-				sb.WriteString(fmt.Sprintf(" ON DELETE %s", constraint.OnDelete))
-			}
-			if constraint.OnUpdate != "" && constraint.OnUpdate != "NO ACTION" {
-				sb.WriteString(fmt.Sprintf(" ON UPDATE %s", constraint.OnUpdate))
-			}
-			if constraint.NotValid {
-				sb.WriteString(" NOT VALID")
-			}
-			if constraint.NotEnforced {
-				sb.WriteString(" NOT ENFORCED")
-			}
-
-		case "UNIQUE":
-			cols := strings.Join(quoteIdents(constraint.Columns), ", ")
-			if constraint.WithoutOverlaps && constraint.PeriodColumn != "" {
-				cols = fmt.Sprintf("%s, %s WITHOUT OVERLAPS", cols, quoteIdent(constraint.PeriodColumn))
-			}
-			sb.WriteString(fmt.Sprintf("    CONSTRAINT %s UNIQUE (%s)",
-				quoteIdent(constraint.Name), cols))
-
-		case "CHECK":
-			if constraint.Name != "" {
-				sb.WriteString(fmt.Sprintf("    CONSTRAINT %s CHECK (%s)",
-					quoteIdent(constraint.Name), constraint.Check))
-			} else {
-				sb.WriteString(fmt.Sprintf("    CHECK (%s)", constraint.Check))
-			}
-			if constraint.NotValid {
-				sb.WriteString(" NOT VALID")
-			}
-			if constraint.NotEnforced {
-				sb.WriteString(" NOT ENFORCED")
-			}
-
-		case "EXCLUSION":
-			using := constraint.ExclusionUsing
-			if using == "" {
-				using = "gist"
-			}
-			var elemParts []string
-			for _, col := range constraint.Columns {
-				op := "="
-				if constraint.ExclusionOperators != nil {
-					if mappedOp, ok := constraint.ExclusionOperators[col]; ok {
-						op = mappedOp
-					}
-				}
-				elemParts = append(elemParts, fmt.Sprintf("%s WITH %s", quoteIdent(col), op))
-			}
-			sb.WriteString(fmt.Sprintf("    CONSTRAINT %s EXCLUDE USING %s (%s)",
-				quoteIdent(constraint.Name), using, strings.Join(elemParts, ", ")))
-			if constraint.ExclusionWhere != "" {
-				sb.WriteString(fmt.Sprintf(" WHERE (%s)", constraint.ExclusionWhere))
-			}
+		if constraint.Name != "" {
+			sb.WriteString(fmt.Sprintf("    CONSTRAINT %s %s", quoteIdent(constraint.Name), generateConstraintDef(constraint)))
+		} else {
+			sb.WriteString(fmt.Sprintf("    %s", generateConstraintDef(constraint)))
 		}
 		if i < len(t.Constraints)-1 {
 			sb.WriteString(",")
@@ -413,20 +461,126 @@ func generateCreateTable(t parser.Table) string {
 
 	sb.WriteString(")")
 
+	if len(t.Inherits) > 0 {
+		sb.WriteString(fmt.Sprintf(" INHERITS (%s)", strings.Join(parser.QuoteTableRefs(t.Inherits, quoteIdent), ", ")))
+	}
 	if t.PartitionBy != "" {
 		sb.WriteString(fmt.Sprintf(" PARTITION BY %s (%s)", t.PartitionBy, t.PartitionKey))
 	}
+	if len(t.StorageParams) > 0 {
+		sb.WriteString(fmt.Sprintf(" WITH (%s)", strings.Join(t.StorageParams, ", ")))
+	}
 
 	sb.WriteString(";")
+	sb.WriteString(parser.RowSecuritySQL(t, quoteIdent))
+	for _, col := range t.Columns {
+		for _, stmt := range parser.ColumnSettingsSQL(qualifiedName(t.Schema, t.Name), col, quoteIdent) {
+			sb.WriteString("\n" + stmt)
+		}
+	}
+	return sb.String()
+}
+
+func generateConstraintDef(c parser.Constraint) string {
+	var sb strings.Builder
+
+	switch c.Type {
+	case "PRIMARY KEY":
+		cols := strings.Join(quoteIdents(c.Columns), ", ")
+		if c.WithoutOverlaps && c.PeriodColumn != "" {
+			cols = fmt.Sprintf("%s, %s WITHOUT OVERLAPS", cols, quoteIdent(c.PeriodColumn))
+		}
+		sb.WriteString(fmt.Sprintf("PRIMARY KEY (%s)", cols))
+
+	case "FOREIGN KEY":
+		refTable := c.RefTable
+		if !strings.Contains(refTable, ".") {
+			refTable = quoteIdent(refTable)
+		}
+		sb.WriteString(fmt.Sprintf("FOREIGN KEY (%s) REFERENCES %s (%s)",
+			strings.Join(quoteIdents(c.Columns), ", "),
+			refTable,
+			strings.Join(quoteIdents(c.RefColumns), ", ")))
+		if c.OnDelete != "" && c.OnDelete != "NO ACTION" {
+			sb.WriteString(fmt.Sprintf(" ON DELETE %s", c.OnDelete))
+		}
+		if c.OnUpdate != "" && c.OnUpdate != "NO ACTION" {
+			sb.WriteString(fmt.Sprintf(" ON UPDATE %s", c.OnUpdate))
+		}
+		if c.NotValid {
+			sb.WriteString(" NOT VALID")
+		}
+		if c.NotEnforced {
+			sb.WriteString(" NOT ENFORCED")
+		}
+
+	case "UNIQUE":
+		cols := strings.Join(quoteIdents(c.Columns), ", ")
+		if c.WithoutOverlaps && c.PeriodColumn != "" {
+			cols = fmt.Sprintf("%s, %s WITHOUT OVERLAPS", cols, quoteIdent(c.PeriodColumn))
+		}
+		sb.WriteString(fmt.Sprintf("UNIQUE (%s)", cols))
+
+	case "CHECK":
+		sb.WriteString(fmt.Sprintf("CHECK (%s)", c.Check))
+		if c.NotValid {
+			sb.WriteString(" NOT VALID")
+		}
+		if c.NotEnforced {
+			sb.WriteString(" NOT ENFORCED")
+		}
+
+	case "EXCLUSION":
+		if c.Definition != "" {
+			return c.Definition
+		}
+		using := c.ExclusionUsing
+		if using == "" {
+			using = "gist"
+		}
+		var elemParts []string
+		for _, col := range c.Columns {
+			op := "="
+			if c.ExclusionOperators != nil {
+				if mappedOp, ok := c.ExclusionOperators[col]; ok {
+					op = mappedOp
+				}
+			}
+			elemParts = append(elemParts, fmt.Sprintf("%s WITH %s", quoteIdent(col), op))
+		}
+		sb.WriteString(fmt.Sprintf("EXCLUDE USING %s (%s)", using, strings.Join(elemParts, ", ")))
+		if c.ExclusionWhere != "" {
+			sb.WriteString(fmt.Sprintf(" WHERE (%s)", c.ExclusionWhere))
+		}
+	}
+
 	return sb.String()
 }
 
+func generateAddConstraint(tableName string, c parser.Constraint) string {
+	if c.Name == "" {
+		return fmt.Sprintf("ALTER TABLE %s ADD %s;", tableName, generateConstraintDef(c))
+	}
+	return fmt.Sprintf("ALTER TABLE %s ADD CONSTRAINT %s %s;", tableName, quoteIdent(c.Name), generateConstraintDef(c))
+}
+
+func generateDropConstraint(tableName, name string) string {
+	return fmt.Sprintf("ALTER TABLE %s DROP CONSTRAINT %s;", tableName, quoteIdent(name))
+}
+
 func generateAlterTable(c *TableChange) string {
 	var stmts []string
 	tableName := qualifiedName(c.Table.Schema, c.Table.Name)
 
+	for _, con := range c.UnnamedDrops {
+		stmts = append(stmts, fmt.Sprintf("-- UNSUPPORTED: Cannot drop unnamed constraint %s; drop it by hand using the name Postgres gave it", generateConstraintDef(con)))
+	}
+	for _, name := range c.DropConstraints {
+		stmts = append(stmts, generateDropConstraint(tableName, name))
+	}
+
 	for _, col := range c.AddColumns {
-		stmt := fmt.Sprintf("ALTER TABLE %s ADD COLUMN %s %s", tableName, quoteIdent(col.Name), col.Type)
+		stmt := fmt.Sprintf("ALTER TABLE %s ADD COLUMN %s %s", tableName, quoteIdent(col.Name), parser.ColumnType(col))
 
 		if col.Identity != "" {
 			stmt += fmt.Sprintf(" GENERATED %s AS IDENTITY", col.Identity)
@@ -446,6 +600,7 @@ func generateAlterTable(c *TableChange) string {
 			stmt += fmt.Sprintf(" DEFAULT %s", col.Default)
 		}
 		stmts = append(stmts, stmt+";")
+		stmts = append(stmts, parser.ColumnSettingsSQL(tableName, col, quoteIdent)...)
 	}
 
 	for _, colName := range c.DropColumns {
@@ -458,11 +613,27 @@ func generateAlterTable(c *TableChange) string {
 			switch change {
 			case "type":
 				stmts = append(stmts, fmt.Sprintf("ALTER TABLE %s ALTER COLUMN %s TYPE %s;",
-					tableName, colName, alt.Column.Type))
+					tableName, colName, parser.CollatedColumnType(alt.Column)))
+			case "collation":
+				if !slices.Contains(alt.Changes, "type") {
+					stmts = append(stmts, fmt.Sprintf("ALTER TABLE %s ALTER COLUMN %s TYPE %s;",
+						tableName, colName, parser.CollatedColumnType(alt.Column)))
+				}
+			case "storage":
+				stmts = append(stmts, fmt.Sprintf("ALTER TABLE %s ALTER COLUMN %s SET STORAGE %s;",
+					tableName, colName, columnStorage(alt.Column)))
+			case "compression":
+				stmts = append(stmts, fmt.Sprintf("ALTER TABLE %s ALTER COLUMN %s SET COMPRESSION %s;",
+					tableName, colName, columnCompression(alt.Column)))
+			case "statistics":
+				stmts = append(stmts, fmt.Sprintf("ALTER TABLE %s ALTER COLUMN %s SET STATISTICS %d;",
+					tableName, colName, statisticsTarget(alt.Column)))
 			case "nullable":
 				if alt.Column.Nullable {
 					stmts = append(stmts, fmt.Sprintf("ALTER TABLE %s ALTER COLUMN %s DROP NOT NULL;",
 						tableName, colName))
+				} else if c.Online {
+					stmts = append(stmts, generateOnlineSetNotNull(c.Table, alt.Column.Name)...)
 				} else {
 					stmts = append(stmts, fmt.Sprintf("ALTER TABLE %s ALTER COLUMN %s SET NOT NULL;",
 						tableName, colName))
@@ -521,13 +692,110 @@ func generateAlterTable(c *TableChange) string {
 		}
 	}
 
+	for _, con := range c.AddConstraints {
+		if c.Online && isOnlineConstraint(con) {
+			stmts = append(stmts, generateOnlineAddConstraint(c.Table, con)...)
+		} else {
+			stmts = append(stmts, generateAddConstraint(tableName, con))
+		}
+	}
+
+	if c.OldTable != nil {
+		stmts = append(stmts, generateAlterTableOptions(tableName, c.AlterOptions, *c.OldTable, c.Table)...)
+	}
+
 	return strings.Join(stmts, "\n")
 }
 
+// Online steps run outside a transaction so each one commits on its own.
+func (c *TableChange) usesOnlineSteps() bool {
+	if !c.Online || c.ChangeType != AlterTable {
+		return false
+	}
+	for _, alt := range c.AlterColumns {
+		if !alt.Column.Nullable && slices.Contains(alt.Changes, "nullable") {
+			return true
+		}
+	}
+	for _, con := range c.AddConstraints {
+		if isOnlineConstraint(con) {
+			return true
+		}
+	}
+	return false
+}
+
+// Unnamed constraints can't be validated or attached to an index by name, and
+// WITHOUT OVERLAPS needs a GiST index.
+func isOnlineConstraint(con parser.Constraint) bool {
+	if con.Name == "" {
+		return false
+	}
+	switch con.Type {
+	case "FOREIGN KEY":
+		return !con.NotValid && !con.NotEnforced
+	case "UNIQUE":
+		return len(con.Columns) > 0 && !con.WithoutOverlaps
+	}
+	return false
+}
+
+// SET NOT NULL skips its scan when a validated check already proves the column
+// has no nulls, and VALIDATE CONSTRAINT doesn't block reads or writes.
+func generateOnlineSetNotNull(t parser.Table, column string) []string {
+	tableName := qualifiedName(t.Schema, t.Name)
+	check := quoteIdent(notNullCheckName(t.Name, column))
+	return []string{
+		fmt.Sprintf("ALTER TABLE %s ADD CONSTRAINT %s CHECK (%s IS NOT NULL) NOT VALID;", tableName, check, quoteIdent(column)),
+		fmt.Sprintf("ALTER TABLE %s VALIDATE CONSTRAINT %s;", tableName, check),
+		fmt.Sprintf("ALTER TABLE %s ALTER COLUMN %s SET NOT NULL;", tableName, quoteIdent(column)),
+		fmt.Sprintf("ALTER TABLE %s DROP CONSTRAINT %s;", tableName, check),
+	}
+}
+
+func generateOnlineAddConstraint(t parser.Table, con parser.Constraint) []string {
+	tableName := qualifiedName(t.Schema, t.Name)
+	name := quoteIdent(con.Name)
+
+	if con.Type == "UNIQUE" {
+		return []string{
+			fmt.Sprintf("CREATE UNIQUE INDEX CONCURRENTLY %s ON %s (%s);", name, tableName, strings.Join(quoteIdents(con.Columns), ", ")),
+			fmt.Sprintf("ALTER TABLE %s ADD CONSTRAINT %s UNIQUE USING INDEX %s;", tableName, name, name),
+		}
+	}
+
+	con.NotValid = true
+	return []string{
+		generateAddConstraint(tableName, con),
+		fmt.Sprintf("ALTER TABLE %s VALIDATE CONSTRAINT %s;", tableName, name),
+	}
+}
+
+func notNullCheckName(table, column string) string {
+	name := fmt.Sprintf("%s_%s_not_null_check", table, column)
+	if len(name) > 63 {
+		name = name[:63]
+	}
+	return name
+}
+
 func generateAlterTableDown(c *TableChange) string {
 	var stmts []string
 	tableName := qualifiedName(c.Table.Schema, c.Table.Name)
 
+	if c.OldTable != nil {
+		stmts = append(stmts, generateAlterTableOptions(tableName, c.AlterOptions, c.Table, *c.OldTable)...)
+	}
+
+	for i := len(c.AddConstraints) - 1; i >= 0; i-- {
+		con := c.AddConstraints[i]
+		if con.Name == "" {
+			stmts = append(stmts, fmt.Sprintf("-- IRREVERSIBLE: Cannot drop unnamed constraint %s", generateConstraintDef(con)))
+			continue
+		}
+		stmts = append(stmts, generateDropConstraint(tableName, con.Name))
+	}
+
 	for _, col := range c.AddColumns {
 		stmts = append(stmts, fmt.Sprintf("ALTER TABLE %s DROP COLUMN %s;", tableName, quoteIdent(col.Name)))
 	}
@@ -542,7 +810,21 @@ func generateAlterTableDown(c *TableChange) string {
 			switch change {
 			case "type":
 				stmts = append(stmts, fmt.Sprintf("ALTER TABLE %s ALTER COLUMN %s TYPE %s;",
-					tableName, colName, alt.OldColumn.Type))
+					tableName, colName, parser.CollatedColumnType(alt.OldColumn)))
+			case "collation":
+				if !slices.Contains(alt.Changes, "type") {
+					stmts = append(stmts, fmt.Sprintf("ALTER TABLE %s ALTER COLUMN %s TYPE %s;",
+						tableName, colName, parser.CollatedColumnType(alt.OldColumn)))
+				}
+			case "storage":
+				stmts = append(stmts, fmt.Sprintf("ALTER TABLE %s ALTER COLUMN %s SET STORAGE %s;",
+					tableName, colName, columnStorage(alt.OldColumn)))
+			case "compression":
+				stmts = append(stmts, fmt.Sprintf("ALTER TABLE %s ALTER COLUMN %s SET COMPRESSION %s;",
+					tableName, colName, columnCompression(alt.OldColumn)))
+			case "statistics":
+				stmts = append(stmts, fmt.Sprintf("ALTER TABLE %s ALTER COLUMN %s SET STATISTICS %d;",
+					tableName, colName, statisticsTarget(alt.OldColumn)))
 			case "nullable":
 				if alt.OldColumn.Nullable {
 					stmts = append(stmts, fmt.Sprintf("ALTER TABLE %s ALTER COLUMN %s DROP NOT NULL;",
@@ -605,5 +887,131 @@ func generateAlterTableDown(c *TableChange) string {
 		}
 	}
 
+	for _, name := range c.DropConstraints {
+		if old := findConstraint(c.OldTable, name); old != nil {
+			stmts = append(stmts, generateAddConstraint(tableName, *old))
+		} else {
+			stmts = append(stmts, fmt.Sprintf("-- IRREVERSIBLE: Cannot restore dropped constraint %s", name))
+		}
+	}
+
 	return strings.Join(stmts, "\n")
 }
+
+func compareTableOptions(current, desired parser.Table) []TableOption {
+	var changes []TableOption
+
+	if current.RowSecurity != desired.RowSecurity {
+		changes = append(changes, OptionRowSecurity)
+	}
+	if current.ForceRowSecurity != desired.ForceRowSecurity {
+		changes = append(changes, OptionForceRowSecurity)
+	}
+	if current.Unlogged != desired.Unlogged {
+		changes = append(changes, OptionPersistence)
+	}
+	set, reset := storageParamChanges(current.StorageParams, desired.StorageParams)
+	if len(set) > 0 || len(reset) > 0 {
+		changes = append(changes, OptionStorageParams)
+	}
+	if strings.Join(current.Inherits, ",") != strings.Join(desired.Inherits, ",") {
+		changes = append(changes, OptionInherits)
+	}
+
+	return changes
+}
+
+// Passing the tables in reverse order produces the down migration.
+func generateAlterTableOptions(tableName string, changes []TableOption, from, to parser.Table) []string {
+	var stmts []string
+
+	for _, change := range changes {
+		switch change {
+		case OptionRowSecurity:
+			if to.RowSecurity {
+				stmts = append(stmts, fmt.Sprintf("ALTER TABLE %s ENABLE ROW LEVEL SECURITY;", tableName))
+			} else {
+				stmts = append(stmts, fmt.Sprintf("ALTER TABLE %s DISABLE ROW LEVEL SECURITY;", tableName))
+			}
+		case OptionForceRowSecurity:
+			if to.ForceRowSecurity {
+				stmts = append(stmts, fmt.Sprintf("ALTER TABLE %s FORCE ROW LEVEL SECURITY;", tableName))
+			} else {
+				stmts = append(stmts, fmt.Sprintf("ALTER TABLE %s NO FORCE ROW LEVEL SECURITY;", tableName))
+			}
+		case OptionPersistence:
+			if to.Unlogged {
+				stmts = append(stmts, fmt.Sprintf("ALTER TABLE %s SET UNLOGGED;", tableName))
+			} else {
+				stmts = append(stmts, fmt.Sprintf("ALTER TABLE %s SET LOGGED;", tableName))
+			}
+		case OptionStorageParams:
+			set, reset := storageParamChanges(from.StorageParams, to.StorageParams)
+			if len(reset) > 0 {
+				stmts = append(stmts, fmt.Sprintf("ALTER TABLE %s RESET (%s);", tableName, strings.Join(reset, ", ")))
+			}
+			if len(set) > 0 {
+				stmts = append(stmts, fmt.Sprintf("ALTER TABLE %s SET (%s);", tableName, strings.Join(set, ", ")))
+			}
+		case OptionInherits:
+			for _, parent := range from.Inherits {
+				if !slices.Contains(to.Inherits, parent) {
+					stmts = append(stmts, fmt.Sprintf("ALTER TABLE %s NO INHERIT %s;", tableName, parser.QuoteTableRef(parent, quoteIdent)))
+				}
+			}
+			for _, parent := range to.Inherits {
+				if !slices.Contains(from.Inherits, parent) {
+					stmts = append(stmts, fmt.Sprintf("ALTER TABLE %s INHERIT %s;", tableName, parser.QuoteTableRef(parent, quoteIdent)))
+				}
+			}
+		}
+	}
+
+	return stmts
+}
+
+func storageParamChanges(from, to []string) ([]string, []string) {
+	fromValues := make(map[string]string)
+	for _, p := range from {
+		name, value, _ := strings.Cut(p, "=")
+		fromValues[name] = value
+	}
+	toNames := make(map[string]bool)
+
+	var set, reset []string
+	for _, p := range to {
+		name, value, _ := strings.Cut(p, "=")
+		toNames[name] = true
+		if old, ok := fromValues[name]; !ok || old != value {
+			set = append(set, p)
+		}
+	}
+	for _, p := range from {
+		name, _, _ := strings.Cut(p, "=")
+		if !toNames[name] {
+			reset = append(reset, name)
+		}
+	}
+	return set, reset
+}
+
+func columnStorage(col parser.Column) string {
+	if col.Storage == "" {
+		return "DEFAULT"
+	}
+	return col.Storage
+}
+
+func columnCompression(col parser.Column) string {
+	if col.Compression == "" {
+		return "DEFAULT"
+	}
+	return col.Compression
+}
+
+func statisticsTarget(col parser.Column) int {
+	if col.Statistics == nil {
+		return -1
+	}
+	return *col.Statistics
+}
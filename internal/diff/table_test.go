@@ -939,3 +939,477 @@ func TestCompareColumn_Generated(t *testing.T) {
 		t.Error("changing generated type should be detected")
 	}
 }
+
+func TestCompare_AlterTable_AddConstraint(t *testing.T) {
+	current := &parser.Schema{
+		Tables: []parser.Table{
+			{Name: "posts", Columns: []parser.Column{{Name: "id", Type: "integer"}, {Name: "user_id", Type: "integer"}}},
+		},
+	}
+	desired := &parser.Schema{
+		Tables: []parser.Table{
+			{
+				Name:    "posts",
+				Columns: []parser.Column{{Name: "id", Type: "integer"}, {Name: "user_id", Type: "integer"}},
+				Constraints: []parser.Constraint{
+					{Name: "posts_user_id_fkey", Type: "FOREIGN KEY", Columns: []string{"user_id"}, RefTable: "users", RefColumns: []string{"id"}},
+				},
+			},
+		},
+	}
+
+	changes := Compare(current, desired)
+
+	if len(changes) != 1 {
+		t.Fatalf("expected 1 change, got %d", len(changes))
+	}
+	tableChange, ok := changes[0].(*TableChange)
+	if !ok || tableChange.ChangeType != AlterTable {
+		t.Fatalf("expected AlterTable change, got %T", changes[0])
+	}
+	if len(tableChange.AddConstraints) != 1 || tableChange.AddConstraints[0].Name != "posts_user_id_fkey" {
+		t.Errorf("AddConstraints = %v, want posts_user_id_fkey", tableChange.AddConstraints)
+	}
+
+	sql := tableChange.SQL()
+	want := "ALTER TABLE posts ADD CONSTRAINT posts_user_id_fkey FOREIGN KEY (user_id) REFERENCES users (id);"
+	if sql != want {
+		t.Errorf("SQL = %q, want %q", sql, want)
+	}
+
+	downSQL := tableChange.DownSQL()
+	if downSQL != "ALTER TABLE posts DROP CONSTRAINT posts_user_id_fkey;" {
+		t.Errorf("DownSQL = %q", downSQL)
+	}
+}
+
+func TestCompare_AlterTable_DropConstraint(t *testing.T) {
+	current := &parser.Schema{
+		Tables: []parser.Table{
+			{
+				Name:    "products",
+				Columns: []parser.Column{{Name: "price", Type: "numeric"}},
+				Constraints: []parser.Constraint{
+					{Name: "price_positive", Type: "CHECK", Check: "price > 0"},
+				},
+			},
+		},
+	}
+	desired := &parser.Schema{
+		Tables: []parser.Table{
+			{Name: "products", Columns: []parser.Column{{Name: "price", Type: "numeric"}}},
+		},
+	}
+
+	changes := Compare(current, desired)
+
+	if len(changes) != 1 {
+		t.Fatalf("expected 1 change, got %d", len(changes))
+	}
+	tableChange := changes[0].(*TableChange)
+	if len(tableChange.DropConstraints) != 1 || tableChange.DropConstraints[0] != "price_positive" {
+		t.Errorf("DropConstraints = %v, want [price_positive]", tableChange.DropConstraints)
+	}
+	if !tableChange.IsReversible() {
+		t.Error("dropping a constraint with a known definition should be reversible")
+	}
+	if sql := tableChange.SQL(); sql != "ALTER TABLE products DROP CONSTRAINT price_positive;" {
+		t.Errorf("SQL = %q", sql)
+	}
+	if downSQL := tableChange.DownSQL(); downSQL != "ALTER TABLE products ADD CONSTRAINT price_positive CHECK (price > 0);" {
+		t.Errorf("DownSQL = %q", downSQL)
+	}
+}
+
+func TestCompare_AlterTable_DropUnnamedConstraint(t *testing.T) {
+	current := &parser.Schema{
+		Tables: []parser.Table{
+			{
+				Name:        "products",
+				Columns:     []parser.Column{{Name: "price", Type: "numeric"}},
+				Constraints: []parser.Constraint{{Type: "CHECK", Check: "price > 0"}},
+			},
+		},
+	}
+	desired := &parser.Schema{
+		Tables: []parser.Table{
+			{Name: "products", Columns: []parser.Column{{Name: "price", Type: "numeric"}}},
+		},
+	}
+
+	changes := Compare(current, desired)
+
+	if len(changes) != 1 {
+		t.Fatalf("expected 1 change, got %d", len(changes))
+	}
+	tableChange := changes[0].(*TableChange)
+	if len(tableChange.UnnamedDrops) != 1 {
+		t.Errorf("UnnamedDrops = %v, want the CHECK constraint", tableChange.UnnamedDrops)
+	}
+	if sql := tableChange.SQL(); !strings.HasPrefix(sql, "-- UNSUPPORTED: Cannot drop unnamed constraint CHECK (price > 0)") {
+		t.Errorf("SQL = %q", sql)
+	}
+}
+
+func TestCompare_AlterTable_ChangeConstraint(t *testing.T) {
+	current := &parser.Schema{
+		Tables: []parser.Table{
+			{
+				Name:    "products",
+				Columns: []parser.Column{{Name: "price", Type: "numeric"}},
+				Constraints: []parser.Constraint{
+					{Name: "price_check", Type: "CHECK", Check: "price > 0", Definition: "CHECK ((price > 0))"},
+				},
+			},
+		},
+	}
+	desired := &parser.Schema{
+		Tables: []parser.Table{
+			{
+				Name:    "products",
+				Columns: []parser.Column{{Name: "price", Type: "numeric"}},
+				Constraints: []parser.Constraint{
+					{Name: "price_check", Type: "CHECK", Check: "price >= 0", Definition: "CHECK ((price >= 0))"},
+				},
+			},
+		},
+	}
+
+	changes := Compare(current, desired)
+
+	if len(changes) != 1 {
+		t.Fatalf("expected 1 change, got %d", len(changes))
+	}
+	sql := changes[0].SQL()
+	dropIdx := strings.Index(sql, "DROP CONSTRAINT price_check")
+	addIdx := strings.Index(sql, "ADD CONSTRAINT price_check CHECK (price >= 0)")
+	if dropIdx == -1 || addIdx == -1 || dropIdx > addIdx {
+		t.Errorf("SQL = %q, want drop followed by add of price_check", sql)
+	}
+
+	downSQL := changes[0].DownSQL()
+	dropIdx = strings.Index(downSQL, "DROP CONSTRAINT price_check")
+	addIdx = strings.Index(downSQL, "ADD CONSTRAINT price_check CHECK (price > 0)")
+	if dropIdx == -1 || addIdx == -1 || dropIdx > addIdx {
+		t.Errorf("DownSQL = %q, want drop followed by re-add of original price_check", downSQL)
+	}
+}
+
+func TestCompare_AlterTable_UnchangedConstraint(t *testing.T) {
+	current := &parser.Schema{
+		Tables: []parser.Table{
+			{
+				Name:    "users",
+				Columns: []parser.Column{{Name: "email", Type: "text"}},
+				Constraints: []parser.Constraint{
+					{Name: "users_email_key", Type: "UNIQUE", Columns: []string{"email"}, Definition: "UNIQUE (email)"},
+					{Name: "users_org_fkey", Type: "FOREIGN KEY", Definition: "FOREIGN KEY (org_id) REFERENCES orgs(id) NOT VALID"},
+				},
+			},
+		},
+	}
+	desired := &parser.Schema{
+		Tables: []parser.Table{
+			{
+				Name:    "users",
+				Columns: []parser.Column{{Name: "email", Type: "text"}},
+				Constraints: []parser.Constraint{
+					{Name: "users_email_key", Type: "UNIQUE", Columns: []string{"email"}, Definition: "UNIQUE (email)"},
+					{Name: "users_org_fkey", Type: "FOREIGN KEY", Definition: "FOREIGN KEY (org_id) REFERENCES orgs(id)"},
+				},
+			},
+		},
+	}
+
+	if changes := Compare(current, desired); len(changes) != 0 {
+		t.Errorf("expected 0 changes, got %d", len(changes))
+	}
+}
+
+func TestTableChange_SQL_ConstraintOrdering(t *testing.T) {
+	oldTable := parser.Table{
+		Name: "orders",
+		Columns: []parser.Column{
+			{Name: "id", Type: "integer"},
+			{Name: "legacy_ref", Type: "integer"},
+		},
+		Constraints: []parser.Constraint{
+			{Name: "orders_legacy_ref_fkey", Type: "FOREIGN KEY", Columns: []string{"legacy_ref"}, RefTable: "legacy", RefColumns: []string{"id"}},
+		},
+	}
+	change := &TableChange{
+		ChangeType:      AlterTable,
+		Table:           parser.Table{Name: "orders"},
+		OldTable:        &oldTable,
+		AddColumns:      []parser.Column{{Name: "customer_id", Type: "integer", Nullable: true}},
+		DropColumns:     []string{"legacy_ref"},
+		DropConstraints: []string{"orders_legacy_ref_fkey"},
+		AddConstraints: []parser.Constraint{
+			{Name: "orders_customer_id_fkey", Type: "FOREIGN KEY", Columns: []string{"customer_id"}, RefTable: "customers", RefColumns: []string{"id"}},
+		},
+	}
+
+	sql := change.SQL()
+	dropConIdx := strings.Index(sql, "DROP CONSTRAINT orders_legacy_ref_fkey")
+	dropColIdx := strings.Index(sql, "DROP COLUMN legacy_ref")
+	addColIdx := strings.Index(sql, "ADD COLUMN customer_id")
+	addConIdx := strings.Index(sql, "ADD CONSTRAINT orders_customer_id_fkey")
+
+	if dropConIdx == -1 || dropColIdx == -1 || addColIdx == -1 || addConIdx == -1 {
+		t.Fatalf("SQL = %q, missing expected statements", sql)
+	}
+	if dropConIdx > dropColIdx {
+		t.Error("constraints should be dropped before columns")
+	}
+	if addConIdx < addColIdx {
+		t.Error("constraints should be added after columns")
+	}
+}
+
+func TestCompare_AlterTable_Options(t *testing.T) {
+	cols := []parser.Column{{Name: "id", Type: "integer"}}
+	current := &parser.Schema{
+		Tables: []parser.Table{
+			{Name: "events", Columns: cols, StorageParams: []string{"fillfactor=100", "autovacuum_enabled=false"}},
+		},
+	}
+	desired := &parser.Schema{
+		Tables: []parser.Table{
+			{Name: "events", Columns: cols, RowSecurity: true, ForceRowSecurity: true, Unlogged: true,
+				StorageParams: []string{"fillfactor=70"}},
+		},
+	}
+
+	changes := Compare(current, desired)
+	if len(changes) != 1 {
+		t.Fatalf("expected 1 change, got %d", len(changes))
+	}
+
+	wantUp := "ALTER TABLE events ENABLE ROW LEVEL SECURITY;\n" +
+		"ALTER TABLE events FORCE ROW LEVEL SECURITY;\n" +
+		"ALTER TABLE events SET UNLOGGED;\n" +
+		"ALTER TABLE events RESET (autovacuum_enabled);\n" +
+		"ALTER TABLE events SET (fillfactor=70);"
+	if got := changes[0].SQL(); got != wantUp {
+		t.Errorf("SQL() =\n%s\nwant\n%s", got, wantUp)
+	}
+
+	wantDown := "ALTER TABLE events DISABLE ROW LEVEL SECURITY;\n" +
+		"ALTER TABLE events NO FORCE ROW LEVEL SECURITY;\n" +
+		"ALTER TABLE events SET LOGGED;\n" +
+		"ALTER TABLE events SET (fillfactor=100, autovacuum_enabled=false);"
+	if got := changes[0].DownSQL(); got != wantDown {
+		t.Errorf("DownSQL() =\n%s\nwant\n%s", got, wantDown)
+	}
+	if !changes[0].IsReversible() {
+		t.Error("expected table option changes to be reversible")
+	}
+}
+
+func TestCompare_AlterTable_Inherits(t *testing.T) {
+	cols := []parser.Column{{Name: "id", Type: "integer"}}
+	current := &parser.Schema{
+		Tables: []parser.Table{
+			{Name: "base", Columns: cols},
+			{Name: "audit", Columns: cols},
+			{Name: "child", Columns: cols, Inherits: []string{"base"}},
+		},
+	}
+	desired := &parser.Schema{
+		Tables: []parser.Table{
+			{Name: "base", Columns: cols},
+			{Name: "audit", Columns: cols},
+			{Name: "child", Columns: cols, Inherits: []string{"audit"}},
+		},
+	}
+
+	changes := Compare(current, desired)
+	if len(changes) != 1 {
+		t.Fatalf("expected 1 change, got %d", len(changes))
+	}
+
+	wantUp := "ALTER TABLE child NO INHERIT base;\nALTER TABLE child INHERIT audit;"
+	if got := changes[0].SQL(); got != wantUp {
+		t.Errorf("SQL() =\n%s\nwant\n%s", got, wantUp)
+	}
+	wantDown := "ALTER TABLE child NO INHERIT audit;\nALTER TABLE child INHERIT base;"
+	if got := changes[0].DownSQL(); got != wantDown {
+		t.Errorf("DownSQL() =\n%s\nwant\n%s", got, wantDown)
+	}
+}
+
+func TestTableChange_SQL_CreateWithOptions(t *testing.T) {
+	change := &TableChange{
+		ChangeType: CreateTable,
+		Table: parser.Table{
+			Name:          "sessions",
+			Columns:       []parser.Column{{Name: "id", Type: "integer", Nullable: true}},
+			Unlogged:      true,
+			Inherits:      []string{"base", "audit.entries"},
+			StorageParams: []string{"fillfactor=70"},
+			RowSecurity:   true,
+		},
+	}
+
+	want := "CREATE UNLOGGED TABLE sessions (\n" +
+		"    id integer\n" +
+		") INHERITS (base, audit.entries) WITH (fillfactor=70);\n" +
+		"ALTER TABLE sessions ENABLE ROW LEVEL SECURITY;"
+	if got := change.SQL(); got != want {
+		t.Errorf("SQL() =\n%s\nwant\n%s", got, want)
+	}
+}
+
+func TestCompare_ReplicaIdentity(t *testing.T) {
+	cols := []parser.Column{{Name: "id", Type: "integer"}}
+	current := &parser.Schema{
+		Tables: []parser.Table{{Name: "orders", Columns: cols, ReplicaIdentity: "FULL"}},
+	}
+	desired := &parser.Schema{
+		Tables:  []parser.Table{{Name: "orders", Columns: cols, ReplicaIdentity: "INDEX", ReplicaIdentityIndex: "orders_id_idx"}},
+		Indexes: []parser.Index{{Name: "orders_id_idx", Table: "orders", Columns: []string{"id"}, Unique: true}},
+	}
+
+	changes := Compare(current, desired)
+	if len(changes) != 2 {
+		t.Fatalf("expected 2 changes, got %d", len(changes))
+	}
+	if changes[0].Type() != CreateIndex {
+		t.Fatalf("expected index to be created before the replica identity change, got %v first", changes[0].Type())
+	}
+
+	if got, want := changes[1].SQL(), `ALTER TABLE orders REPLICA IDENTITY USING INDEX orders_id_idx;`; got != want {
+		t.Errorf("SQL() = %q, want %q", got, want)
+	}
+	if got, want := changes[1].DownSQL(), `ALTER TABLE orders REPLICA IDENTITY FULL;`; got != want {
+		t.Errorf("DownSQL() = %q, want %q", got, want)
+	}
+}
+
+func TestCompare_AlterTable_ColumnOptions(t *testing.T) {
+	stats := 500
+	current := &parser.Schema{
+		Tables: []parser.Table{{Name: "users", Columns: []parser.Column{
+			{Name: "email", Type: "text", Nullable: true},
+			{Name: "bio", Type: "text", Nullable: true, Compression: "pglz"},
+		}}},
+	}
+	desired := &parser.Schema{
+		Tables: []parser.Table{{Name: "users", Columns: []parser.Column{
+			{Name: "email", Type: "text", Nullable: true, Collation: "case_insensitive", Statistics: &stats},
+			{Name: "bio", Type: "text", Nullable: true, Storage: "EXTERNAL"},
+		}}},
+	}
+
+	changes := Compare(current, desired)
+	if len(changes) != 1 {
+		t.Fatalf("expected 1 change, got %d", len(changes))
+	}
+
+	up := changes[0].SQL()
+	for _, want := range []string{
+		"ALTER TABLE users ALTER COLUMN email TYPE text COLLATE case_insensitive;",
+		"ALTER TABLE users ALTER COLUMN email SET STATISTICS 500;",
+		"ALTER TABLE users ALTER COLUMN bio SET STORAGE EXTERNAL;",
+		"ALTER TABLE users ALTER COLUMN bio SET COMPRESSION DEFAULT;",
+	} {
+		if !strings.Contains(up, want) {
+			t.Errorf("SQL() missing %q, got:\n%s", want, up)
+		}
+	}
+
+	down := changes[0].DownSQL()
+	for _, want := range []string{
+		"ALTER TABLE users ALTER COLUMN email TYPE text;",
+		"ALTER TABLE users ALTER COLUMN email SET STATISTICS -1;",
+		"ALTER TABLE users ALTER COLUMN bio SET STORAGE DEFAULT;",
+		"ALTER TABLE users ALTER COLUMN bio SET COMPRESSION pglz;",
+	} {
+		if !strings.Contains(down, want) {
+			t.Errorf("DownSQL() missing %q, got:\n%s", want, down)
+		}
+	}
+}
+
+func TestTableChange_SQL_CreateWithColumnOptions(t *testing.T) {
+	stats := 1000
+	change := &TableChange{
+		ChangeType: CreateTable,
+		Table: parser.Table{
+			Name: "documents",
+			Columns: []parser.Column{
+				{Name: "title", Type: "text", Collation: `"C"`, Statistics: &stats},
+				{Name: "body", Type: "text", Compression: "lz4", Storage: "EXTERNAL"},
+			},
+		},
+	}
+
+	want := "CREATE TABLE documents (\n" +
+		"    title text COLLATE \"C\" NOT NULL,\n" +
+		"    body text COMPRESSION lz4 NOT NULL\n" +
+		");\n" +
+		"ALTER TABLE documents ALTER COLUMN title SET STATISTICS 1000;\n" +
+		"ALTER TABLE documents ALTER COLUMN body SET STORAGE EXTERNAL;"
+	if got := change.SQL(); got != want {
+		t.Errorf("SQL() =\n%s\nwant\n%s", got, want)
+	}
+}
+
+func TestCompare_AlterTable_Online(t *testing.T) {
+	current := parser.Table{
+		Name: "orders",
+		Columns: []parser.Column{
+			{Name: "id", Type: "integer"},
+			{Name: "customer_id", Type: "integer", Nullable: true},
+			{Name: "reference", Type: "text", Nullable: true},
+		},
+	}
+	desired := current
+	desired.Columns = []parser.Column{
+		{Name: "id", Type: "integer"},
+		{Name: "customer_id", Type: "integer"},
+		{Name: "reference", Type: "text", Nullable: true},
+	}
+	desired.Constraints = []parser.Constraint{
+		{Name: "orders_customer_id_fkey", Type: "FOREIGN KEY", Columns: []string{"customer_id"}, RefTable: "customers", RefColumns: []string{"id"}},
+		{Name: "orders_reference_key", Type: "UNIQUE", Columns: []string{"reference"}},
+	}
+
+	changes := CompareWithOptions(
+		&parser.Schema{Tables: []parser.Table{current}},
+		&parser.Schema{Tables: []parser.Table{desired}},
+		Options{Online: true},
+	)
+	if len(changes) != 1 {
+		t.Fatalf("expected 1 change, got %d", len(changes))
+	}
+
+	want := []string{
+		"ALTER TABLE orders ADD CONSTRAINT orders_customer_id_not_null_check CHECK (customer_id IS NOT NULL) NOT VALID;",
+		"ALTER TABLE orders VALIDATE CONSTRAINT orders_customer_id_not_null_check;",
+		"ALTER TABLE orders ALTER COLUMN customer_id SET NOT NULL;",
+		"ALTER TABLE orders DROP CONSTRAINT orders_customer_id_not_null_check;",
+		"ALTER TABLE orders ADD CONSTRAINT orders_customer_id_fkey FOREIGN KEY (customer_id) REFERENCES customers (id) NOT VALID;",
+		"ALTER TABLE orders VALIDATE CONSTRAINT orders_customer_id_fkey;",
+		"CREATE UNIQUE INDEX CONCURRENTLY orders_reference_key ON orders (reference);",
+		"ALTER TABLE orders ADD CONSTRAINT orders_reference_key UNIQUE USING INDEX orders_reference_key;",
+	}
+	if got := changes[0].SQL(); got != strings.Join(want, "\n") {
+		t.Errorf("SQL() =\n%s\nwant\n%s", got, strings.Join(want, "\n"))
+	}
+
+	if !NeedsNoTransaction(changes) {
+		t.Error("online steps must run outside a transaction")
+	}
+	if hazards := Hazards(changes[0]); len(hazards) != 0 {
+		t.Errorf("Hazards() = %v, want none", hazards)
+	}
+
+	offline := Compare(&parser.Schema{Tables: []parser.Table{current}}, &parser.Schema{Tables: []parser.Table{desired}})
+	if NeedsNoTransaction(offline) {
+		t.Error("without online, the change should run in a transaction")
+	}
+	if strings.Contains(offline[0].SQL(), "VALIDATE") {
+		t.Errorf("SQL() without online = %q, want single statements", offline[0].SQL())
+	}
+}
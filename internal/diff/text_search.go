@@ -53,19 +53,19 @@ func compareTextSearchConfigs(current, desired []parser.TextSearchConfig) []Chan
 	var changes []Change
 	currentMap := make(map[string]parser.TextSearchConfig)
 	for _, t := range current {
-		currentMap[t.Name] = t
+		currentMap[objectKey(t.Schema, t.Name)] = t
 	}
 	for _, t := range desired {
-		if _, exists := currentMap[t.Name]; !exists {
+		if _, exists := currentMap[objectKey(t.Schema, t.Name)]; !exists {
 			changes = append(changes, &TextSearchConfigChange{ChangeType: CreateTextSearchConfig, TextSearchConfig: t})
 		}
 	}
 	desiredMap := make(map[string]bool)
 	for _, t := range desired {
-		desiredMap[t.Name] = true
+		desiredMap[objectKey(t.Schema, t.Name)] = true
 	}
 	for _, t := range current {
-		if !desiredMap[t.Name] {
+		if !desiredMap[objectKey(t.Schema, t.Name)] {
 			oldTS := t
 			changes = append(changes, &TextSearchConfigChange{ChangeType: DropTextSearchConfig, TextSearchConfig: t, OldTextSearchConfig: &oldTS})
 		}
@@ -18,7 +18,7 @@ func (c *TriggerChange) SQL() string {
 	case CreateTrigger:
 		return generateCreateTrigger(c.Trigger)
 	case DropTrigger:
-		return fmt.Sprintf("DROP TRIGGER %s ON %s;", quoteIdent(c.Trigger.Name), quoteIdent(c.Trigger.Table))
+		return fmt.Sprintf("DROP TRIGGER %s ON %s;", quoteIdent(c.Trigger.Name), qualifiedName(c.Trigger.Schema, c.Trigger.Table))
 	}
 	return ""
 }
@@ -26,7 +26,7 @@ func (c *TriggerChange) SQL() string {
 func (c *TriggerChange) DownSQL() string {
 	switch c.ChangeType {
 	case CreateTrigger:
-		return fmt.Sprintf("DROP TRIGGER %s ON %s;", quoteIdent(c.Trigger.Name), quoteIdent(c.Trigger.Table))
+		return fmt.Sprintf("DROP TRIGGER %s ON %s;", quoteIdent(c.Trigger.Name), qualifiedName(c.Trigger.Schema, c.Trigger.Table))
 	case DropTrigger:
 		if c.OldTrigger != nil {
 			return generateCreateTrigger(*c.OldTrigger)
@@ -56,22 +56,26 @@ func compareTriggers(current, desired []parser.Trigger) []Change {
 
 	currentMap := make(map[string]parser.Trigger)
 	for _, t := range current {
-		currentMap[t.Name] = t
+		currentMap[tableObjectKey(t.Schema, t.Table, t.Name)] = t
 	}
 
 	desiredMap := make(map[string]parser.Trigger)
 	for _, t := range desired {
-		desiredMap[t.Name] = t
+		desiredMap[tableObjectKey(t.Schema, t.Table, t.Name)] = t
 	}
 
 	for _, t := range desired {
-		if _, exists := currentMap[t.Name]; !exists {
+		if existing, exists := currentMap[tableObjectKey(t.Schema, t.Table, t.Name)]; !exists {
+			changes = append(changes, &TriggerChange{ChangeType: CreateTrigger, Trigger: t})
+		} else if !triggersEqual(existing, t) {
+			oldTrig := existing
+			changes = append(changes, &TriggerChange{ChangeType: DropTrigger, Trigger: existing, OldTrigger: &oldTrig})
 			changes = append(changes, &TriggerChange{ChangeType: CreateTrigger, Trigger: t})
 		}
 	}
 
 	for _, t := range current {
-		if _, exists := desiredMap[t.Name]; !exists {
+		if _, exists := desiredMap[tableObjectKey(t.Schema, t.Table, t.Name)]; !exists {
 			oldTrig := t
 			changes = append(changes, &TriggerChange{ChangeType: DropTrigger, Trigger: t, OldTrigger: &oldTrig})
 		}
@@ -80,12 +84,23 @@ func compareTriggers(current, desired []parser.Trigger) []Change {
 	return changes
 }
 
+func triggersEqual(a, b parser.Trigger) bool {
+	if a.Definition != "" && b.Definition != "" {
+		return normalizeSQL(a.Definition) == normalizeSQL(b.Definition)
+	}
+	return generateCreateTrigger(a) == generateCreateTrigger(b)
+}
+
 func generateCreateTrigger(t parser.Trigger) string {
+	if t.Definition != "" {
+		return strings.TrimSuffix(strings.TrimSpace(t.Definition), ";") + ";"
+	}
+
 	var sb strings.Builder
 
 	sb.WriteString(fmt.Sprintf("CREATE TRIGGER %s ", quoteIdent(t.Name)))
 	sb.WriteString(fmt.Sprintf("%s %s ", t.Timing, strings.Join(t.Events, " OR ")))
-	sb.WriteString(fmt.Sprintf("ON %s ", quoteIdent(t.Table)))
+	sb.WriteString(fmt.Sprintf("ON %s ", qualifiedName(t.Schema, t.Table)))
 	sb.WriteString(fmt.Sprintf("FOR EACH %s ", t.ForEach))
 
 	if t.When != "" {
@@ -205,3 +205,47 @@ func TestTriggerChange_IsReversible(t *testing.T) {
 		t.Error("DropTrigger without OldTrigger should not be reversible")
 	}
 }
+
+func TestCompare_TriggerDefinitionChanged(t *testing.T) {
+	current := &parser.Schema{
+		Triggers: []parser.Trigger{{
+			Schema:     "public",
+			Name:       "touch",
+			Table:      "users",
+			Definition: "CREATE TRIGGER touch BEFORE UPDATE ON public.users FOR EACH ROW EXECUTE FUNCTION set_updated_at()",
+		}},
+	}
+	desired := &parser.Schema{
+		Triggers: []parser.Trigger{{
+			Schema:     "public",
+			Name:       "touch",
+			Table:      "users",
+			Definition: "CREATE TRIGGER touch BEFORE INSERT OR UPDATE ON public.users FOR EACH ROW EXECUTE FUNCTION set_updated_at()",
+		}},
+	}
+
+	changes := Compare(current, desired)
+	if len(changes) != 2 || changes[0].Type() != DropTrigger || changes[1].Type() != CreateTrigger {
+		t.Fatalf("expected DropTrigger then CreateTrigger, got %d changes", len(changes))
+	}
+
+	if got := changes[1].SQL(); got != desired.Triggers[0].Definition+";" {
+		t.Errorf("SQL = %q, want the desired definition", got)
+	}
+	if got := changes[0].DownSQL(); got != current.Triggers[0].Definition+";" {
+		t.Errorf("DownSQL = %q, want the previous definition", got)
+	}
+}
+
+func TestCompare_TriggerUnchanged(t *testing.T) {
+	schema := &parser.Schema{
+		Triggers: []parser.Trigger{{
+			Name:       "touch",
+			Table:      "users",
+			Definition: "CREATE TRIGGER touch BEFORE UPDATE ON public.users FOR EACH ROW EXECUTE FUNCTION set_updated_at()",
+		}},
+	}
+	if changes := Compare(schema, schema); len(changes) != 0 {
+		t.Errorf("expected no changes, got %d", len(changes))
+	}
+}
@@ -69,16 +69,16 @@ func compareViews(current, desired []parser.View) []Change {
 
 	currentMap := make(map[string]parser.View)
 	for _, v := range current {
-		currentMap[v.Name] = v
+		currentMap[objectKey(v.Schema, v.Name)] = v
 	}
 
 	desiredMap := make(map[string]parser.View)
 	for _, v := range desired {
-		desiredMap[v.Name] = v
+		desiredMap[objectKey(v.Schema, v.Name)] = v
 	}
 
 	for _, v := range desired {
-		if existing, exists := currentMap[v.Name]; !exists {
+		if existing, exists := currentMap[objectKey(v.Schema, v.Name)]; !exists {
 			changes = append(changes, &ViewChange{ChangeType: CreateView, View: v})
 		} else if normalizeSQL(existing.Definition) != normalizeSQL(v.Definition) {
 			oldView := existing
@@ -87,7 +87,7 @@ func compareViews(current, desired []parser.View) []Change {
 	}
 
 	for _, v := range current {
-		if _, exists := desiredMap[v.Name]; !exists {
+		if _, exists := desiredMap[objectKey(v.Schema, v.Name)]; !exists {
 			oldView := v
 			changes = append(changes, &ViewChange{ChangeType: DropView, View: v, OldView: &oldView})
 		}
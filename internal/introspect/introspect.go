@@ -49,6 +49,7 @@ func Database(ctx context.Context, databaseURL string) (*parser.Schema, error) {
 		loadRoles,
 		loadRoleGrants,
 		loadDefaultPrivileges,
+		loadDependencies,
 	}
 
 	for _, loader := range loaders {
@@ -69,7 +70,8 @@ func loadTables(ctx context.Context, conn *pgx.Conn, schema *parser.Schema) erro
 			c.data_type,
 			c.is_nullable,
 			c.column_default,
-			c.udt_name
+			c.udt_name,
+			c.udt_schema
 		FROM information_schema.columns c
 		JOIN information_schema.tables t ON c.table_name = t.table_name AND c.table_schema = t.table_schema
 		WHERE c.table_schema NOT IN ('pg_catalog', 'information_schema', 'pg_toast')
@@ -85,9 +87,9 @@ func loadTables(ctx context.Context, conn *pgx.Conn, schema *parser.Schema) erro
 
 	for rows.Next() {
 		var schemaName, tableName, columnName, dataType, isNullable string
-		var columnDefault, udtName *string
+		var columnDefault, udtName, udtSchema *string
 
-		if err := rows.Scan(&schemaName, &tableName, &columnName, &dataType, &isNullable, &columnDefault, &udtName); err != nil {
+		if err := rows.Scan(&schemaName, &tableName, &columnName, &dataType, &isNullable, &columnDefault, &udtName, &udtSchema); err != nil {
 			return fmt.Errorf("failed to scan column: %w", err)
 		}
 
@@ -106,6 +108,9 @@ func loadTables(ctx context.Context, conn *pgx.Conn, schema *parser.Schema) erro
 		if columnDefault != nil {
 			col.Default = *columnDefault
 		}
+		if udtSchema != nil && (dataType == "USER-DEFINED" || dataType == "ARRAY") {
+			col.TypeSchema = *udtSchema
+		}
 
 		table.Columns = append(table.Columns, col)
 	}
@@ -118,6 +123,14 @@ func loadTables(ctx context.Context, conn *pgx.Conn, schema *parser.Schema) erro
 		return err
 	}
 
+	if err := loadTableOptions(ctx, conn, tableMap); err != nil {
+		return err
+	}
+
+	if err := loadColumnOptions(ctx, conn, tableMap); err != nil {
+		return err
+	}
+
 	for _, table := range tableMap {
 		schema.Tables = append(schema.Tables, *table)
 	}
@@ -138,7 +151,9 @@ func loadConstraints(ctx context.Context, conn *pgx.Conn, tableMap map[string]*p
 			string_agg(DISTINCT ccu.column_name, ',') AS ref_columns,
 			rc.delete_rule,
 			rc.update_rule,
-			pg_get_constraintdef(pgc.oid) as constraint_def
+			pg_get_constraintdef(pgc.oid) as constraint_def,
+			pg_get_expr(pgc.conbin, pgc.conrelid) as check_expr,
+			COALESCE(pgc.convalidated, true) as validated
 		FROM information_schema.table_constraints tc
 		LEFT JOIN information_schema.key_column_usage kcu
 			ON tc.constraint_name = kcu.constraint_name
@@ -154,6 +169,7 @@ func loadConstraints(ctx context.Context, conn *pgx.Conn, tableMap map[string]*p
 		LEFT JOIN pg_constraint pgc
 			ON pgc.conname = tc.constraint_name
 			AND pgc.connamespace = (SELECT oid FROM pg_namespace WHERE nspname = tc.table_schema)
+			AND pgc.conrelid = (quote_ident(tc.table_schema) || '.' || quote_ident(tc.table_name))::regclass
 		WHERE tc.table_schema NOT IN ('pg_catalog', 'information_schema', 'pg_toast')
 		AND tc.constraint_type IN ('PRIMARY KEY', 'FOREIGN KEY', 'UNIQUE', 'CHECK')
 		GROUP BY tc.table_schema, tc.table_name, tc.constraint_name, tc.constraint_type, ccu.table_schema, ccu.table_name, rc.delete_rule, rc.update_rule, pgc.oid
@@ -166,9 +182,10 @@ func loadConstraints(ctx context.Context, conn *pgx.Conn, tableMap map[string]*p
 
 	for rows.Next() {
 		var schemaName, tableName, constraintName, constraintType string
-		var columns, refSchema, refTable, refColumns, deleteRule, updateRule, constraintDef *string
+		var columns, refSchema, refTable, refColumns, deleteRule, updateRule, constraintDef, checkExpr *string
+		var validated bool
 
-		if err := rows.Scan(&schemaName, &tableName, &constraintName, &constraintType, &columns, &refSchema, &refTable, &refColumns, &deleteRule, &updateRule, &constraintDef); err != nil {
+		if err := rows.Scan(&schemaName, &tableName, &constraintName, &constraintType, &columns, &refSchema, &refTable, &refColumns, &deleteRule, &updateRule, &constraintDef, &checkExpr, &validated); err != nil {
 			return fmt.Errorf("failed to scan constraint: %w", err)
 		}
 
@@ -183,8 +200,9 @@ func loadConstraints(ctx context.Context, conn *pgx.Conn, tableMap map[string]*p
 		}
 
 		constraint := parser.Constraint{
-			Name: constraintName,
-			Type: constraintType,
+			Name:     constraintName,
+			Type:     constraintType,
+			NotValid: !validated,
 		}
 
 		if columns != nil && *columns != "" {
@@ -207,8 +225,78 @@ func loadConstraints(ctx context.Context, conn *pgx.Conn, tableMap map[string]*p
 		if updateRule != nil {
 			constraint.OnUpdate = *updateRule
 		}
-		if constraintDef != nil && constraintType == "CHECK" {
-			constraint.Check = *constraintDef
+		if constraintDef != nil {
+			constraint.Definition = *constraintDef
+		}
+		if checkExpr != nil && constraintType == "CHECK" {
+			constraint.Check = *checkExpr
+		}
+
+		table.Constraints = append(table.Constraints, constraint)
+	}
+
+	return loadExclusionConstraints(ctx, conn, tableMap)
+}
+
+func loadExclusionConstraints(ctx context.Context, conn *pgx.Conn, tableMap map[string]*parser.Table) error {
+	rows, err := conn.Query(ctx, `
+		SELECT
+			n.nspname AS schema_name,
+			c.relname AS table_name,
+			con.conname,
+			am.amname AS using_method,
+			array_agg(COALESCE(a.attname, '') ORDER BY k.ord) AS columns,
+			array_agg(op.oprname ORDER BY k.ord) AS operators,
+			pg_get_expr(ix.indpred, ix.indrelid) AS where_clause,
+			pg_get_constraintdef(con.oid) AS constraint_def
+		FROM pg_constraint con
+		JOIN pg_class c ON con.conrelid = c.oid
+		JOIN pg_namespace n ON c.relnamespace = n.oid
+		JOIN pg_index ix ON ix.indexrelid = con.conindid
+		JOIN pg_class ic ON ic.oid = con.conindid
+		JOIN pg_am am ON ic.relam = am.oid
+		CROSS JOIN LATERAL unnest(con.conkey, con.conexclop) WITH ORDINALITY AS k(attnum, opoid, ord)
+		LEFT JOIN pg_attribute a ON a.attrelid = con.conrelid AND a.attnum = k.attnum
+		JOIN pg_operator op ON op.oid = k.opoid
+		WHERE con.contype = 'x'
+		AND n.nspname NOT IN ('pg_catalog', 'information_schema', 'pg_toast')
+		GROUP BY n.nspname, c.relname, con.conname, am.amname, ix.indpred, ix.indrelid, con.oid
+		ORDER BY n.nspname, c.relname, con.conname
+	`)
+	if err != nil {
+		return fmt.Errorf("failed to query exclusion constraints: %w", err)
+	}
+	defer rows.Close()
+
+	for rows.Next() {
+		var schemaName, tableName, constraintName, usingMethod, constraintDef string
+		var columns, operators []string
+		var whereClause *string
+
+		if err := rows.Scan(&schemaName, &tableName, &constraintName, &usingMethod, &columns, &operators, &whereClause, &constraintDef); err != nil {
+			return fmt.Errorf("failed to scan exclusion constraint: %w", err)
+		}
+
+		table, ok := tableMap[schemaName+"."+tableName]
+		if !ok {
+			continue
+		}
+
+		constraint := parser.Constraint{
+			Name:               constraintName,
+			Type:               "EXCLUSION",
+			Columns:            columns,
+			ExclusionUsing:     usingMethod,
+			ExclusionOperators: make(map[string]string),
+			Definition:         constraintDef,
+		}
+		for i, col := range columns {
+			if i < len(operators) {
+				constraint.ExclusionOperators[col] = operators[i]
+			}
+		}
+		if whereClause != nil {
+			constraint.ExclusionWhere = *whereClause
 		}
 
 		table.Constraints = append(table.Constraints, constraint)
@@ -217,6 +305,150 @@ func loadConstraints(ctx context.Context, conn *pgx.Conn, tableMap map[string]*p
 	return nil
 }
 
+func loadTableOptions(ctx context.Context, conn *pgx.Conn, tableMap map[string]*parser.Table) error {
+	rows, err := conn.Query(ctx, `
+		SELECT
+			n.nspname AS schema_name,
+			c.relname AS table_name,
+			c.relrowsecurity,
+			c.relforcerowsecurity,
+			c.relpersistence = 'u' AS unlogged,
+			COALESCE(c.reloptions, '{}') AS storage_params,
+			CASE c.relreplident
+				WHEN 'f' THEN 'FULL'
+				WHEN 'n' THEN 'NOTHING'
+				WHEN 'i' THEN 'INDEX'
+				ELSE ''
+			END AS replica_identity,
+			COALESCE((
+				SELECT ic.relname
+				FROM pg_index ri
+				JOIN pg_class ic ON ri.indexrelid = ic.oid
+				WHERE ri.indrelid = c.oid AND ri.indisreplident
+			), '') AS replica_identity_index,
+			COALESCE(ARRAY(
+				SELECT CASE WHEN pn.nspname = n.nspname THEN pc.relname ELSE pn.nspname || '.' || pc.relname END
+				FROM pg_inherits i
+				JOIN pg_class pc ON i.inhparent = pc.oid
+				JOIN pg_namespace pn ON pc.relnamespace = pn.oid
+				WHERE i.inhrelid = c.oid AND NOT c.relispartition
+				ORDER BY i.inhseqno
+			), '{}') AS inherits
+		FROM pg_class c
+		JOIN pg_namespace n ON c.relnamespace = n.oid
+		WHERE c.relkind IN ('r', 'p')
+		AND n.nspname NOT IN ('pg_catalog', 'information_schema', 'pg_toast')
+	`)
+	if err != nil {
+		return fmt.Errorf("failed to query table options: %w", err)
+	}
+	defer rows.Close()
+
+	for rows.Next() {
+		var schemaName, tableName, replicaIdentity, replicaIndex string
+		var rowSecurity, forceRowSecurity, unlogged bool
+		var storageParams, inherits []string
+		if err := rows.Scan(&schemaName, &tableName, &rowSecurity, &forceRowSecurity, &unlogged,
+			&storageParams, &replicaIdentity, &replicaIndex, &inherits); err != nil {
+			return fmt.Errorf("failed to scan table options: %w", err)
+		}
+
+		key := schemaName + "." + tableName
+		if table, ok := tableMap[key]; ok {
+			table.RowSecurity = rowSecurity
+			table.ForceRowSecurity = forceRowSecurity
+			table.Unlogged = unlogged
+			table.StorageParams = storageParams
+			table.ReplicaIdentity = replicaIdentity
+			table.ReplicaIdentityIndex = replicaIndex
+			table.Inherits = inherits
+		}
+	}
+
+	return nil
+}
+
+func loadColumnOptions(ctx context.Context, conn *pgx.Conn, tableMap map[string]*parser.Table) error {
+	version, err := serverVersion(ctx, conn)
+	if err != nil {
+		return err
+	}
+	compression := "''"
+	if version >= 140000 {
+		compression = `CASE a.attcompression
+				WHEN 'p' THEN 'pglz'
+				WHEN 'l' THEN 'lz4'
+				ELSE ''
+			END`
+	}
+
+	rows, err := conn.Query(ctx, fmt.Sprintf(`
+		SELECT
+			n.nspname AS schema_name,
+			c.relname AS table_name,
+			a.attname AS column_name,
+			CASE
+				WHEN a.attcollation = t.typcollation OR a.attcollation = 0 THEN ''
+				WHEN cn.nspname IN ('pg_catalog', 'public') THEN quote_ident(co.collname)
+				ELSE quote_ident(cn.nspname) || '.' || quote_ident(co.collname)
+			END AS collation,
+			CASE WHEN a.attstorage = t.typstorage THEN ''
+			ELSE CASE a.attstorage
+				WHEN 'p' THEN 'PLAIN'
+				WHEN 'e' THEN 'EXTERNAL'
+				WHEN 'm' THEN 'MAIN'
+				WHEN 'x' THEN 'EXTENDED'
+			END END AS storage,
+			%s AS compression,
+			NULLIF(a.attstattarget::int, -1) AS statistics
+		FROM pg_attribute a
+		JOIN pg_class c ON a.attrelid = c.oid
+		JOIN pg_namespace n ON c.relnamespace = n.oid
+		JOIN pg_type t ON a.atttypid = t.oid
+		LEFT JOIN pg_collation co ON a.attcollation = co.oid
+		LEFT JOIN pg_namespace cn ON co.collnamespace = cn.oid
+		WHERE c.relkind IN ('r', 'p')
+		AND a.attnum > 0
+		AND NOT a.attisdropped
+		AND n.nspname NOT IN ('pg_catalog', 'information_schema', 'pg_toast')
+	`, compression))
+	if err != nil {
+		return fmt.Errorf("failed to query column options: %w", err)
+	}
+	defer rows.Close()
+
+	for rows.Next() {
+		var schemaName, tableName, columnName, collation, storage, compression string
+		var statistics *int
+		if err := rows.Scan(&schemaName, &tableName, &columnName, &collation, &storage, &compression, &statistics); err != nil {
+			return fmt.Errorf("failed to scan column options: %w", err)
+		}
+
+		table, ok := tableMap[schemaName+"."+tableName]
+		if !ok {
+			continue
+		}
+		for i := range table.Columns {
+			if table.Columns[i].Name == columnName {
+				table.Columns[i].Collation = collation
+				table.Columns[i].Storage = storage
+				table.Columns[i].Compression = compression
+				table.Columns[i].Statistics = statistics
+			}
+		}
+	}
+
+	return nil
+}
+
+func serverVersion(ctx context.Context, conn *pgx.Conn) (int, error) {
+	var version int
+	if err := conn.QueryRow(ctx, `SELECT current_setting('server_version_num')::int`).Scan(&version); err != nil {
+		return 0, fmt.Errorf("failed to query server version: %w", err)
+	}
+	return version, nil
+}
+
 func loadPartitionInfo(ctx context.Context, conn *pgx.Conn, tableMap map[string]*parser.Table) error {
 	rows, err := conn.Query(ctx, `
 		SELECT
@@ -312,7 +544,7 @@ func loadIndexes(ctx context.Context, conn *pgx.Conn, schema *parser.Schema) err
 		AND NOT ix.indisprimary
 		AND NOT EXISTS (
 			SELECT 1 FROM pg_constraint c
-			WHERE c.conindid = ix.indexrelid AND c.contype = 'u'
+			WHERE c.conindid = ix.indexrelid AND c.contype IN ('u', 'x')
 		)
 		GROUP BY n.nspname, i.relname, t.relname, ix.indisunique, am.amname, ix.indexrelid, ix.indpred
 		ORDER BY n.nspname, t.relname, i.relname
@@ -386,6 +618,7 @@ func loadFunctions(ctx context.Context, conn *pgx.Conn, schema *parser.Schema) e
 			n.nspname AS schema_name,
 			p.proname,
 			pg_get_function_arguments(p.oid) AS args,
+			pg_get_function_identity_arguments(p.oid) AS identity_args,
 			pg_get_function_result(p.oid) AS returns,
 			l.lanname AS language,
 			p.prosrc AS body,
@@ -395,7 +628,7 @@ func loadFunctions(ctx context.Context, conn *pgx.Conn, schema *parser.Schema) e
 		JOIN pg_language l ON p.prolang = l.oid
 		WHERE n.nspname NOT IN ('pg_catalog', 'information_schema', 'pg_toast')
 		AND p.prokind = 'f'
-		ORDER BY n.nspname, p.proname
+		ORDER BY n.nspname, p.proname, identity_args
 	`)
 	if err != nil {
 		return fmt.Errorf("failed to query functions: %w", err)
@@ -403,19 +636,20 @@ func loadFunctions(ctx context.Context, conn *pgx.Conn, schema *parser.Schema) e
 	defer rows.Close()
 
 	for rows.Next() {
-		var schemaName, name, args, returns, language, body, definition string
-		if err := rows.Scan(&schemaName, &name, &args, &returns, &language, &body, &definition); err != nil {
+		var schemaName, name, args, identityArgs, returns, language, body, definition string
+		if err := rows.Scan(&schemaName, &name, &args, &identityArgs, &returns, &language, &body, &definition); err != nil {
 			return fmt.Errorf("failed to scan function: %w", err)
 		}
 
 		fn := parser.Function{
-			Schema:     schemaName,
-			Name:       name,
-			Args:       args,
-			Returns:    returns,
-			Language:   language,
-			Body:       body,
-			Definition: definition,
+			Schema:       schemaName,
+			Name:         name,
+			Args:         args,
+			IdentityArgs: identityArgs,
+			Returns:      returns,
+			Language:     language,
+			Body:         body,
+			Definition:   definition,
 		}
 
 		schema.Functions = append(schema.Functions, fn)
@@ -626,11 +860,19 @@ func loadDomains(ctx context.Context, conn *pgx.Conn, schema *parser.Schema) err
 			pg_catalog.format_type(t.typbasetype, t.typtypmod) AS base_type,
 			t.typnotnull,
 			t.typdefault,
-			pg_get_constraintdef(c.oid) AS check_constraint,
+			ARRAY(
+				SELECT c.conname::text FROM pg_constraint c
+				WHERE c.contypid = t.oid AND c.contype = 'c'
+				ORDER BY c.conname
+			) AS constraint_names,
+			ARRAY(
+				SELECT pg_get_constraintdef(c.oid) FROM pg_constraint c
+				WHERE c.contypid = t.oid AND c.contype = 'c'
+				ORDER BY c.conname
+			) AS constraint_defs,
 			col.collname AS collation
 		FROM pg_type t
 		JOIN pg_namespace n ON t.typnamespace = n.oid
-		LEFT JOIN pg_constraint c ON c.contypid = t.oid
 		LEFT JOIN pg_collation col ON t.typcollation = col.oid AND col.collname != 'default'
 		WHERE t.typtype = 'd'
 		AND n.nspname NOT IN ('pg_catalog', 'information_schema', 'pg_toast')
@@ -644,9 +886,10 @@ func loadDomains(ctx context.Context, conn *pgx.Conn, schema *parser.Schema) err
 	for rows.Next() {
 		var schemaName, name, baseType string
 		var notNull bool
-		var defaultVal, checkConstraint, collation *string
+		var defaultVal, collation *string
+		var constraintNames, constraintDefs []string
 
-		if err := rows.Scan(&schemaName, &name, &baseType, &notNull, &defaultVal, &checkConstraint, &collation); err != nil {
+		if err := rows.Scan(&schemaName, &name, &baseType, &notNull, &defaultVal, &constraintNames, &constraintDefs, &collation); err != nil {
 			return fmt.Errorf("failed to scan domain: %w", err)
 		}
 
@@ -659,8 +902,12 @@ func loadDomains(ctx context.Context, conn *pgx.Conn, schema *parser.Schema) err
 		if defaultVal != nil {
 			domain.Default = *defaultVal
 		}
-		if checkConstraint != nil {
-			domain.Check = *checkConstraint
+		for i := range constraintNames {
+			domain.Constraints = append(domain.Constraints, parser.Constraint{
+				Name:       constraintNames[i],
+				Type:       "CHECK",
+				Definition: constraintDefs[i],
+			})
 		}
 		if collation != nil {
 			domain.Collation = *collation
@@ -1310,6 +1557,7 @@ func loadProcedures(ctx context.Context, conn *pgx.Conn, schema *parser.Schema)
 			n.nspname AS schema_name,
 			p.proname,
 			pg_get_function_arguments(p.oid) AS args,
+			pg_get_function_identity_arguments(p.oid) AS identity_args,
 			l.lanname AS language,
 			p.prosrc AS body,
 			pg_get_functiondef(p.oid) AS definition
@@ -1318,7 +1566,7 @@ func loadProcedures(ctx context.Context, conn *pgx.Conn, schema *parser.Schema)
 		JOIN pg_language l ON p.prolang = l.oid
 		WHERE n.nspname NOT IN ('pg_catalog', 'information_schema', 'pg_toast')
 		AND p.prokind = 'p'
-		ORDER BY n.nspname, p.proname
+		ORDER BY n.nspname, p.proname, identity_args
 	`)
 	if err != nil {
 		return fmt.Errorf("failed to query procedures: %w", err)
@@ -1326,18 +1574,19 @@ func loadProcedures(ctx context.Context, conn *pgx.Conn, schema *parser.Schema)
 	defer rows.Close()
 
 	for rows.Next() {
-		var schemaName, name, args, language, body, definition string
-		if err := rows.Scan(&schemaName, &name, &args, &language, &body, &definition); err != nil {
+		var schemaName, name, args, identityArgs, language, body, definition string
+		if err := rows.Scan(&schemaName, &name, &args, &identityArgs, &language, &body, &definition); err != nil {
 			return fmt.Errorf("failed to scan procedure: %w", err)
 		}
 
 		schema.Procedures = append(schema.Procedures, parser.Procedure{
-			Schema:     schemaName,
-			Name:       name,
-			Args:       args,
-			Language:   language,
-			Body:       body,
-			Definition: definition,
+			Schema:       schemaName,
+			Name:         name,
+			Args:         args,
+			IdentityArgs: identityArgs,
+			Language:     language,
+			Body:         body,
+			Definition:   definition,
 		})
 	}
 
@@ -1675,3 +1924,101 @@ func loadDefaultPrivileges(ctx context.Context, conn *pgx.Conn, schema *parser.S
 
 	return nil
 }
+
+func loadDependencies(ctx context.Context, conn *pgx.Conn, schema *parser.Schema) error {
+	rows, err := conn.Query(ctx, `
+		WITH objects AS (
+			SELECT 'pg_class'::regclass AS classid, c.oid AS objid,
+				CASE c.relkind
+					WHEN 'r' THEN 'table' WHEN 'p' THEN 'table'
+					WHEN 'v' THEN 'view' WHEN 'm' THEN 'materialized view'
+					WHEN 'S' THEN 'sequence' WHEN 'f' THEN 'foreign table'
+					WHEN 'i' THEN 'index' WHEN 'I' THEN 'index'
+					WHEN 'c' THEN 'type'
+				END AS kind,
+				n.nspname AS schema_name, c.relname::text AS name
+			FROM pg_class c
+			JOIN pg_namespace n ON n.oid = c.relnamespace
+			UNION ALL
+			SELECT 'pg_rewrite'::regclass, r.oid,
+				CASE c.relkind WHEN 'm' THEN 'materialized view' ELSE 'view' END,
+				n.nspname, c.relname::text
+			FROM pg_rewrite r
+			JOIN pg_class c ON c.oid = r.ev_class
+			JOIN pg_namespace n ON n.oid = c.relnamespace
+			WHERE c.relkind IN ('v', 'm')
+			UNION ALL
+			SELECT 'pg_attrdef'::regclass, ad.oid, 'table', n.nspname, c.relname::text
+			FROM pg_attrdef ad
+			JOIN pg_class c ON c.oid = ad.adrelid
+			JOIN pg_namespace n ON n.oid = c.relnamespace
+			UNION ALL
+			SELECT 'pg_constraint'::regclass, con.oid,
+				CASE WHEN con.conrelid <> 0 THEN 'table' ELSE 'type' END,
+				n.nspname, COALESCE(c.relname, t.typname)::text
+			FROM pg_constraint con
+			JOIN pg_namespace n ON n.oid = con.connamespace
+			LEFT JOIN pg_class c ON c.oid = con.conrelid
+			LEFT JOIN pg_type t ON t.oid = con.contypid
+			UNION ALL
+			SELECT 'pg_trigger'::regclass, tg.oid, 'trigger', n.nspname, c.relname || ':' || tg.tgname
+			FROM pg_trigger tg
+			JOIN pg_class c ON c.oid = tg.tgrelid
+			JOIN pg_namespace n ON n.oid = c.relnamespace
+			WHERE NOT tg.tgisinternal
+			UNION ALL
+			SELECT 'pg_policy'::regclass, pol.oid, 'policy', n.nspname, c.relname || ':' || pol.polname
+			FROM pg_policy pol
+			JOIN pg_class c ON c.oid = pol.polrelid
+			JOIN pg_namespace n ON n.oid = c.relnamespace
+			UNION ALL
+			SELECT 'pg_proc'::regclass, p.oid,
+				CASE p.prokind WHEN 'p' THEN 'procedure' WHEN 'a' THEN 'aggregate' ELSE 'function' END,
+				n.nspname, p.proname || '(' || pg_get_function_identity_arguments(p.oid) || ')'
+			FROM pg_proc p
+			JOIN pg_namespace n ON n.oid = p.pronamespace
+			UNION ALL
+			SELECT 'pg_type'::regclass, t.oid,
+				CASE rc.relkind
+					WHEN 'r' THEN 'table' WHEN 'p' THEN 'table'
+					WHEN 'v' THEN 'view' WHEN 'm' THEN 'materialized view'
+					WHEN 'f' THEN 'foreign table'
+					ELSE 'type'
+				END,
+				n.nspname, base.typname::text
+			FROM pg_type t
+			JOIN pg_type base ON base.oid = CASE WHEN t.typcategory = 'A' AND t.typelem <> 0 THEN t.typelem ELSE t.oid END
+			JOIN pg_namespace n ON n.oid = base.typnamespace
+			LEFT JOIN pg_class rc ON rc.oid = base.typrelid
+		)
+		SELECT DISTINCT
+			o.kind, o.schema_name, o.name,
+			r.kind, r.schema_name, r.name,
+			COALESCE(a.attname::text, '')
+		FROM pg_depend d
+		JOIN objects o ON o.classid = d.classid AND o.objid = d.objid
+		JOIN objects r ON r.classid = d.refclassid AND r.objid = d.refobjid
+		LEFT JOIN pg_attribute a ON d.refclassid = 'pg_class'::regclass
+			AND a.attrelid = d.refobjid AND a.attnum = d.refobjsubid AND d.refobjsubid > 0
+		WHERE d.deptype = 'n'
+		AND o.kind IS NOT NULL AND r.kind IS NOT NULL
+		AND o.schema_name NOT IN ('pg_catalog', 'information_schema', 'pg_toast')
+		AND r.schema_name NOT IN ('pg_catalog', 'information_schema', 'pg_toast')
+		AND NOT (o.kind = r.kind AND o.schema_name = r.schema_name AND o.name = r.name)
+		ORDER BY 2, 1, 3, 5, 4, 6, 7
+	`)
+	if err != nil {
+		return fmt.Errorf("failed to query dependencies: %w", err)
+	}
+	defer rows.Close()
+
+	for rows.Next() {
+		var dep parser.Dependency
+		if err := rows.Scan(&dep.Kind, &dep.Schema, &dep.Name, &dep.RefKind, &dep.RefSchema, &dep.RefName, &dep.RefColumn); err != nil {
+			return fmt.Errorf("failed to scan dependency: %w", err)
+		}
+		schema.Dependencies = append(schema.Dependencies, dep)
+	}
+
+	return nil
+}
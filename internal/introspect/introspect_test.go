@@ -135,6 +135,161 @@ func TestDatabase_Integration(t *testing.T) {
 	}
 }
 
+func TestDatabase_ColumnCollation_Integration(t *testing.T) {
+	if testing.Short() {
+		t.Skip("skipping integration test")
+	}
+
+	ctx, cancel := context.WithTimeout(context.Background(), 120*time.Second)
+	defer cancel()
+
+	cfg := docker.DefaultPostgresConfig()
+	container, err := docker.StartPostgres(ctx, cfg)
+	if err != nil {
+		t.Fatalf("StartPostgres() error = %v", err)
+	}
+
+	defer func() {
+		_ = docker.StopContainer(context.Background(), container.ID)
+	}()
+
+	schemaSQL := `
+		CREATE COLLATION case_insensitive (provider = icu, locale = 'und-u-ks-level2', deterministic = false);
+
+		CREATE TABLE users (
+			id SERIAL PRIMARY KEY,
+			email TEXT COLLATE case_insensitive NOT NULL UNIQUE,
+			name TEXT
+		);
+	`
+
+	if err := docker.ExecuteSQL(ctx, container, schemaSQL); err != nil {
+		t.Fatalf("ExecuteSQL() error = %v", err)
+	}
+
+	schema, err := Database(ctx, container.ConnectionString())
+	if err != nil {
+		t.Fatalf("Database() error = %v", err)
+	}
+
+	usersTable := findTable(schema.Tables, "users")
+	if usersTable == nil {
+		t.Fatal("users table not found")
+	}
+
+	for _, col := range usersTable.Columns {
+		if col.Name == "email" && col.Collation != "case_insensitive" {
+			t.Errorf("users.email Collation = %q, want %q", col.Collation, "case_insensitive")
+		}
+		if col.Name == "name" && col.Collation != "" {
+			t.Errorf("users.name Collation = %q, want default", col.Collation)
+		}
+	}
+}
+
+func TestDatabase_TableOptions_Integration(t *testing.T) {
+	if testing.Short() {
+		t.Skip("skipping integration test")
+	}
+
+	ctx, cancel := context.WithTimeout(context.Background(), 120*time.Second)
+	defer cancel()
+
+	cfg := docker.DefaultPostgresConfig()
+	container, err := docker.StartPostgres(ctx, cfg)
+	if err != nil {
+		t.Fatalf("StartPostgres() error = %v", err)
+	}
+
+	defer func() {
+		_ = docker.StopContainer(context.Background(), container.ID)
+	}()
+
+	schemaSQL := `
+		CREATE TABLE posts (
+			id SERIAL PRIMARY KEY,
+			title TEXT NOT NULL
+		) WITH (fillfactor=70);
+
+		ALTER TABLE posts ENABLE ROW LEVEL SECURITY;
+		ALTER TABLE posts REPLICA IDENTITY FULL;
+	`
+
+	if err := docker.ExecuteSQL(ctx, container, schemaSQL); err != nil {
+		t.Fatalf("ExecuteSQL() error = %v", err)
+	}
+
+	schema, err := Database(ctx, container.ConnectionString())
+	if err != nil {
+		t.Fatalf("Database() error = %v", err)
+	}
+
+	postsTable := findTable(schema.Tables, "posts")
+	if postsTable == nil {
+		t.Fatal("posts table not found")
+	}
+
+	if !postsTable.RowSecurity {
+		t.Error("posts table should have row level security enabled")
+	}
+	if postsTable.ReplicaIdentity != "FULL" {
+		t.Errorf("posts ReplicaIdentity = %q, want %q", postsTable.ReplicaIdentity, "FULL")
+	}
+	if len(postsTable.StorageParams) != 1 || postsTable.StorageParams[0] != "fillfactor=70" {
+		t.Errorf("posts StorageParams = %v, want [fillfactor=70]", postsTable.StorageParams)
+	}
+}
+
+func TestDatabase_Dependencies_Integration(t *testing.T) {
+	if testing.Short() {
+		t.Skip("skipping integration test")
+	}
+
+	ctx, cancel := context.WithTimeout(context.Background(), 120*time.Second)
+	defer cancel()
+
+	cfg := docker.DefaultPostgresConfig()
+	container, err := docker.StartPostgres(ctx, cfg)
+	if err != nil {
+		t.Fatalf("StartPostgres() error = %v", err)
+	}
+
+	defer func() {
+		_ = docker.StopContainer(context.Background(), container.ID)
+	}()
+
+	schemaSQL := `
+		CREATE TABLE posts (
+			id SERIAL PRIMARY KEY,
+			title TEXT NOT NULL,
+			published BOOLEAN NOT NULL DEFAULT FALSE
+		);
+
+		CREATE VIEW published_posts AS
+		SELECT id, title FROM posts WHERE published = TRUE;
+	`
+
+	if err := docker.ExecuteSQL(ctx, container, schemaSQL); err != nil {
+		t.Fatalf("ExecuteSQL() error = %v", err)
+	}
+
+	schema, err := Database(ctx, container.ConnectionString())
+	if err != nil {
+		t.Fatalf("Database() error = %v", err)
+	}
+
+	foundViewDep := false
+	for _, dep := range schema.Dependencies {
+		if dep.Kind == "view" && dep.Name == "published_posts" &&
+			dep.RefKind == "table" && dep.RefName == "posts" && dep.RefColumn == "title" {
+			foundViewDep = true
+		}
+	}
+	if !foundViewDep {
+		t.Error("expected dependency of published_posts on posts.title")
+	}
+}
+
 func TestDatabase_EmptySchema(t *testing.T) {
 	if testing.Short() {
 		t.Skip("skipping integration test")
@@ -0,0 +1,212 @@
+package migrate
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"regexp"
+	"strings"
+
+	"github.com/jackc/pgx/v5"
+	"github.com/jackc/pgx/v5/pgconn"
+)
+
+const NoTransactionDirective = "-- shrugged:no-transaction"
+
+var noTransactionRegex = regexp.MustCompile(`(?m)^\s*--\s*shrugged:no-transaction\s*$`)
+
+const HazardDirective = "-- shrugged:hazard"
+
+var hazardRegex = regexp.MustCompile(`(?m)^\s*--\s*shrugged:hazard\s+([a-z-]+)`)
+
+type StatementProgress func(index, total int, statement string)
+
+// When Postgres gives an error position, Line and Column point at it rather
+// than at the start of the statement.
+type StatementError struct {
+	File      string
+	Line      int
+	Column    int
+	Statement string
+	Err       error
+}
+
+func (e *StatementError) Error() string {
+	var sb strings.Builder
+
+	var pgErr *pgconn.PgError
+	if errors.As(e.Err, &pgErr) {
+		sb.WriteString(fmt.Sprintf("%s:%d:%d: %s: %s (SQLSTATE %s)", e.File, e.Line, e.Column, pgErr.Severity, pgErr.Message, pgErr.Code))
+		if pgErr.Detail != "" {
+			sb.WriteString("\nDETAIL: " + pgErr.Detail)
+		}
+		if pgErr.Hint != "" {
+			sb.WriteString("\nHINT: " + pgErr.Hint)
+		}
+	} else {
+		sb.WriteString(fmt.Sprintf("%s:%d:%d: %v", e.File, e.Line, e.Column, e.Err))
+	}
+
+	sb.WriteString("\nSTATEMENT:")
+	for _, line := range strings.Split(e.Statement, "\n") {
+		sb.WriteString("\n    " + line)
+	}
+	return sb.String()
+}
+
+func (e *StatementError) Unwrap() error {
+	return e.Err
+}
+
+type execer interface {
+	Exec(ctx context.Context, sql string, arguments ...any) (pgconn.CommandTag, error)
+}
+
+func IsNoTransaction(content string) bool {
+	return noTransactionRegex.MatchString(content)
+}
+
+func Hazards(content string) []string {
+	var kinds []string
+	seen := make(map[string]bool)
+	for _, m := range hazardRegex.FindAllStringSubmatch(content, -1) {
+		if !seen[m[1]] {
+			seen[m[1]] = true
+			kinds = append(kinds, m[1])
+		}
+	}
+	return kinds
+}
+
+// Execute runs a migration without recording it in the history table.
+func Execute(ctx context.Context, conn *pgx.Conn, file, content string) error {
+	if IsNoTransaction(content) {
+		for _, stmt := range SplitStatements(content) {
+			if err := execStatement(ctx, conn, file, content, stmt); err != nil {
+				return err
+			}
+		}
+		return nil
+	}
+
+	return runInTransaction(ctx, conn, file, content, Timeouts{}, func(pgx.Tx) error { return nil })
+}
+
+func execStatement(ctx context.Context, exec execer, file, content string, stmt Statement) error {
+	_, err := exec.Exec(ctx, stmt.SQL)
+	if err == nil {
+		return nil
+	}
+
+	line, col := stmt.Line, stmt.Column
+	var pgErr *pgconn.PgError
+	if errors.As(err, &pgErr) && pgErr.Position > 0 {
+		runes := []rune(stmt.SQL)
+		if int(pgErr.Position) <= len(runes) {
+			line, col = position(content, stmt.Offset+len(string(runes[:pgErr.Position-1])))
+		}
+	}
+
+	return &StatementError{File: file, Line: line, Column: col, Statement: stmt.SQL, Err: err}
+}
+
+func execStatementWithTimeouts(ctx context.Context, conn *pgx.Conn, file, content string, stmt Statement, t Timeouts) error {
+	if err := setTimeouts(ctx, conn, t, false); err != nil {
+		return err
+	}
+	err := execStatement(ctx, conn, file, content, stmt)
+	if resetErr := resetTimeouts(ctx, conn, t, false); err == nil {
+		err = resetErr
+	}
+	return err
+}
+
+func runInTransaction(ctx context.Context, conn *pgx.Conn, file, content string, t Timeouts, finish func(tx pgx.Tx) error) error {
+	tx, err := conn.Begin(ctx)
+	if err != nil {
+		return fmt.Errorf("failed to begin transaction: %w", err)
+	}
+	defer func() { _ = tx.Rollback(ctx) }()
+
+	if err := setTimeouts(ctx, tx, t, true); err != nil {
+		return err
+	}
+	for _, stmt := range SplitStatements(content) {
+		if err := execStatement(ctx, tx, file, content, stmt); err != nil {
+			return err
+		}
+	}
+	if err := resetTimeouts(ctx, tx, t, true); err != nil {
+		return err
+	}
+
+	if err := finish(tx); err != nil {
+		return err
+	}
+
+	return tx.Commit(ctx)
+}
+
+// runStatements records progress after each statement so a failed run resumes
+// where it stopped.
+func runStatements(ctx context.Context, conn *pgx.Conn, key, file, content string, t Timeouts, progress StatementProgress, finish func(tx pgx.Tx) error) error {
+	checksum := ComputeChecksum(content)
+	statements := SplitStatements(content)
+
+	done, err := loadProgress(ctx, conn, key, checksum)
+	if err != nil {
+		return err
+	}
+	if done > len(statements) {
+		done = len(statements)
+	}
+
+	for i := done; i < len(statements); i++ {
+		if progress != nil {
+			progress(i+1, len(statements), statements[i].SQL)
+		}
+		if err := execStatementWithTimeouts(ctx, conn, file, content, statements[i], t); err != nil {
+			return fmt.Errorf("statement %d of %d failed (rerun to resume from it): %w", i+1, len(statements), err)
+		}
+		if _, err := conn.Exec(ctx, fmt.Sprintf(`
+			INSERT INTO %s (name, checksum, statements_done, updated_at) VALUES ($1, $2, $3, NOW())
+			ON CONFLICT (name) DO UPDATE SET checksum = $2, statements_done = $3, updated_at = NOW()
+		`, progressTable()), key, checksum, i+1); err != nil {
+			return fmt.Errorf("failed to record progress: %w", err)
+		}
+	}
+
+	tx, err := conn.Begin(ctx)
+	if err != nil {
+		return fmt.Errorf("failed to begin transaction: %w", err)
+	}
+	defer func() { _ = tx.Rollback(ctx) }()
+
+	if err := finish(tx); err != nil {
+		return err
+	}
+	if _, err := tx.Exec(ctx, fmt.Sprintf(`DELETE FROM %s WHERE name = $1`, progressTable()), key); err != nil {
+		return fmt.Errorf("failed to clear progress: %w", err)
+	}
+
+	return tx.Commit(ctx)
+}
+
+func loadProgress(ctx context.Context, conn *pgx.Conn, key, checksum string) (int, error) {
+	var done int
+	var recorded string
+	err := conn.QueryRow(ctx, fmt.Sprintf(`
+		SELECT statements_done, checksum FROM %s WHERE name = $1
+	`, progressTable()), key).Scan(&done, &recorded)
+	if err == pgx.ErrNoRows {
+		return 0, nil
+	}
+	if err != nil {
+		return 0, fmt.Errorf("failed to read progress: %w", err)
+	}
+	if recorded != checksum {
+		return 0, fmt.Errorf("%s was partially run (%d statement(s)) and has changed since; "+
+			"finish or undo those statements by hand and delete its row from %s", key, done, progressTable())
+	}
+	return done, nil
+}
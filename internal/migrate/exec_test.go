@@ -0,0 +1,243 @@
+package migrate
+
+import (
+	"context"
+	"errors"
+	"reflect"
+	"testing"
+	"time"
+
+	"github.com/jackc/pgx/v5/pgconn"
+
+	"github.com/terminally-online/shrugged/internal/docker"
+)
+
+func TestSplitStatements(t *testing.T) {
+	tests := []struct {
+		name string
+		sql  string
+		want []string
+	}{
+		{
+			name: "simple",
+			sql:  "CREATE TABLE a (id int);\nCREATE TABLE b (id int);\n",
+			want: []string{"CREATE TABLE a (id int)", "CREATE TABLE b (id int)"},
+		},
+		{
+			name: "semicolons in strings and identifiers",
+			sql:  `INSERT INTO "x;y" VALUES ('a;b', E'c\';d');SELECT 1`,
+			want: []string{`INSERT INTO "x;y" VALUES ('a;b', E'c\';d')`, "SELECT 1"},
+		},
+		{
+			name: "dollar quoted body",
+			sql:  "CREATE FUNCTION f() RETURNS void AS $fn$ BEGIN PERFORM 1; END; $fn$ LANGUAGE plpgsql;\nSELECT $1;",
+			want: []string{"CREATE FUNCTION f() RETURNS void AS $fn$ BEGIN PERFORM 1; END; $fn$ LANGUAGE plpgsql", "SELECT $1"},
+		},
+		{
+			name: "comments",
+			sql:  "-- shrugged:no-transaction\n\n/* one; /* nested; */ */\nCREATE INDEX CONCURRENTLY i ON t (c); -- trailing;\n-- only a comment;\n",
+			want: []string{"-- shrugged:no-transaction\n\n/* one; /* nested; */ */\nCREATE INDEX CONCURRENTLY i ON t (c)"},
+		},
+		{
+			name: "begin atomic body",
+			sql:  "CREATE FUNCTION f() RETURNS int LANGUAGE sql BEGIN ATOMIC SELECT CASE WHEN true THEN 1 END; SELECT 2; END;\nSELECT 3;",
+			want: []string{"CREATE FUNCTION f() RETURNS int LANGUAGE sql BEGIN ATOMIC SELECT CASE WHEN true THEN 1 END; SELECT 2; END", "SELECT 3"},
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			var got []string
+			for _, stmt := range SplitStatements(tt.sql) {
+				got = append(got, stmt.SQL)
+			}
+			if !reflect.DeepEqual(got, tt.want) {
+				t.Errorf("SplitStatements() = %q, want %q", got, tt.want)
+			}
+		})
+	}
+}
+
+func TestSplitStatements_Positions(t *testing.T) {
+	sql := "-- header\nCREATE TABLE a (id int);\n\n  INSERT INTO a VALUES ('é');  SELECT 1;"
+
+	statements := SplitStatements(sql)
+	if len(statements) != 3 {
+		t.Fatalf("expected 3 statements, got %d", len(statements))
+	}
+
+	want := [][2]int{{1, 1}, {4, 3}, {4, 32}}
+	for i, stmt := range statements {
+		if stmt.Line != want[i][0] || stmt.Column != want[i][1] {
+			t.Errorf("statement %d at %d:%d, want %d:%d", i+1, stmt.Line, stmt.Column, want[i][0], want[i][1])
+		}
+		if sql[stmt.Offset:stmt.Offset+len(stmt.SQL)] != stmt.SQL {
+			t.Errorf("statement %d offset %d does not match its SQL", i+1, stmt.Offset)
+		}
+	}
+}
+
+func TestStatementError_Error(t *testing.T) {
+	err := &StatementError{
+		File:      "migrations/001.sql",
+		Line:      3,
+		Column:    15,
+		Statement: "ALTER TABLE users\n    ADD COLUMN id int",
+		Err: &pgconn.PgError{
+			Severity: "ERROR",
+			Code:     "42701",
+			Message:  `column "id" of relation "users" already exists`,
+			Detail:   "some detail",
+			Hint:     "some hint",
+		},
+	}
+
+	want := "migrations/001.sql:3:15: ERROR: column \"id\" of relation \"users\" already exists (SQLSTATE 42701)\n" +
+		"DETAIL: some detail\n" +
+		"HINT: some hint\n" +
+		"STATEMENT:\n" +
+		"    ALTER TABLE users\n" +
+		"        ADD COLUMN id int"
+	if got := err.Error(); got != want {
+		t.Errorf("Error() =\n%s\nwant\n%s", got, want)
+	}
+}
+
+func TestApply_ErrorLocation_Integration(t *testing.T) {
+	if testing.Short() {
+		t.Skip("skipping integration test")
+	}
+
+	ctx, cancel := context.WithTimeout(context.Background(), 60*time.Second)
+	defer cancel()
+
+	cfg := docker.DefaultPostgresConfig()
+	container, err := docker.StartPostgres(ctx, cfg)
+	if err != nil {
+		t.Fatalf("StartPostgres() error = %v", err)
+	}
+	defer func() { _ = docker.StopContainer(context.Background(), container.ID) }()
+
+	conn, err := Connect(ctx, container.ConnectionString())
+	if err != nil {
+		t.Fatalf("Connect() error = %v", err)
+	}
+	defer func() { _ = conn.Close(context.Background()) }()
+
+	migration := Migration{
+		Name:    "001_users.sql",
+		Path:    "migrations/001_users.sql",
+		Content: "CREATE TABLE users (id int);\n\nSELECT missing_column\nFROM users;\n",
+	}
+
+	err = Apply(ctx, conn, migration)
+	var stmtErr *StatementError
+	if !errors.As(err, &stmtErr) {
+		t.Fatalf("Apply() error = %v, want a StatementError", err)
+	}
+	if stmtErr.File != migration.Path || stmtErr.Line != 3 || stmtErr.Column != 8 {
+		t.Errorf("error at %s:%d:%d, want %s:3:8", stmtErr.File, stmtErr.Line, stmtErr.Column, migration.Path)
+	}
+
+	applied, err := GetApplied(ctx, conn)
+	if err != nil {
+		t.Fatalf("GetApplied() error = %v", err)
+	}
+	if len(applied) != 0 {
+		t.Errorf("expected failed migration to be rolled back, got %d applied", len(applied))
+	}
+}
+
+func TestIsNoTransaction(t *testing.T) {
+	if !IsNoTransaction("-- shrugged:no-transaction\nCREATE INDEX CONCURRENTLY i ON t (c);") {
+		t.Error("expected directive to be detected")
+	}
+	if IsNoTransaction("CREATE INDEX i ON t (c); -- shrugged:no-transaction is not on its own line") {
+		t.Error("directive must be on its own line")
+	}
+}
+
+func TestHazards(t *testing.T) {
+	content := `-- shrugged:hazard table-rewrite: changing the type of total rewrites orders
+-- shrugged:hazard access-exclusive: orders is locked against reads and writes during the rewrite
+ALTER TABLE orders ALTER COLUMN total TYPE numeric(12,2);
+
+-- shrugged:hazard table-rewrite: changing the type of note rewrites orders
+ALTER TABLE orders ALTER COLUMN note TYPE varchar(40);
+
+SELECT '-- shrugged:hazard data-loss';
+`
+	want := []string{"table-rewrite", "access-exclusive"}
+	if got := Hazards(content); !reflect.DeepEqual(got, want) {
+		t.Errorf("Hazards() = %v, want %v", got, want)
+	}
+	if got := Hazards("CREATE TABLE t (id int);"); got != nil {
+		t.Errorf("Hazards() = %v, want none", got)
+	}
+}
+
+func TestApplyNoTransaction_Resume_Integration(t *testing.T) {
+	if testing.Short() {
+		t.Skip("skipping integration test")
+	}
+
+	ctx, cancel := context.WithTimeout(context.Background(), 60*time.Second)
+	defer cancel()
+
+	cfg := docker.DefaultPostgresConfig()
+	container, err := docker.StartPostgres(ctx, cfg)
+	if err != nil {
+		t.Fatalf("StartPostgres() error = %v", err)
+	}
+	defer func() { _ = docker.StopContainer(context.Background(), container.ID) }()
+
+	conn, err := Connect(ctx, container.ConnectionString())
+	if err != nil {
+		t.Fatalf("Connect() error = %v", err)
+	}
+	defer func() { _ = conn.Close(context.Background()) }()
+
+	migration := Migration{
+		Name: "001_indexes.sql",
+		Content: NoTransactionDirective + "\n\n" +
+			"CREATE TABLE users (id int);\n\n" +
+			"CREATE INDEX CONCURRENTLY idx_posts_id ON posts (id);\n",
+	}
+
+	if err := Apply(ctx, conn, migration); err == nil {
+		t.Fatal("expected Apply() to fail while posts does not exist")
+	}
+
+	applied, err := GetApplied(ctx, conn)
+	if err != nil {
+		t.Fatalf("GetApplied() error = %v", err)
+	}
+	if len(applied) != 0 {
+		t.Fatalf("expected failed migration not to be recorded, got %d", len(applied))
+	}
+
+	if _, err := conn.Exec(ctx, "CREATE TABLE posts (id int)"); err != nil {
+		t.Fatalf("failed to create posts: %v", err)
+	}
+
+	var resumedAt int
+	progress := func(index, total int, statement string) {
+		if resumedAt == 0 {
+			resumedAt = index
+		}
+	}
+	if err := ApplyWithProgress(ctx, conn, migration, progress); err != nil {
+		t.Fatalf("ApplyWithProgress() resume error = %v", err)
+	}
+	if resumedAt != 2 {
+		t.Errorf("resumed at statement %d, want 2", resumedAt)
+	}
+
+	applied, err = GetApplied(ctx, conn)
+	if err != nil {
+		t.Fatalf("GetApplied() error = %v", err)
+	}
+	if len(applied) != 1 {
+		t.Errorf("expected 1 applied migration, got %d", len(applied))
+	}
+}
@@ -0,0 +1,258 @@
+package migrate
+
+import (
+	"context"
+	"fmt"
+	"os"
+	"strings"
+	"time"
+
+	"github.com/jackc/pgx/v5"
+)
+
+const DefaultHistoryTable = "shrugged_migrations"
+
+const (
+	ActionApply    = "apply"
+	ActionRollback = "rollback"
+	ActionBaseline = "baseline"
+)
+
+// The progress table for non-transactional migrations is named after the
+// history table, with a _progress suffix.
+type History struct {
+	Schema  string
+	Table   string
+	Version string
+	Label   string
+}
+
+var history = History{Table: DefaultHistoryTable}
+
+func SetHistory(h History) {
+	if h.Table == "" {
+		h.Table = DefaultHistoryTable
+	}
+	history = h
+}
+
+type HistoryEntry struct {
+	ID       int64
+	Name     string
+	Action   string
+	At       time.Time
+	Checksum string
+	Duration time.Duration
+	Version  string
+	User     string
+	Hostname string
+	Label    string
+}
+
+// IsHistoryRelation lets introspection of a live database leave out the
+// history and progress tables.
+func IsHistoryRelation(schema, name string) bool {
+	if history.Schema != "" && schema != history.Schema {
+		return false
+	}
+	switch name {
+	case history.Table, history.Table + "_id_seq", history.Table + "_progress":
+		return true
+	}
+	return false
+}
+
+func historyTable() string {
+	return qualifiedName(history.Schema, history.Table)
+}
+
+func progressTable() string {
+	return qualifiedName(history.Schema, history.Table+"_progress")
+}
+
+func qualifiedName(schema, name string) string {
+	if schema == "" {
+		return pgx.Identifier{name}.Sanitize()
+	}
+	return pgx.Identifier{schema, name}.Sanitize()
+}
+
+func EnsureMigrationsTable(ctx context.Context, conn *pgx.Conn) error {
+	if history.Schema != "" {
+		if _, err := conn.Exec(ctx, fmt.Sprintf(`
+			CREATE SCHEMA IF NOT EXISTS %s
+		`, pgx.Identifier{history.Schema}.Sanitize())); err != nil {
+			return err
+		}
+	}
+
+	_, err := conn.Exec(ctx, fmt.Sprintf(`
+		CREATE TABLE IF NOT EXISTS %s (
+			id BIGSERIAL PRIMARY KEY,
+			name TEXT NOT NULL,
+			action TEXT NOT NULL DEFAULT 'apply',
+			applied_at TIMESTAMPTZ NOT NULL DEFAULT NOW(),
+			checksum TEXT,
+			duration_ms BIGINT,
+			shrugged_version TEXT,
+			db_user TEXT,
+			hostname TEXT,
+			label TEXT
+		)
+	`, historyTable()))
+	if err != nil {
+		return err
+	}
+
+	if err := upgradeHistoryTable(ctx, conn); err != nil {
+		return err
+	}
+
+	_, err = conn.Exec(ctx, fmt.Sprintf(`
+		CREATE TABLE IF NOT EXISTS %s (
+			name TEXT PRIMARY KEY,
+			checksum TEXT NOT NULL,
+			statements_done INTEGER NOT NULL DEFAULT 0,
+			updated_at TIMESTAMPTZ NOT NULL DEFAULT NOW()
+		)
+	`, progressTable()))
+	return err
+}
+
+// hasHistory lets commands that only read the history, like status, run
+// without creating the history table.
+func hasHistory(ctx context.Context, conn *pgx.Conn) (bool, error) {
+	var exists bool
+	if err := conn.QueryRow(ctx, `SELECT to_regclass($1) IS NOT NULL`, historyTable()).Scan(&exists); err != nil {
+		return false, fmt.Errorf("failed to check for history table: %w", err)
+	}
+	if !exists {
+		return false, nil
+	}
+	return true, upgradeHistoryTable(ctx, conn)
+}
+
+// The first history table only had name and applied_at.
+var historyColumns = []struct {
+	name       string
+	definition string
+}{
+	{"checksum", "TEXT"},
+	{"action", "TEXT NOT NULL DEFAULT 'apply'"},
+	{"duration_ms", "BIGINT"},
+	{"shrugged_version", "TEXT"},
+	{"db_user", "TEXT"},
+	{"hostname", "TEXT"},
+	{"label", "TEXT"},
+}
+
+// upgradeHistoryTable moves an old history table, with one row per applied
+// migration, to one row per apply or rollback. It only reads tables that are
+// already up to date, so read-only roles can check the history.
+func upgradeHistoryTable(ctx context.Context, conn *pgx.Conn) error {
+	table := historyTable()
+
+	rows, err := conn.Query(ctx, `
+		SELECT attname FROM pg_attribute
+		WHERE attrelid = $1::regclass AND attnum > 0 AND NOT attisdropped
+	`, table)
+	if err != nil {
+		return err
+	}
+	existing := make(map[string]bool)
+	for rows.Next() {
+		var name string
+		if err := rows.Scan(&name); err != nil {
+			rows.Close()
+			return err
+		}
+		existing[name] = true
+	}
+	rows.Close()
+	if err := rows.Err(); err != nil {
+		return err
+	}
+
+	var add []string
+	for _, c := range historyColumns {
+		if !existing[c.name] {
+			add = append(add, fmt.Sprintf("ADD COLUMN %s %s", c.name, c.definition))
+		}
+	}
+	if len(add) > 0 {
+		if _, err := conn.Exec(ctx, fmt.Sprintf(`ALTER TABLE %s %s`, table, strings.Join(add, ", "))); err != nil {
+			return err
+		}
+	}
+
+	if existing["id"] {
+		return nil
+	}
+
+	tx, err := conn.Begin(ctx)
+	if err != nil {
+		return err
+	}
+	defer func() { _ = tx.Rollback(ctx) }()
+
+	var pkey string
+	if err := tx.QueryRow(ctx, `
+		SELECT conname FROM pg_constraint WHERE conrelid = $1::regclass AND contype = 'p'
+	`, table).Scan(&pkey); err != nil && err != pgx.ErrNoRows {
+		return err
+	}
+
+	if _, err := tx.Exec(ctx, fmt.Sprintf(`ALTER TABLE %s ADD COLUMN id BIGSERIAL`, table)); err != nil {
+		return err
+	}
+	if pkey != "" {
+		if _, err := tx.Exec(ctx, fmt.Sprintf(`ALTER TABLE %s DROP CONSTRAINT %s`, table, pgx.Identifier{pkey}.Sanitize())); err != nil {
+			return err
+		}
+	}
+	if _, err := tx.Exec(ctx, fmt.Sprintf(`ALTER TABLE %s ADD PRIMARY KEY (id)`, table)); err != nil {
+		return err
+	}
+
+	return tx.Commit(ctx)
+}
+
+func GetHistory(ctx context.Context, conn *pgx.Conn) ([]HistoryEntry, error) {
+	if exists, err := hasHistory(ctx, conn); err != nil || !exists {
+		return nil, err
+	}
+
+	rows, err := conn.Query(ctx, fmt.Sprintf(`
+		SELECT id, name, action, applied_at, COALESCE(checksum, ''), COALESCE(duration_ms, 0),
+			COALESCE(shrugged_version, ''), COALESCE(db_user, ''), COALESCE(hostname, ''), COALESCE(label, '')
+		FROM %s
+		ORDER BY id
+	`, historyTable()))
+	if err != nil {
+		return nil, fmt.Errorf("failed to query migration history: %w", err)
+	}
+	defer rows.Close()
+
+	var entries []HistoryEntry
+	for rows.Next() {
+		var e HistoryEntry
+		var durationMS int64
+		if err := rows.Scan(&e.ID, &e.Name, &e.Action, &e.At, &e.Checksum, &durationMS,
+			&e.Version, &e.User, &e.Hostname, &e.Label); err != nil {
+			return nil, fmt.Errorf("failed to scan migration history: %w", err)
+		}
+		e.Duration = time.Duration(durationMS) * time.Millisecond
+		entries = append(entries, e)
+	}
+
+	return entries, rows.Err()
+}
+
+func recordHistory(ctx context.Context, tx pgx.Tx, name, action, checksum string, duration time.Duration) error {
+	hostname, _ := os.Hostname()
+	_, err := tx.Exec(ctx, fmt.Sprintf(`
+		INSERT INTO %s (name, action, checksum, duration_ms, shrugged_version, db_user, hostname, label)
+		VALUES ($1, $2, $3, $4, NULLIF($5, ''), current_user, NULLIF($6, ''), NULLIF($7, ''))
+	`, historyTable()), name, action, checksum, duration.Milliseconds(), history.Version, hostname, history.Label)
+	return err
+}
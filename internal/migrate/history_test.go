@@ -0,0 +1,192 @@
+package migrate
+
+import (
+	"context"
+	"testing"
+	"time"
+
+	"github.com/terminally-online/shrugged/internal/docker"
+)
+
+func TestQualifiedName(t *testing.T) {
+	tests := []struct {
+		schema string
+		name   string
+		want   string
+	}{
+		{"", "shrugged_migrations", `"shrugged_migrations"`},
+		{"ops", "schema_history", `"ops"."schema_history"`},
+		{"Ops", `odd"name`, `"Ops"."odd""name"`},
+	}
+
+	for _, tt := range tests {
+		if got := qualifiedName(tt.schema, tt.name); got != tt.want {
+			t.Errorf("qualifiedName(%q, %q) = %s, want %s", tt.schema, tt.name, got, tt.want)
+		}
+	}
+}
+
+func TestSetHistory_DefaultTable(t *testing.T) {
+	defer SetHistory(History{})
+
+	SetHistory(History{Schema: "ops"})
+	if got := historyTable(); got != `"ops"."shrugged_migrations"` {
+		t.Errorf("historyTable() = %s, want ops.shrugged_migrations", got)
+	}
+	if got := progressTable(); got != `"ops"."shrugged_migrations_progress"` {
+		t.Errorf("progressTable() = %s, want ops.shrugged_migrations_progress", got)
+	}
+}
+
+func TestIsHistoryRelation(t *testing.T) {
+	defer SetHistory(History{})
+
+	SetHistory(History{})
+	for _, name := range []string{"shrugged_migrations", "shrugged_migrations_id_seq", "shrugged_migrations_progress"} {
+		if !IsHistoryRelation("public", name) {
+			t.Errorf("IsHistoryRelation(public, %s) = false, want true", name)
+		}
+	}
+	if IsHistoryRelation("public", "users") {
+		t.Error("IsHistoryRelation(public, users) = true, want false")
+	}
+
+	SetHistory(History{Schema: "ops", Table: "schema_history"})
+	if !IsHistoryRelation("ops", "schema_history") {
+		t.Error("IsHistoryRelation(ops, schema_history) = false, want true")
+	}
+	if IsHistoryRelation("public", "schema_history") {
+		t.Error("IsHistoryRelation(public, schema_history) = true, want false")
+	}
+}
+
+func TestHistory_Integration(t *testing.T) {
+	if testing.Short() {
+		t.Skip("skipping integration test")
+	}
+
+	ctx, cancel := context.WithTimeout(context.Background(), 60*time.Second)
+	defer cancel()
+
+	cfg := docker.DefaultPostgresConfig()
+	container, err := docker.StartPostgres(ctx, cfg)
+	if err != nil {
+		t.Fatalf("StartPostgres() error = %v", err)
+	}
+	defer func() { _ = docker.StopContainer(context.Background(), container.ID) }()
+
+	conn, err := Connect(ctx, container.ConnectionString())
+	if err != nil {
+		t.Fatalf("Connect() error = %v", err)
+	}
+	defer func() { _ = conn.Close(context.Background()) }()
+
+	SetHistory(History{Schema: "ops", Table: "schema_history", Version: "v1.2.3", Label: "deploy-42"})
+	defer SetHistory(History{})
+
+	up := Migration{Name: "001_create_users.sql", Content: "CREATE TABLE users (id INT);"}
+	down := Migration{Name: "001_create_users.sql", Content: "DROP TABLE users;"}
+
+	for _, step := range []func() error{
+		func() error { return Apply(ctx, conn, up) },
+		func() error { return Rollback(ctx, conn, down) },
+		func() error { return Apply(ctx, conn, up) },
+	} {
+		if err := step(); err != nil {
+			t.Fatalf("step error = %v", err)
+		}
+	}
+
+	applied, err := GetApplied(ctx, conn)
+	if err != nil {
+		t.Fatalf("GetApplied() error = %v", err)
+	}
+	if len(applied) != 1 || applied[0].Name != up.Name {
+		t.Fatalf("GetApplied() = %v, want %s", applied, up.Name)
+	}
+
+	entries, err := GetHistory(ctx, conn)
+	if err != nil {
+		t.Fatalf("GetHistory() error = %v", err)
+	}
+	if len(entries) != 3 {
+		t.Fatalf("expected 3 history entries, got %d", len(entries))
+	}
+
+	wantActions := []string{ActionApply, ActionRollback, ActionApply}
+	for i, e := range entries {
+		if e.Action != wantActions[i] {
+			t.Errorf("entry %d action = %q, want %q", i, e.Action, wantActions[i])
+		}
+		if e.Version != "v1.2.3" || e.Label != "deploy-42" {
+			t.Errorf("entry %d version/label = %q/%q, want v1.2.3/deploy-42", i, e.Version, e.Label)
+		}
+		if e.User != "postgres" {
+			t.Errorf("entry %d user = %q, want postgres", i, e.User)
+		}
+	}
+
+	var exists bool
+	if err := conn.QueryRow(ctx, `SELECT to_regclass('ops.schema_history_progress') IS NOT NULL`).Scan(&exists); err != nil {
+		t.Fatalf("failed to check progress table: %v", err)
+	}
+	if !exists {
+		t.Error("progress table should be named after the history table")
+	}
+}
+
+func TestHistory_UpgradesOldTable_Integration(t *testing.T) {
+	if testing.Short() {
+		t.Skip("skipping integration test")
+	}
+
+	ctx, cancel := context.WithTimeout(context.Background(), 60*time.Second)
+	defer cancel()
+
+	cfg := docker.DefaultPostgresConfig()
+	container, err := docker.StartPostgres(ctx, cfg)
+	if err != nil {
+		t.Fatalf("StartPostgres() error = %v", err)
+	}
+	defer func() { _ = docker.StopContainer(context.Background(), container.ID) }()
+
+	conn, err := Connect(ctx, container.ConnectionString())
+	if err != nil {
+		t.Fatalf("Connect() error = %v", err)
+	}
+	defer func() { _ = conn.Close(context.Background()) }()
+
+	if _, err := conn.Exec(ctx, `
+		CREATE TABLE shrugged_migrations (
+			name TEXT PRIMARY KEY,
+			applied_at TIMESTAMPTZ NOT NULL DEFAULT NOW(),
+			checksum TEXT
+		);
+		INSERT INTO shrugged_migrations (name, checksum) VALUES ('001_first.sql', 'abc'), ('002_second.sql', 'def');
+	`); err != nil {
+		t.Fatalf("failed to create old history table: %v", err)
+	}
+
+	if err := Rollback(ctx, conn, Migration{Name: "002_second.sql", Content: "SELECT 1;"}); err != nil {
+		t.Fatalf("Rollback() error = %v", err)
+	}
+
+	applied, err := GetApplied(ctx, conn)
+	if err != nil {
+		t.Fatalf("GetApplied() error = %v", err)
+	}
+	if len(applied) != 1 || applied[0].Name != "001_first.sql" || applied[0].Checksum != "abc" {
+		t.Errorf("GetApplied() = %v, want 001_first.sql", applied)
+	}
+
+	entries, err := GetHistory(ctx, conn)
+	if err != nil {
+		t.Fatalf("GetHistory() error = %v", err)
+	}
+	if len(entries) != 3 {
+		t.Fatalf("expected 3 history entries, got %d", len(entries))
+	}
+	if entries[2].Action != ActionRollback || entries[2].Name != "002_second.sql" {
+		t.Errorf("last entry = %+v, want rollback of 002_second.sql", entries[2])
+	}
+}
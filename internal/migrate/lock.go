@@ -0,0 +1,98 @@
+package migrate
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"time"
+
+	"github.com/jackc/pgx/v5"
+)
+
+// DefaultLockKey is the bytes of "shrugged" read as a big-endian integer.
+const DefaultLockKey int64 = 0x7368727567676564
+
+const lockPollInterval = 250 * time.Millisecond
+
+var ErrLockHeld = errors.New("migration lock is held by another process")
+
+type LockOptions struct {
+	Key     int64
+	Timeout time.Duration
+}
+
+func AcquireLock(ctx context.Context, conn *pgx.Conn, opts LockOptions) error {
+	key := opts.Key
+	if key == 0 {
+		key = DefaultLockKey
+	}
+
+	deadline := time.Now().Add(opts.Timeout)
+	for {
+		var acquired bool
+		if err := conn.QueryRow(ctx, "SELECT pg_try_advisory_lock($1)", key).Scan(&acquired); err != nil {
+			return fmt.Errorf("failed to acquire migration lock: %w", err)
+		}
+		if acquired {
+			return nil
+		}
+
+		if !time.Now().Before(deadline) {
+			if holder := lockHolder(ctx, conn, key); holder != "" {
+				return fmt.Errorf("%w (key %d, held by %s)", ErrLockHeld, key, holder)
+			}
+			return fmt.Errorf("%w (key %d)", ErrLockHeld, key)
+		}
+
+		select {
+		case <-ctx.Done():
+			return ctx.Err()
+		case <-time.After(lockPollInterval):
+		}
+	}
+}
+
+func ReleaseLock(ctx context.Context, conn *pgx.Conn, key int64) error {
+	if key == 0 {
+		key = DefaultLockKey
+	}
+	if _, err := conn.Exec(ctx, "SELECT pg_advisory_unlock($1)", key); err != nil {
+		return fmt.Errorf("failed to release migration lock: %w", err)
+	}
+	return nil
+}
+
+func lockHolder(ctx context.Context, conn *pgx.Conn, key int64) string {
+	var pid int
+	var user, app, addr string
+	var since *time.Time
+	err := conn.QueryRow(ctx, `
+		SELECT a.pid, COALESCE(a.usename, ''), COALESCE(a.application_name, ''),
+			COALESCE(host(a.client_addr), ''), a.backend_start
+		FROM pg_locks l
+		JOIN pg_stat_activity a ON a.pid = l.pid
+		WHERE l.locktype = 'advisory' AND l.granted
+		AND l.classid::bigint = (($1::bigint >> 32) & 4294967295)
+		AND l.objid::bigint = ($1::bigint & 4294967295)
+		AND l.objsubid = 1
+		LIMIT 1
+	`, key).Scan(&pid, &user, &app, &addr, &since)
+	if err != nil {
+		return ""
+	}
+
+	holder := fmt.Sprintf("pid %d", pid)
+	if user != "" {
+		holder += " user " + user
+	}
+	if addr != "" {
+		holder += " from " + addr
+	}
+	if app != "" {
+		holder += fmt.Sprintf(" (%s)", app)
+	}
+	if since != nil {
+		holder += " since " + since.Format("2006-01-02 15:04:05")
+	}
+	return holder
+}
@@ -0,0 +1,94 @@
+package migrate
+
+import (
+	"context"
+	"errors"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/terminally-online/shrugged/internal/docker"
+)
+
+func TestAcquireLock_Integration(t *testing.T) {
+	if testing.Short() {
+		t.Skip("skipping integration test")
+	}
+
+	ctx, cancel := context.WithTimeout(context.Background(), 60*time.Second)
+	defer cancel()
+
+	cfg := docker.DefaultPostgresConfig()
+	container, err := docker.StartPostgres(ctx, cfg)
+	if err != nil {
+		t.Fatalf("StartPostgres() error = %v", err)
+	}
+	defer func() { _ = docker.StopContainer(context.Background(), container.ID) }()
+
+	first, err := Connect(ctx, container.ConnectionString())
+	if err != nil {
+		t.Fatalf("Connect() error = %v", err)
+	}
+	defer func() { _ = first.Close(context.Background()) }()
+
+	second, err := Connect(ctx, container.ConnectionString())
+	if err != nil {
+		t.Fatalf("Connect() error = %v", err)
+	}
+	defer func() { _ = second.Close(context.Background()) }()
+
+	opts := LockOptions{Key: 42, Timeout: 500 * time.Millisecond}
+	if err := AcquireLock(ctx, first, opts); err != nil {
+		t.Fatalf("AcquireLock() error = %v", err)
+	}
+
+	err = AcquireLock(ctx, second, opts)
+	if !errors.Is(err, ErrLockHeld) {
+		t.Fatalf("AcquireLock() while held error = %v, want ErrLockHeld", err)
+	}
+
+	if err := ReleaseLock(ctx, first, opts.Key); err != nil {
+		t.Fatalf("ReleaseLock() error = %v", err)
+	}
+
+	if err := AcquireLock(ctx, second, opts); err != nil {
+		t.Fatalf("AcquireLock() after release error = %v", err)
+	}
+}
+
+func TestLockHolder_NegativeKey_Integration(t *testing.T) {
+	if testing.Short() {
+		t.Skip("skipping integration test")
+	}
+
+	ctx, cancel := context.WithTimeout(context.Background(), 60*time.Second)
+	defer cancel()
+
+	cfg := docker.DefaultPostgresConfig()
+	container, err := docker.StartPostgres(ctx, cfg)
+	if err != nil {
+		t.Fatalf("StartPostgres() error = %v", err)
+	}
+	defer func() { _ = docker.StopContainer(context.Background(), container.ID) }()
+
+	first, err := Connect(ctx, container.ConnectionString())
+	if err != nil {
+		t.Fatalf("Connect() error = %v", err)
+	}
+	defer func() { _ = first.Close(context.Background()) }()
+
+	second, err := Connect(ctx, container.ConnectionString())
+	if err != nil {
+		t.Fatalf("Connect() error = %v", err)
+	}
+	defer func() { _ = second.Close(context.Background()) }()
+
+	opts := LockOptions{Key: -42, Timeout: 500 * time.Millisecond}
+	if err := AcquireLock(ctx, first, opts); err != nil {
+		t.Fatalf("AcquireLock() error = %v", err)
+	}
+
+	if holder := lockHolder(ctx, second, opts.Key); !strings.HasPrefix(holder, "pid ") {
+		t.Errorf("lockHolder() = %q, want the holding backend", holder)
+	}
+}
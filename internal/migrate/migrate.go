@@ -16,53 +16,70 @@ import (
 
 type Migration struct {
 	Name      string
+	Path      string
 	Content   string
 	Checksum  string
 	AppliedAt time.Time
 	Modified  bool
+	// OutOfOrder marks a pending migration that sorts before the latest applied one.
+	OutOfOrder   bool
+	SquashedInto string
 }
 
-const migrationsTable = "shrugged_migrations"
+type OutOfOrderPolicy string
 
-func ComputeChecksum(content string) string {
-	hash := sha256.Sum256([]byte(content))
-	return hex.EncodeToString(hash[:])
+const (
+	OutOfOrderError OutOfOrderPolicy = "error"
+	OutOfOrderWarn  OutOfOrderPolicy = "warn"
+	OutOfOrderAllow OutOfOrderPolicy = "allow"
+)
+
+func ParseOutOfOrderPolicy(s string) (OutOfOrderPolicy, error) {
+	switch p := OutOfOrderPolicy(strings.ToLower(s)); p {
+	case OutOfOrderError, OutOfOrderWarn, OutOfOrderAllow:
+		return p, nil
+	}
+	return "", fmt.Errorf("invalid out-of-order policy %q (want error, warn or allow)", s)
 }
 
-func EnsureMigrationsTable(ctx context.Context, conn *pgx.Conn) error {
-	_, err := conn.Exec(ctx, fmt.Sprintf(`
-		CREATE TABLE IF NOT EXISTS %s (
-			name TEXT PRIMARY KEY,
-			applied_at TIMESTAMPTZ NOT NULL DEFAULT NOW(),
-			checksum TEXT
-		)
-	`, migrationsTable))
-	if err != nil {
-		return err
+// file names the migration's file in error messages.
+func (m Migration) file() string {
+	if m.Path != "" {
+		return m.Path
 	}
+	return m.Name
+}
 
-	_, err = conn.Exec(ctx, fmt.Sprintf(`
-		ALTER TABLE %s ADD COLUMN IF NOT EXISTS checksum TEXT
-	`, migrationsTable))
-	return err
+func ComputeChecksum(content string) string {
+	hash := sha256.Sum256([]byte(content))
+	return hex.EncodeToString(hash[:])
 }
 
-func GetApplied(ctx context.Context, databaseURL string) ([]Migration, error) {
+// Connect opens the single connection that a migration run uses for its lock,
+// its reads of the history table and every migration it applies.
+func Connect(ctx context.Context, databaseURL string) (*pgx.Conn, error) {
 	conn, err := pgx.Connect(ctx, databaseURL)
 	if err != nil {
 		return nil, fmt.Errorf("failed to connect to database: %w", err)
 	}
-	defer func() { _ = conn.Close(ctx) }()
+	return conn, nil
+}
 
-	if err := EnsureMigrationsTable(ctx, conn); err != nil {
+func GetApplied(ctx context.Context, conn *pgx.Conn) ([]Migration, error) {
+	if exists, err := hasHistory(ctx, conn); err != nil || !exists {
 		return nil, err
 	}
 
 	rows, err := conn.Query(ctx, fmt.Sprintf(`
 		SELECT name, applied_at, COALESCE(checksum, '')
-		FROM %s
+		FROM (
+			SELECT DISTINCT ON (name) name, action, applied_at, checksum
+			FROM %s
+			ORDER BY name, id DESC
+		) latest
+		WHERE action <> 'rollback'
 		ORDER BY name
-	`, migrationsTable))
+	`, historyTable()))
 	if err != nil {
 		return nil, fmt.Errorf("failed to query migrations: %w", err)
 	}
@@ -77,18 +94,23 @@ func GetApplied(ctx context.Context, databaseURL string) ([]Migration, error) {
 		migrations = append(migrations, m)
 	}
 
+	sortMigrations(migrations)
 	return migrations, nil
 }
 
-func GetPending(ctx context.Context, databaseURL, migrationsDir string) ([]Migration, error) {
-	applied, err := GetApplied(ctx, databaseURL)
+func GetPending(ctx context.Context, conn *pgx.Conn, migrationsDir string) ([]Migration, error) {
+	applied, err := GetApplied(ctx, conn)
 	if err != nil {
 		return nil, err
 	}
 
 	appliedMap := make(map[string]Migration)
+	var latest string
 	for _, m := range applied {
 		appliedMap[m.Name] = m
+		if latest == "" || CompareNames(m.Name, latest) > 0 {
+			latest = m.Name
+		}
 	}
 
 	entries, err := os.ReadDir(migrationsDir)
@@ -122,22 +144,42 @@ func GetPending(ctx context.Context, databaseURL, migrationsDir string) ([]Migra
 			return nil, fmt.Errorf("failed to read migration %s: %w", name, err)
 		}
 
+		if replaced := Squashes(string(content)); len(replaced) > 0 {
+			squashed, err := squashApplied(name, replaced, appliedMap)
+			if err != nil {
+				return nil, err
+			}
+			if squashed {
+				continue
+			}
+		}
+
 		pending = append(pending, Migration{
-			Name:     name,
-			Content:  string(content),
-			Checksum: ComputeChecksum(string(content)),
+			Name:       name,
+			Path:       filepath.Join(migrationsDir, name),
+			Content:    string(content),
+			Checksum:   ComputeChecksum(string(content)),
+			OutOfOrder: latest != "" && CompareNames(name, latest) < 0,
 		})
 	}
 
-	sort.Slice(pending, func(i, j int) bool {
-		return pending[i].Name < pending[j].Name
-	})
-
+	sortMigrations(pending)
 	return pending, nil
 }
 
-func GetAppliedWithStatus(ctx context.Context, databaseURL, migrationsDir string) ([]Migration, error) {
-	applied, err := GetApplied(ctx, databaseURL)
+func sortMigrations(migrations []Migration) {
+	sort.SliceStable(migrations, func(i, j int) bool {
+		return CompareNames(migrations[i].Name, migrations[j].Name) < 0
+	})
+}
+
+func GetAppliedWithStatus(ctx context.Context, conn *pgx.Conn, migrationsDir string) ([]Migration, error) {
+	applied, err := GetApplied(ctx, conn)
+	if err != nil {
+		return nil, err
+	}
+
+	squashes, err := loadSquashes(migrationsDir)
 	if err != nil {
 		return nil, err
 	}
@@ -147,7 +189,11 @@ func GetAppliedWithStatus(ctx context.Context, databaseURL, migrationsDir string
 		content, err := os.ReadFile(path)
 		if err != nil {
 			if os.IsNotExist(err) {
-				applied[i].Modified = true
+				if squashed, ok := squashes[m.Name]; ok {
+					applied[i].SquashedInto = squashed
+				} else {
+					applied[i].Modified = true
+				}
 				continue
 			}
 			return nil, fmt.Errorf("failed to read migration %s: %w", m.Name, err)
@@ -164,8 +210,8 @@ func GetAppliedWithStatus(ctx context.Context, databaseURL, migrationsDir string
 	return applied, nil
 }
 
-func HasModifiedMigrations(ctx context.Context, databaseURL, migrationsDir string) ([]Migration, error) {
-	applied, err := GetAppliedWithStatus(ctx, databaseURL, migrationsDir)
+func HasModifiedMigrations(ctx context.Context, conn *pgx.Conn, migrationsDir string) ([]Migration, error) {
+	applied, err := GetAppliedWithStatus(ctx, conn, migrationsDir)
 	if err != nil {
 		return nil, err
 	}
@@ -180,13 +226,40 @@ func HasModifiedMigrations(ctx context.Context, databaseURL, migrationsDir strin
 	return modified, nil
 }
 
-func Apply(ctx context.Context, databaseURL string, m Migration) error {
-	conn, err := pgx.Connect(ctx, databaseURL)
-	if err != nil {
-		return fmt.Errorf("failed to connect to database: %w", err)
+func Apply(ctx context.Context, conn *pgx.Conn, m Migration) error {
+	return ApplyWithProgress(ctx, conn, m, nil)
+}
+
+func ApplyWithProgress(ctx context.Context, conn *pgx.Conn, m Migration, progress StatementProgress) error {
+	if err := EnsureMigrationsTable(ctx, conn); err != nil {
+		return fmt.Errorf("failed to ensure migrations table: %w", err)
+	}
+
+	checksum := m.Checksum
+	if checksum == "" {
+		checksum = ComputeChecksum(m.Content)
 	}
-	defer func() { _ = conn.Close(ctx) }()
 
+	start := time.Now()
+	record := func(tx pgx.Tx) error {
+		return recordApplied(ctx, tx, m.Name, checksum, time.Since(start))
+	}
+	if IsNoTransaction(m.Content) {
+		return runStatements(ctx, conn, m.Name, m.file(), m.Content, timeouts, progress, record)
+	}
+	return runInTransaction(ctx, conn, m.file(), m.Content, timeouts, record)
+}
+
+func recordApplied(ctx context.Context, tx pgx.Tx, name, checksum string, duration time.Duration) error {
+	if err := recordHistory(ctx, tx, name, ActionApply, checksum, duration); err != nil {
+		return fmt.Errorf("failed to record migration: %w", err)
+	}
+	return nil
+}
+
+// Baseline records m as applied without running it, for a migration that
+// captures the schema a database already has.
+func Baseline(ctx context.Context, conn *pgx.Conn, m Migration) error {
 	if err := EnsureMigrationsTable(ctx, conn); err != nil {
 		return fmt.Errorf("failed to ensure migrations table: %w", err)
 	}
@@ -197,26 +270,15 @@ func Apply(ctx context.Context, databaseURL string, m Migration) error {
 	}
 	defer func() { _ = tx.Rollback(ctx) }()
 
-	if _, err := tx.Exec(ctx, m.Content); err != nil {
-		return fmt.Errorf("failed to execute migration: %w", err)
-	}
-
-	checksum := m.Checksum
-	if checksum == "" {
-		checksum = ComputeChecksum(m.Content)
-	}
-
-	if _, err := tx.Exec(ctx, fmt.Sprintf(`
-		INSERT INTO %s (name, checksum) VALUES ($1, $2)
-	`, migrationsTable), m.Name, checksum); err != nil {
-		return fmt.Errorf("failed to record migration: %w", err)
+	if err := recordHistory(ctx, tx, m.Name, ActionBaseline, ComputeChecksum(m.Content), 0); err != nil {
+		return fmt.Errorf("failed to record baseline: %w", err)
 	}
 
 	return tx.Commit(ctx)
 }
 
-func GetLastApplied(ctx context.Context, databaseURL string) (*Migration, error) {
-	applied, err := GetApplied(ctx, databaseURL)
+func GetLastApplied(ctx context.Context, conn *pgx.Conn) (*Migration, error) {
+	applied, err := GetApplied(ctx, conn)
 	if err != nil {
 		return nil, err
 	}
@@ -226,8 +288,8 @@ func GetLastApplied(ctx context.Context, databaseURL string) (*Migration, error)
 	return &applied[len(applied)-1], nil
 }
 
-func GetRollbackable(ctx context.Context, databaseURL, migrationsDir string, count int) ([]Migration, error) {
-	applied, err := GetApplied(ctx, databaseURL)
+func GetRollbackable(ctx context.Context, conn *pgx.Conn, migrationsDir string, count int) ([]Migration, error) {
+	applied, err := GetApplied(ctx, conn)
 	if err != nil {
 		return nil, err
 	}
@@ -240,22 +302,123 @@ func GetRollbackable(ctx context.Context, databaseURL, migrationsDir string, cou
 		count = len(applied)
 	}
 
+	return loadDownMigrations(migrationsDir, applied[len(applied)-count:])
+}
+
+// GetRollbackableTo returns migrations newest first, so rolling them all back
+// leaves target as the last applied migration.
+func GetRollbackableTo(ctx context.Context, conn *pgx.Conn, migrationsDir, target string) ([]Migration, error) {
+	applied, err := GetApplied(ctx, conn)
+	if err != nil {
+		return nil, err
+	}
+
+	idx, err := findTarget(applied, target)
+	if err != nil {
+		return nil, err
+	}
+	if idx < 0 {
+		return nil, fmt.Errorf("migration %s has not been applied", target)
+	}
+
+	return loadDownMigrations(migrationsDir, applied[idx+1:])
+}
+
+func GetPendingTo(ctx context.Context, conn *pgx.Conn, migrationsDir, target string) ([]Migration, error) {
+	applied, err := GetApplied(ctx, conn)
+	if err != nil {
+		return nil, err
+	}
+	if idx, err := findTarget(applied, target); err != nil {
+		return nil, err
+	} else if idx >= 0 {
+		return nil, fmt.Errorf("migration %s is already applied; use rollback --to to move back to it", applied[idx].Name)
+	}
+
+	pending, err := GetPending(ctx, conn, migrationsDir)
+	if err != nil {
+		return nil, err
+	}
+
+	idx, err := findTarget(pending, target)
+	if err != nil {
+		return nil, err
+	}
+	if idx < 0 {
+		return nil, fmt.Errorf("migration %s not found in %s", target, migrationsDir)
+	}
+
+	return pending[:idx+1], nil
+}
+
+func CheckDownMigrations(migrationsDir string, migrations []Migration) error {
+	var missing []string
+	for _, m := range migrations {
+		downName := downMigrationName(m.Name)
+		if _, err := os.Stat(filepath.Join(migrationsDir, downName)); err != nil {
+			if !os.IsNotExist(err) {
+				return fmt.Errorf("failed to read down migration %s: %w", downName, err)
+			}
+			missing = append(missing, downName)
+		}
+	}
+	if len(missing) > 0 {
+		return fmt.Errorf("down migration not found: %s", strings.Join(missing, ", "))
+	}
+	return nil
+}
+
+// MatchesTarget accepts the file name, the name without .sql, or the version.
+func MatchesTarget(name, target string) bool {
+	target = strings.TrimSuffix(target, ".sql")
+	return strings.TrimSuffix(name, ".sql") == target || Version(name) == target
+}
+
+func findTarget(migrations []Migration, target string) (int, error) {
+	idx := -1
+	for i, m := range migrations {
+		if !MatchesTarget(m.Name, target) {
+			continue
+		}
+		if idx >= 0 {
+			return -1, fmt.Errorf("migration %s is ambiguous: matches %s and %s", target, migrations[idx].Name, m.Name)
+		}
+		idx = i
+	}
+	return idx, nil
+}
+
+// Every down file is checked before any is read, so a missing one is reported
+// before anything runs.
+func loadDownMigrations(migrationsDir string, applied []Migration) ([]Migration, error) {
+	for _, m := range applied {
+		content, err := os.ReadFile(filepath.Join(migrationsDir, m.Name))
+		if err != nil {
+			continue
+		}
+		if len(Squashes(string(content))) > 0 {
+			return nil, fmt.Errorf("cannot roll back %s: a squashed migration has no down migration; "+
+				"restore the migrations it replaces from the archive to roll back past it", m.Name)
+		}
+	}
+	if err := CheckDownMigrations(migrationsDir, applied); err != nil {
+		return nil, err
+	}
+
 	var rollbackable []Migration
-	for i := len(applied) - 1; i >= len(applied)-count; i-- {
+	for i := len(applied) - 1; i >= 0; i-- {
 		m := applied[i]
-		downName := strings.TrimSuffix(m.Name, ".sql") + ".down.sql"
+		downName := downMigrationName(m.Name)
 		downPath := filepath.Join(migrationsDir, downName)
 
 		content, err := os.ReadFile(downPath)
 		if err != nil {
-			if os.IsNotExist(err) {
-				return nil, fmt.Errorf("down migration not found: %s", downName)
-			}
 			return nil, fmt.Errorf("failed to read down migration %s: %w", downName, err)
 		}
 
 		rollbackable = append(rollbackable, Migration{
 			Name:    m.Name,
+			Path:    downPath,
 			Content: string(content),
 		})
 	}
@@ -263,28 +426,34 @@ func GetRollbackable(ctx context.Context, databaseURL, migrationsDir string, cou
 	return rollbackable, nil
 }
 
-func Rollback(ctx context.Context, databaseURL string, m Migration) error {
-	conn, err := pgx.Connect(ctx, databaseURL)
-	if err != nil {
-		return fmt.Errorf("failed to connect to database: %w", err)
-	}
-	defer func() { _ = conn.Close(ctx) }()
+func Rollback(ctx context.Context, conn *pgx.Conn, m Migration) error {
+	return RollbackWithProgress(ctx, conn, m, nil)
+}
 
-	tx, err := conn.Begin(ctx)
-	if err != nil {
-		return fmt.Errorf("failed to begin transaction: %w", err)
+// The rollback is recorded as a history row, which marks the migration as no
+// longer applied.
+func RollbackWithProgress(ctx context.Context, conn *pgx.Conn, m Migration, progress StatementProgress) error {
+	if err := EnsureMigrationsTable(ctx, conn); err != nil {
+		return fmt.Errorf("failed to ensure migrations table: %w", err)
 	}
-	defer func() { _ = tx.Rollback(ctx) }()
 
-	if _, err := tx.Exec(ctx, m.Content); err != nil {
-		return fmt.Errorf("failed to execute rollback: %w", err)
+	start := time.Now()
+	record := func(tx pgx.Tx) error {
+		return recordRolledBack(ctx, tx, m.Name, ComputeChecksum(m.Content), time.Since(start))
+	}
+	if IsNoTransaction(m.Content) {
+		return runStatements(ctx, conn, downMigrationName(m.Name), m.file(), m.Content, timeouts, progress, record)
 	}
+	return runInTransaction(ctx, conn, m.file(), m.Content, timeouts, record)
+}
 
-	if _, err := tx.Exec(ctx, fmt.Sprintf(`
-		DELETE FROM %s WHERE name = $1
-	`, migrationsTable), m.Name); err != nil {
-		return fmt.Errorf("failed to remove migration record: %w", err)
+func recordRolledBack(ctx context.Context, tx pgx.Tx, name, checksum string, duration time.Duration) error {
+	if err := recordHistory(ctx, tx, name, ActionRollback, checksum, duration); err != nil {
+		return fmt.Errorf("failed to record rollback: %w", err)
 	}
+	return nil
+}
 
-	return tx.Commit(ctx)
+func downMigrationName(name string) string {
+	return strings.TrimSuffix(name, ".sql") + ".down.sql"
 }
@@ -4,6 +4,7 @@ import (
 	"context"
 	"os"
 	"path/filepath"
+	"strings"
 	"testing"
 	"time"
 
@@ -68,7 +69,13 @@ func TestEnsureMigrationsTable_Integration(t *testing.T) {
 	}
 	defer func() { _ = docker.StopContainer(context.Background(), container.ID) }()
 
-	applied, err := GetApplied(ctx, container.ConnectionString())
+	conn, err := Connect(ctx, container.ConnectionString())
+	if err != nil {
+		t.Fatalf("Connect() error = %v", err)
+	}
+	defer func() { _ = conn.Close(context.Background()) }()
+
+	applied, err := GetApplied(ctx, conn)
 	if err != nil {
 		t.Fatalf("GetApplied() error = %v", err)
 	}
@@ -76,6 +83,24 @@ func TestEnsureMigrationsTable_Integration(t *testing.T) {
 	if len(applied) != 0 {
 		t.Errorf("expected 0 applied migrations, got %d", len(applied))
 	}
+
+	var exists bool
+	if err := conn.QueryRow(ctx, `SELECT to_regclass('shrugged_migrations') IS NOT NULL`).Scan(&exists); err != nil {
+		t.Fatalf("failed to check for history table: %v", err)
+	}
+	if exists {
+		t.Error("GetApplied() should not create the history table")
+	}
+
+	if err := EnsureMigrationsTable(ctx, conn); err != nil {
+		t.Fatalf("EnsureMigrationsTable() error = %v", err)
+	}
+	if err := conn.QueryRow(ctx, `SELECT to_regclass('shrugged_migrations') IS NOT NULL`).Scan(&exists); err != nil {
+		t.Fatalf("failed to check for history table: %v", err)
+	}
+	if !exists {
+		t.Error("EnsureMigrationsTable() should create the history table")
+	}
 }
 
 func TestApplyAndGetApplied_Integration(t *testing.T) {
@@ -93,18 +118,22 @@ func TestApplyAndGetApplied_Integration(t *testing.T) {
 	}
 	defer func() { _ = docker.StopContainer(context.Background(), container.ID) }()
 
-	dbURL := container.ConnectionString()
+	conn, err := Connect(ctx, container.ConnectionString())
+	if err != nil {
+		t.Fatalf("Connect() error = %v", err)
+	}
+	defer func() { _ = conn.Close(context.Background()) }()
 
 	migration := Migration{
 		Name:    "001_create_users.sql",
 		Content: "CREATE TABLE users (id SERIAL PRIMARY KEY, name TEXT);",
 	}
 
-	if err := Apply(ctx, dbURL, migration); err != nil {
+	if err := Apply(ctx, conn, migration); err != nil {
 		t.Fatalf("Apply() error = %v", err)
 	}
 
-	applied, err := GetApplied(ctx, dbURL)
+	applied, err := GetApplied(ctx, conn)
 	if err != nil {
 		t.Fatalf("GetApplied() error = %v", err)
 	}
@@ -137,7 +166,11 @@ func TestGetPending_Integration(t *testing.T) {
 	}
 	defer func() { _ = docker.StopContainer(context.Background(), container.ID) }()
 
-	dbURL := container.ConnectionString()
+	conn, err := Connect(ctx, container.ConnectionString())
+	if err != nil {
+		t.Fatalf("Connect() error = %v", err)
+	}
+	defer func() { _ = conn.Close(context.Background()) }()
 
 	tmpDir, err := os.MkdirTemp("", "migrate_test")
 	if err != nil {
@@ -160,7 +193,7 @@ func TestGetPending_Integration(t *testing.T) {
 		}
 	}
 
-	pending, err := GetPending(ctx, dbURL, tmpDir)
+	pending, err := GetPending(ctx, conn, tmpDir)
 	if err != nil {
 		t.Fatalf("GetPending() error = %v", err)
 	}
@@ -176,11 +209,11 @@ func TestGetPending_Integration(t *testing.T) {
 		t.Errorf("second pending = %q, want %q", pending[1].Name, "002_create_posts.sql")
 	}
 
-	if err := Apply(ctx, dbURL, pending[0]); err != nil {
+	if err := Apply(ctx, conn, pending[0]); err != nil {
 		t.Fatalf("Apply() error = %v", err)
 	}
 
-	pending, err = GetPending(ctx, dbURL, tmpDir)
+	pending, err = GetPending(ctx, conn, tmpDir)
 	if err != nil {
 		t.Fatalf("GetPending() after apply error = %v", err)
 	}
@@ -205,7 +238,11 @@ func TestGetPending_SkipsDownMigrations(t *testing.T) {
 	}
 	defer func() { _ = docker.StopContainer(context.Background(), container.ID) }()
 
-	dbURL := container.ConnectionString()
+	conn, err := Connect(ctx, container.ConnectionString())
+	if err != nil {
+		t.Fatalf("Connect() error = %v", err)
+	}
+	defer func() { _ = conn.Close(context.Background()) }()
 
 	tmpDir, err := os.MkdirTemp("", "migrate_test")
 	if err != nil {
@@ -227,7 +264,7 @@ func TestGetPending_SkipsDownMigrations(t *testing.T) {
 		}
 	}
 
-	pending, err := GetPending(ctx, dbURL, tmpDir)
+	pending, err := GetPending(ctx, conn, tmpDir)
 	if err != nil {
 		t.Fatalf("GetPending() error = %v", err)
 	}
@@ -256,9 +293,13 @@ func TestGetLastApplied_Integration(t *testing.T) {
 	}
 	defer func() { _ = docker.StopContainer(context.Background(), container.ID) }()
 
-	dbURL := container.ConnectionString()
+	conn, err := Connect(ctx, container.ConnectionString())
+	if err != nil {
+		t.Fatalf("Connect() error = %v", err)
+	}
+	defer func() { _ = conn.Close(context.Background()) }()
 
-	last, err := GetLastApplied(ctx, dbURL)
+	last, err := GetLastApplied(ctx, conn)
 	if err != nil {
 		t.Fatalf("GetLastApplied() error = %v", err)
 	}
@@ -272,12 +313,12 @@ func TestGetLastApplied_Integration(t *testing.T) {
 	}
 
 	for _, m := range migrations {
-		if err := Apply(ctx, dbURL, m); err != nil {
+		if err := Apply(ctx, conn, m); err != nil {
 			t.Fatalf("Apply() error = %v", err)
 		}
 	}
 
-	last, err = GetLastApplied(ctx, dbURL)
+	last, err = GetLastApplied(ctx, conn)
 	if err != nil {
 		t.Fatalf("GetLastApplied() error = %v", err)
 	}
@@ -306,18 +347,22 @@ func TestRollback_Integration(t *testing.T) {
 	}
 	defer func() { _ = docker.StopContainer(context.Background(), container.ID) }()
 
-	dbURL := container.ConnectionString()
+	conn, err := Connect(ctx, container.ConnectionString())
+	if err != nil {
+		t.Fatalf("Connect() error = %v", err)
+	}
+	defer func() { _ = conn.Close(context.Background()) }()
 
 	migration := Migration{
 		Name:    "001_create_rollback_test.sql",
 		Content: "CREATE TABLE rollback_test (id SERIAL PRIMARY KEY);",
 	}
 
-	if err := Apply(ctx, dbURL, migration); err != nil {
+	if err := Apply(ctx, conn, migration); err != nil {
 		t.Fatalf("Apply() error = %v", err)
 	}
 
-	applied, err := GetApplied(ctx, dbURL)
+	applied, err := GetApplied(ctx, conn)
 	if err != nil {
 		t.Fatalf("GetApplied() error = %v", err)
 	}
@@ -330,11 +375,11 @@ func TestRollback_Integration(t *testing.T) {
 		Content: "DROP TABLE rollback_test;",
 	}
 
-	if err := Rollback(ctx, dbURL, rollbackMigration); err != nil {
+	if err := Rollback(ctx, conn, rollbackMigration); err != nil {
 		t.Fatalf("Rollback() error = %v", err)
 	}
 
-	applied, err = GetApplied(ctx, dbURL)
+	applied, err = GetApplied(ctx, conn)
 	if err != nil {
 		t.Fatalf("GetApplied() after rollback error = %v", err)
 	}
@@ -358,7 +403,11 @@ func TestGetRollbackable_Integration(t *testing.T) {
 	}
 	defer func() { _ = docker.StopContainer(context.Background(), container.ID) }()
 
-	dbURL := container.ConnectionString()
+	conn, err := Connect(ctx, container.ConnectionString())
+	if err != nil {
+		t.Fatalf("Connect() error = %v", err)
+	}
+	defer func() { _ = conn.Close(context.Background()) }()
 
 	tmpDir, err := os.MkdirTemp("", "migrate_test")
 	if err != nil {
@@ -388,12 +437,12 @@ func TestGetRollbackable_Integration(t *testing.T) {
 	}
 
 	for _, m := range migrations {
-		if err := Apply(ctx, dbURL, m); err != nil {
+		if err := Apply(ctx, conn, m); err != nil {
 			t.Fatalf("Apply() error = %v", err)
 		}
 	}
 
-	rollbackable, err := GetRollbackable(ctx, dbURL, tmpDir, 1)
+	rollbackable, err := GetRollbackable(ctx, conn, tmpDir, 1)
 	if err != nil {
 		t.Fatalf("GetRollbackable() error = %v", err)
 	}
@@ -410,7 +459,7 @@ func TestGetRollbackable_Integration(t *testing.T) {
 		t.Errorf("rollbackable content = %q, want down migration content", rollbackable[0].Content)
 	}
 
-	rollbackable, err = GetRollbackable(ctx, dbURL, tmpDir, 2)
+	rollbackable, err = GetRollbackable(ctx, conn, tmpDir, 2)
 	if err != nil {
 		t.Fatalf("GetRollbackable(2) error = %v", err)
 	}
@@ -442,7 +491,11 @@ func TestGetRollbackable_MissingDownMigration(t *testing.T) {
 	}
 	defer func() { _ = docker.StopContainer(context.Background(), container.ID) }()
 
-	dbURL := container.ConnectionString()
+	conn, err := Connect(ctx, container.ConnectionString())
+	if err != nil {
+		t.Fatalf("Connect() error = %v", err)
+	}
+	defer func() { _ = conn.Close(context.Background()) }()
 
 	tmpDir, err := os.MkdirTemp("", "migrate_test")
 	if err != nil {
@@ -459,11 +512,11 @@ func TestGetRollbackable_MissingDownMigration(t *testing.T) {
 		Content: "CREATE TABLE test (id INT);",
 	}
 
-	if err := Apply(ctx, dbURL, migration); err != nil {
+	if err := Apply(ctx, conn, migration); err != nil {
 		t.Fatalf("Apply() error = %v", err)
 	}
 
-	_, err = GetRollbackable(ctx, dbURL, tmpDir, 1)
+	_, err = GetRollbackable(ctx, conn, tmpDir, 1)
 	if err == nil {
 		t.Error("expected error for missing down migration")
 	}
@@ -484,7 +537,11 @@ func TestHasModifiedMigrations_Integration(t *testing.T) {
 	}
 	defer func() { _ = docker.StopContainer(context.Background(), container.ID) }()
 
-	dbURL := container.ConnectionString()
+	conn, err := Connect(ctx, container.ConnectionString())
+	if err != nil {
+		t.Fatalf("Connect() error = %v", err)
+	}
+	defer func() { _ = conn.Close(context.Background()) }()
 
 	tmpDir, err := os.MkdirTemp("", "migrate_test")
 	if err != nil {
@@ -502,11 +559,11 @@ func TestHasModifiedMigrations_Integration(t *testing.T) {
 		Content: originalContent,
 	}
 
-	if err := Apply(ctx, dbURL, migration); err != nil {
+	if err := Apply(ctx, conn, migration); err != nil {
 		t.Fatalf("Apply() error = %v", err)
 	}
 
-	modified, err := HasModifiedMigrations(ctx, dbURL, tmpDir)
+	modified, err := HasModifiedMigrations(ctx, conn, tmpDir)
 	if err != nil {
 		t.Fatalf("HasModifiedMigrations() error = %v", err)
 	}
@@ -519,7 +576,7 @@ func TestHasModifiedMigrations_Integration(t *testing.T) {
 		t.Fatalf("failed to write modified file: %v", err)
 	}
 
-	modified, err = HasModifiedMigrations(ctx, dbURL, tmpDir)
+	modified, err = HasModifiedMigrations(ctx, conn, tmpDir)
 	if err != nil {
 		t.Fatalf("HasModifiedMigrations() after modification error = %v", err)
 	}
@@ -527,3 +584,305 @@ func TestHasModifiedMigrations_Integration(t *testing.T) {
 		t.Errorf("expected 1 modified migration, got %d", len(modified))
 	}
 }
+
+func TestMatchesTarget(t *testing.T) {
+	tests := []struct {
+		name   string
+		target string
+		want   bool
+	}{
+		{"20251216205122.sql", "20251216205122", true},
+		{"20251216205122.sql", "20251216205122.sql", true},
+		{"20251216205122_add_invoice_status.sql", "20251216205122", true},
+		{"20251216205122_add_invoice_status.sql", "20251216205122_add_invoice_status", true},
+		{"20251216205122.sql", "2025121620512", false},
+		{"20251216205122_add_invoice_status.sql", "20251216205122_add", false},
+	}
+
+	for _, tt := range tests {
+		if got := MatchesTarget(tt.name, tt.target); got != tt.want {
+			t.Errorf("MatchesTarget(%q, %q) = %v, want %v", tt.name, tt.target, got, tt.want)
+		}
+	}
+}
+
+func TestCheckDownMigrations(t *testing.T) {
+	tmpDir, err := os.MkdirTemp("", "migrate_test")
+	if err != nil {
+		t.Fatalf("failed to create temp dir: %v", err)
+	}
+	defer func() { _ = os.RemoveAll(tmpDir) }()
+
+	if err := os.WriteFile(filepath.Join(tmpDir, "001_first.down.sql"), []byte("DROP TABLE first;"), 0644); err != nil {
+		t.Fatalf("failed to write file: %v", err)
+	}
+
+	migrations := []Migration{{Name: "001_first.sql"}, {Name: "002_second.sql"}, {Name: "003_third.sql"}}
+
+	if err := CheckDownMigrations(tmpDir, migrations[:1]); err != nil {
+		t.Errorf("CheckDownMigrations() error = %v", err)
+	}
+
+	err = CheckDownMigrations(tmpDir, migrations)
+	if err == nil {
+		t.Fatal("expected error for missing down migrations")
+	}
+	if !strings.Contains(err.Error(), "002_second.down.sql, 003_third.down.sql") {
+		t.Errorf("error should list every missing down migration, got: %v", err)
+	}
+}
+
+func TestLoadDownMigrations_RefusesSquashed(t *testing.T) {
+	tmpDir, err := os.MkdirTemp("", "migrate_test")
+	if err != nil {
+		t.Fatalf("failed to create temp dir: %v", err)
+	}
+	defer func() { _ = os.RemoveAll(tmpDir) }()
+
+	content := "-- shrugged:squashes 0001_first.sql\nCREATE TABLE first (id int);"
+	if err := os.WriteFile(filepath.Join(tmpDir, "0001.1_squashed.sql"), []byte(content), 0644); err != nil {
+		t.Fatalf("failed to write file: %v", err)
+	}
+
+	_, err = loadDownMigrations(tmpDir, []Migration{{Name: "0001.1_squashed.sql"}})
+	if err == nil || !strings.Contains(err.Error(), "cannot roll back 0001.1_squashed.sql") {
+		t.Errorf("loadDownMigrations() error = %v, want refusal to roll back the squashed migration", err)
+	}
+}
+
+func TestMigrateTo_Integration(t *testing.T) {
+	if testing.Short() {
+		t.Skip("skipping integration test")
+	}
+
+	ctx, cancel := context.WithTimeout(context.Background(), 60*time.Second)
+	defer cancel()
+
+	cfg := docker.DefaultPostgresConfig()
+	container, err := docker.StartPostgres(ctx, cfg)
+	if err != nil {
+		t.Fatalf("StartPostgres() error = %v", err)
+	}
+	defer func() { _ = docker.StopContainer(context.Background(), container.ID) }()
+
+	conn, err := Connect(ctx, container.ConnectionString())
+	if err != nil {
+		t.Fatalf("Connect() error = %v", err)
+	}
+	defer func() { _ = conn.Close(context.Background()) }()
+
+	tmpDir, err := os.MkdirTemp("", "migrate_test")
+	if err != nil {
+		t.Fatalf("failed to create temp dir: %v", err)
+	}
+	defer func() { _ = os.RemoveAll(tmpDir) }()
+
+	files := map[string]string{
+		"001_first.sql":       "CREATE TABLE first (id INT);",
+		"001_first.down.sql":  "DROP TABLE first;",
+		"002_second.sql":      "CREATE TABLE second (id INT);",
+		"002_second.down.sql": "DROP TABLE second;",
+		"003_third.sql":       "CREATE TABLE third (id INT);",
+	}
+	for name, content := range files {
+		if err := os.WriteFile(filepath.Join(tmpDir, name), []byte(content), 0644); err != nil {
+			t.Fatalf("failed to write file: %v", err)
+		}
+	}
+
+	pending, err := GetPendingTo(ctx, conn, tmpDir, "002")
+	if err != nil {
+		t.Fatalf("GetPendingTo() error = %v", err)
+	}
+	if len(pending) != 2 || pending[1].Name != "002_second.sql" {
+		t.Fatalf("GetPendingTo() = %v, want 001_first.sql and 002_second.sql", pending)
+	}
+
+	if _, err := GetPendingTo(ctx, conn, tmpDir, "004"); err == nil {
+		t.Error("expected error for unknown target")
+	}
+
+	for _, m := range pending {
+		if err := Apply(ctx, conn, m); err != nil {
+			t.Fatalf("Apply() error = %v", err)
+		}
+	}
+
+	if _, err := GetPendingTo(ctx, conn, tmpDir, "001_first"); err == nil {
+		t.Error("expected error for already applied target")
+	}
+
+	rollbackable, err := GetRollbackableTo(ctx, conn, tmpDir, "001")
+	if err != nil {
+		t.Fatalf("GetRollbackableTo() error = %v", err)
+	}
+	if len(rollbackable) != 1 || rollbackable[0].Name != "002_second.sql" {
+		t.Fatalf("GetRollbackableTo() = %v, want 002_second.sql", rollbackable)
+	}
+
+	pending, err = GetPending(ctx, conn, tmpDir)
+	if err != nil {
+		t.Fatalf("GetPending() error = %v", err)
+	}
+	if err := Apply(ctx, conn, pending[0]); err != nil {
+		t.Fatalf("Apply() error = %v", err)
+	}
+
+	if _, err := GetRollbackableTo(ctx, conn, tmpDir, "001"); err == nil {
+		t.Error("expected error for missing down migration of 003_third.sql")
+	}
+
+	rollbackable, err = GetRollbackableTo(ctx, conn, tmpDir, "003_third.sql")
+	if err != nil {
+		t.Fatalf("GetRollbackableTo() error = %v", err)
+	}
+	if len(rollbackable) != 0 {
+		t.Errorf("GetRollbackableTo(last applied) = %v, want nothing", rollbackable)
+	}
+}
+
+func TestParseOutOfOrderPolicy(t *testing.T) {
+	tests := []struct {
+		input   string
+		want    OutOfOrderPolicy
+		wantErr bool
+	}{
+		{"error", OutOfOrderError, false},
+		{"warn", OutOfOrderWarn, false},
+		{"ALLOW", OutOfOrderAllow, false},
+		{"", "", true},
+		{"ignore", "", true},
+	}
+
+	for _, tt := range tests {
+		got, err := ParseOutOfOrderPolicy(tt.input)
+		if (err != nil) != tt.wantErr {
+			t.Errorf("ParseOutOfOrderPolicy(%q) error = %v, wantErr %v", tt.input, err, tt.wantErr)
+		}
+		if got != tt.want {
+			t.Errorf("ParseOutOfOrderPolicy(%q) = %q, want %q", tt.input, got, tt.want)
+		}
+	}
+}
+
+func TestGetPending_OutOfOrder_Integration(t *testing.T) {
+	if testing.Short() {
+		t.Skip("skipping integration test")
+	}
+
+	ctx, cancel := context.WithTimeout(context.Background(), 60*time.Second)
+	defer cancel()
+
+	cfg := docker.DefaultPostgresConfig()
+	container, err := docker.StartPostgres(ctx, cfg)
+	if err != nil {
+		t.Fatalf("StartPostgres() error = %v", err)
+	}
+	defer func() { _ = docker.StopContainer(context.Background(), container.ID) }()
+
+	conn, err := Connect(ctx, container.ConnectionString())
+	if err != nil {
+		t.Fatalf("Connect() error = %v", err)
+	}
+	defer func() { _ = conn.Close(context.Background()) }()
+
+	tmpDir, err := os.MkdirTemp("", "migrate_test")
+	if err != nil {
+		t.Fatalf("failed to create temp dir: %v", err)
+	}
+	defer func() { _ = os.RemoveAll(tmpDir) }()
+
+	files := map[string]string{
+		"001_first.sql":  "CREATE TABLE first (id INT);",
+		"002_branch.sql": "CREATE TABLE branch (id INT);",
+		"003_third.sql":  "CREATE TABLE third (id INT);",
+		"004_fourth.sql": "CREATE TABLE fourth (id INT);",
+	}
+	for name, content := range files {
+		if err := os.WriteFile(filepath.Join(tmpDir, name), []byte(content), 0644); err != nil {
+			t.Fatalf("failed to write file: %v", err)
+		}
+	}
+
+	for _, name := range []string{"001_first.sql", "003_third.sql"} {
+		if err := Apply(ctx, conn, Migration{Name: name, Content: files[name]}); err != nil {
+			t.Fatalf("Apply(%s) error = %v", name, err)
+		}
+	}
+
+	pending, err := GetPending(ctx, conn, tmpDir)
+	if err != nil {
+		t.Fatalf("GetPending() error = %v", err)
+	}
+	if len(pending) != 2 {
+		t.Fatalf("expected 2 pending migrations, got %d", len(pending))
+	}
+	if pending[0].Name != "002_branch.sql" || !pending[0].OutOfOrder {
+		t.Errorf("pending[0] = %s (out of order %v), want 002_branch.sql out of order", pending[0].Name, pending[0].OutOfOrder)
+	}
+	if pending[1].Name != "004_fourth.sql" || pending[1].OutOfOrder {
+		t.Errorf("pending[1] = %s (out of order %v), want 004_fourth.sql in order", pending[1].Name, pending[1].OutOfOrder)
+	}
+}
+
+func TestSequentialNames_Integration(t *testing.T) {
+	if testing.Short() {
+		t.Skip("skipping integration test")
+	}
+
+	ctx, cancel := context.WithTimeout(context.Background(), 60*time.Second)
+	defer cancel()
+
+	cfg := docker.DefaultPostgresConfig()
+	container, err := docker.StartPostgres(ctx, cfg)
+	if err != nil {
+		t.Fatalf("StartPostgres() error = %v", err)
+	}
+	defer func() { _ = docker.StopContainer(context.Background(), container.ID) }()
+
+	conn, err := Connect(ctx, container.ConnectionString())
+	if err != nil {
+		t.Fatalf("Connect() error = %v", err)
+	}
+	defer func() { _ = conn.Close(context.Background()) }()
+
+	tmpDir, err := os.MkdirTemp("", "migrate_test")
+	if err != nil {
+		t.Fatalf("failed to create temp dir: %v", err)
+	}
+	defer func() { _ = os.RemoveAll(tmpDir) }()
+
+	files := map[string]string{
+		"9999_before.sql":      "CREATE TABLE before (id INT);",
+		"9999_before.down.sql": "DROP TABLE before;",
+		"10000_later.sql":      "CREATE TABLE later (id INT);",
+		"10000_later.down.sql": "DROP TABLE later;",
+	}
+	for name, content := range files {
+		if err := os.WriteFile(filepath.Join(tmpDir, name), []byte(content), 0644); err != nil {
+			t.Fatalf("failed to write file: %v", err)
+		}
+	}
+
+	pending, err := GetPending(ctx, conn, tmpDir)
+	if err != nil {
+		t.Fatalf("GetPending() error = %v", err)
+	}
+	if len(pending) != 2 || pending[0].Name != "9999_before.sql" || pending[1].Name != "10000_later.sql" {
+		t.Fatalf("GetPending() = %v, want 9999_before.sql then 10000_later.sql", pending)
+	}
+
+	for _, m := range pending {
+		if err := Apply(ctx, conn, m); err != nil {
+			t.Fatalf("Apply() error = %v", err)
+		}
+	}
+
+	rollbackable, err := GetRollbackable(ctx, conn, tmpDir, 1)
+	if err != nil {
+		t.Fatalf("GetRollbackable() error = %v", err)
+	}
+	if len(rollbackable) != 1 || rollbackable[0].Name != "10000_later.sql" {
+		t.Errorf("GetRollbackable() = %v, want 10000_later.sql", rollbackable)
+	}
+}
@@ -0,0 +1,157 @@
+package migrate
+
+import (
+	"fmt"
+	"os"
+	"strconv"
+	"strings"
+	"time"
+)
+
+type Naming string
+
+const (
+	NamingTimestamp  Naming = "timestamp"
+	NamingSequential Naming = "sequential"
+)
+
+const sequentialWidth = 4
+
+const timestampWidth = len("20060102150405")
+
+func ParseNaming(s string) (Naming, error) {
+	switch n := Naming(strings.ToLower(s)); n {
+	case NamingTimestamp, NamingSequential:
+		return n, nil
+	}
+	return "", fmt.Errorf("invalid migration naming %q (want timestamp or sequential)", s)
+}
+
+func NextName(migrationsDir string, naming Naming, description string, now time.Time) (string, error) {
+	var version string
+	switch naming {
+	case NamingSequential:
+		next, err := nextSequence(migrationsDir)
+		if err != nil {
+			return "", err
+		}
+		version = fmt.Sprintf("%0*d", sequentialWidth, next)
+	default:
+		version = now.UTC().Format("20060102150405")
+	}
+
+	if slug := Slugify(description); slug != "" {
+		return version + "_" + slug, nil
+	}
+	return version, nil
+}
+
+func Slugify(description string) string {
+	var sb strings.Builder
+	pendingSep := false
+	for _, r := range strings.ToLower(description) {
+		if (r >= 'a' && r <= 'z') || (r >= '0' && r <= '9') {
+			if pendingSep && sb.Len() > 0 {
+				sb.WriteByte('_')
+			}
+			sb.WriteRune(r)
+			pendingSep = false
+		} else {
+			pendingSep = true
+		}
+	}
+	return sb.String()
+}
+
+func Version(name string) string {
+	base := strings.TrimSuffix(strings.TrimSuffix(name, ".sql"), ".down")
+	version, _, _ := strings.Cut(base, "_")
+	return version
+}
+
+// CompareNames compares numeric versions by value, so sequential numbers stay
+// in order once they outgrow their zero padding. A sub-version such as 0002.1,
+// given to squashed migrations and to changes split out to run outside a
+// transaction, sorts right after 0002.
+func CompareNames(a, b string) int {
+	va, vb := Version(a), Version(b)
+	ia, fa, _ := strings.Cut(va, ".")
+	ib, fb, _ := strings.Cut(vb, ".")
+	if isNumeric(ia) && isNumeric(ib) && (fa == "" || isNumeric(fa)) && (fb == "" || isNumeric(fb)) {
+		if c := compareNumeric(ia, ib); c != 0 {
+			return c
+		}
+		if c := compareNumeric(fa, fb); c != 0 {
+			return c
+		}
+		return strings.Compare(a, b)
+	}
+	if c := strings.Compare(va, vb); c != 0 {
+		return c
+	}
+	return strings.Compare(a, b)
+}
+
+// SubVersion returns the version to give a migration that has to sort right
+// after the one with the given version.
+func SubVersion(version string) string {
+	base, sub, ok := strings.Cut(version, ".")
+	if n, err := strconv.Atoi(sub); ok && err == nil {
+		return fmt.Sprintf("%s.%d", base, n+1)
+	}
+	return version + ".1"
+}
+
+func compareNumeric(a, b string) int {
+	a, b = strings.TrimLeft(a, "0"), strings.TrimLeft(b, "0")
+	if len(a) != len(b) {
+		return len(a) - len(b)
+	}
+	return strings.Compare(a, b)
+}
+
+func nextSequence(migrationsDir string) (int, error) {
+	entries, err := os.ReadDir(migrationsDir)
+	if err != nil {
+		if os.IsNotExist(err) {
+			return 1, nil
+		}
+		return 0, fmt.Errorf("failed to read migrations directory: %w", err)
+	}
+
+	highest := 0
+	for _, entry := range entries {
+		if entry.IsDir() || !strings.HasSuffix(entry.Name(), ".sql") {
+			continue
+		}
+		version, _, _ := strings.Cut(Version(entry.Name()), ".")
+		if !isNumeric(version) {
+			continue
+		}
+		// A sequence number after a timestamp would sort before it.
+		if len(version) >= timestampWidth {
+			return 0, fmt.Errorf("cannot number migrations sequentially: %s has a timestamp version; "+
+				"keep migration_naming set to timestamp for this directory", entry.Name())
+		}
+		n, err := strconv.Atoi(version)
+		if err != nil {
+			continue
+		}
+		if n > highest {
+			highest = n
+		}
+	}
+	return highest + 1, nil
+}
+
+func isNumeric(s string) bool {
+	if s == "" {
+		return false
+	}
+	for _, r := range s {
+		if r < '0' || r > '9' {
+			return false
+		}
+	}
+	return true
+}
@@ -0,0 +1,175 @@
+package migrate
+
+import (
+	"os"
+	"path/filepath"
+	"sort"
+	"strings"
+	"testing"
+	"time"
+)
+
+func TestSlugify(t *testing.T) {
+	tests := []struct {
+		input string
+		want  string
+	}{
+		{"add_invoice_status", "add_invoice_status"},
+		{"Add invoice status", "add_invoice_status"},
+		{"  add--invoice  status!  ", "add_invoice_status"},
+		{"v2 users", "v2_users"},
+		{"", ""},
+		{"---", ""},
+	}
+
+	for _, tt := range tests {
+		if got := Slugify(tt.input); got != tt.want {
+			t.Errorf("Slugify(%q) = %q, want %q", tt.input, got, tt.want)
+		}
+	}
+}
+
+func TestVersion(t *testing.T) {
+	tests := []struct {
+		name string
+		want string
+	}{
+		{"20251216205122.sql", "20251216205122"},
+		{"20251216205122.down.sql", "20251216205122"},
+		{"20251216205122_add_invoice_status.sql", "20251216205122"},
+		{"0001_init.down.sql", "0001"},
+	}
+
+	for _, tt := range tests {
+		if got := Version(tt.name); got != tt.want {
+			t.Errorf("Version(%q) = %q, want %q", tt.name, got, tt.want)
+		}
+	}
+}
+
+func TestCompareNames(t *testing.T) {
+	names := []string{
+		"10000_later.sql",
+		"9999.1_squashed.sql",
+		"0002_second.sql",
+		"9999_before.sql",
+		"0001_first.down.sql",
+		"0001_first.sql",
+	}
+
+	sort.Slice(names, func(i, j int) bool { return CompareNames(names[i], names[j]) < 0 })
+
+	want := []string{
+		"0001_first.down.sql",
+		"0001_first.sql",
+		"0002_second.sql",
+		"9999_before.sql",
+		"9999.1_squashed.sql",
+		"10000_later.sql",
+	}
+	for i := range want {
+		if names[i] != want[i] {
+			t.Errorf("sorted[%d] = %q, want %q", i, names[i], want[i])
+		}
+	}
+}
+
+func TestSubVersion(t *testing.T) {
+	tests := map[string]string{
+		"0002":           "0002.1",
+		"0002.1":         "0002.2",
+		"20250531120000": "20250531120000.1",
+	}
+	for version, want := range tests {
+		if got := SubVersion(version); got != want {
+			t.Errorf("SubVersion(%q) = %q, want %q", version, got, want)
+		}
+	}
+}
+
+func TestNextName_Timestamp(t *testing.T) {
+	now := time.Date(2025, 12, 16, 20, 51, 22, 0, time.UTC)
+
+	got, err := NextName("unused", NamingTimestamp, "Add invoice status", now)
+	if err != nil {
+		t.Fatalf("NextName() error = %v", err)
+	}
+	if got != "20251216205122_add_invoice_status" {
+		t.Errorf("NextName() = %q, want 20251216205122_add_invoice_status", got)
+	}
+
+	got, err = NextName("unused", NamingTimestamp, "", now)
+	if err != nil {
+		t.Fatalf("NextName() error = %v", err)
+	}
+	if got != "20251216205122" {
+		t.Errorf("NextName() without description = %q, want 20251216205122", got)
+	}
+}
+
+func TestNextName_Sequential(t *testing.T) {
+	tmpDir, err := os.MkdirTemp("", "naming_test")
+	if err != nil {
+		t.Fatalf("failed to create temp dir: %v", err)
+	}
+	defer func() { _ = os.RemoveAll(tmpDir) }()
+
+	got, err := NextName(tmpDir, NamingSequential, "init", time.Now())
+	if err != nil {
+		t.Fatalf("NextName() error = %v", err)
+	}
+	if got != "0001_init" {
+		t.Errorf("NextName() in empty dir = %q, want 0001_init", got)
+	}
+
+	for _, name := range []string{"0001_init.sql", "0001_init.down.sql", "0007_users.sql", SumFile} {
+		if err := os.WriteFile(filepath.Join(tmpDir, name), []byte(""), 0644); err != nil {
+			t.Fatalf("failed to write file: %v", err)
+		}
+	}
+
+	got, err = NextName(tmpDir, NamingSequential, "add invoice status", time.Now())
+	if err != nil {
+		t.Fatalf("NextName() error = %v", err)
+	}
+	if got != "0008_add_invoice_status" {
+		t.Errorf("NextName() = %q, want 0008_add_invoice_status", got)
+	}
+
+	if err := os.WriteFile(filepath.Join(tmpDir, "0008.1_users_index.sql"), []byte(""), 0644); err != nil {
+		t.Fatalf("failed to write file: %v", err)
+	}
+	got, err = NextName(tmpDir, NamingSequential, "next", time.Now())
+	if err != nil {
+		t.Fatalf("NextName() error = %v", err)
+	}
+	if got != "0009_next" {
+		t.Errorf("NextName() after a sub-version = %q, want 0009_next", got)
+	}
+}
+
+func TestNextName_SequentialAfterTimestamps(t *testing.T) {
+	tmpDir, err := os.MkdirTemp("", "naming_test")
+	if err != nil {
+		t.Fatalf("failed to create temp dir: %v", err)
+	}
+	defer func() { _ = os.RemoveAll(tmpDir) }()
+
+	if err := os.WriteFile(filepath.Join(tmpDir, "20251216205122_init.sql"), []byte(""), 0644); err != nil {
+		t.Fatalf("failed to write file: %v", err)
+	}
+
+	_, err = NextName(tmpDir, NamingSequential, "users", time.Now())
+	if err == nil || !strings.Contains(err.Error(), "timestamp version") {
+		t.Errorf("NextName() error = %v, want refusal to mix sequential and timestamp versions", err)
+	}
+}
+
+func TestParseNaming(t *testing.T) {
+	if got, err := ParseNaming("Sequential"); err != nil || got != NamingSequential {
+		t.Errorf("ParseNaming(Sequential) = %q, %v", got, err)
+	}
+	if _, err := ParseNaming("random"); err == nil {
+		t.Error("expected error for unknown naming")
+	}
+}
@@ -0,0 +1,161 @@
+package migrate
+
+import (
+	"strings"
+	"unicode/utf8"
+)
+
+// Line and Column are 1-based; Column counts characters, not bytes.
+type Statement struct {
+	SQL    string
+	Offset int
+	Line   int
+	Column int
+}
+
+// SplitStatements ignores semicolons inside literals, quoted identifiers,
+// dollar-quoted bodies, comments and BEGIN ATOMIC bodies.
+func SplitStatements(sql string) []Statement {
+	var statements []Statement
+	start := 0
+	hasCode := false
+	atomicDepth := 0
+	var prevWord string
+
+	flush := func(end int) {
+		if hasCode {
+			text := strings.TrimRight(sql[start:end], " \t\r\n")
+			trimmed := strings.TrimLeft(text, " \t\r\n")
+			offset := start + len(text) - len(trimmed)
+			line, col := position(sql, offset)
+			statements = append(statements, Statement{SQL: trimmed, Offset: offset, Line: line, Column: col})
+		}
+		start = end + 1
+		hasCode = false
+		prevWord = ""
+	}
+
+	for i := 0; i < len(sql); i++ {
+		c := sql[i]
+		switch {
+		case c == '-' && i+1 < len(sql) && sql[i+1] == '-':
+			for i < len(sql) && sql[i] != '\n' {
+				i++
+			}
+
+		case c == '/' && i+1 < len(sql) && sql[i+1] == '*':
+			depth := 0
+			for ; i < len(sql); i++ {
+				if sql[i] == '/' && i+1 < len(sql) && sql[i+1] == '*' {
+					depth++
+					i++
+				} else if sql[i] == '*' && i+1 < len(sql) && sql[i+1] == '/' {
+					depth--
+					i++
+					if depth == 0 {
+						break
+					}
+				}
+			}
+
+		case c == '\'':
+			hasCode = true
+			escapes := i > 0 && (sql[i-1] == 'E' || sql[i-1] == 'e') && (i < 2 || !isIdentChar(sql[i-2]))
+			for i++; i < len(sql); i++ {
+				if escapes && sql[i] == '\\' {
+					i++
+				} else if sql[i] == '\'' {
+					if i+1 < len(sql) && sql[i+1] == '\'' {
+						i++
+					} else {
+						break
+					}
+				}
+			}
+
+		case c == '"':
+			hasCode = true
+			for i++; i < len(sql); i++ {
+				if sql[i] == '"' {
+					if i+1 < len(sql) && sql[i+1] == '"' {
+						i++
+					} else {
+						break
+					}
+				}
+			}
+
+		case c == '$' && (i == 0 || !isIdentChar(sql[i-1])):
+			hasCode = true
+			if tag, ok := dollarTag(sql[i:]); ok {
+				end := strings.Index(sql[i+len(tag):], tag)
+				if end < 0 {
+					i = len(sql)
+				} else {
+					i += len(tag) + end + len(tag) - 1
+				}
+			}
+
+		case c == ';':
+			if atomicDepth == 0 {
+				flush(i)
+			}
+
+		case isIdentStart(c):
+			hasCode = true
+			j := i
+			for j < len(sql) && isIdentChar(sql[j]) {
+				j++
+			}
+			word := strings.ToLower(sql[i:j])
+			switch {
+			case word == "atomic" && prevWord == "begin":
+				atomicDepth++
+			case atomicDepth > 0 && word == "case":
+				atomicDepth++
+			case atomicDepth > 0 && word == "end":
+				atomicDepth--
+			}
+			prevWord = word
+			i = j - 1
+
+		case c != ' ' && c != '\t' && c != '\n' && c != '\r':
+			hasCode = true
+		}
+	}
+	if start < len(sql) {
+		flush(len(sql))
+	}
+
+	return statements
+}
+
+func position(sql string, offset int) (int, int) {
+	if offset > len(sql) {
+		offset = len(sql)
+	}
+	before := sql[:offset]
+	line := strings.Count(before, "\n") + 1
+	lineStart := strings.LastIndex(before, "\n") + 1
+	return line, utf8.RuneCountInString(before[lineStart:]) + 1
+}
+
+func dollarTag(s string) (string, bool) {
+	for j := 1; j < len(s); j++ {
+		if s[j] == '$' {
+			return s[:j+1], true
+		}
+		if !isIdentChar(s[j]) || (j == 1 && s[j] >= '0' && s[j] <= '9') {
+			return "", false
+		}
+	}
+	return "", false
+}
+
+func isIdentStart(c byte) bool {
+	return c == '_' || (c >= 'a' && c <= 'z') || (c >= 'A' && c <= 'Z') || c >= 0x80
+}
+
+func isIdentChar(c byte) bool {
+	return isIdentStart(c) || (c >= '0' && c <= '9') || c == '$'
+}
@@ -0,0 +1,180 @@
+package migrate
+
+import (
+	"context"
+	"fmt"
+	"os"
+	"path/filepath"
+	"regexp"
+	"strings"
+
+	"github.com/jackc/pgx/v5"
+)
+
+const SquashDirective = "-- shrugged:squashes"
+
+var squashRegex = regexp.MustCompile(`(?m)^\s*--\s*shrugged:squashes\s+(\S+)\s*$`)
+
+func Squashes(content string) []string {
+	var names []string
+	for _, m := range squashRegex.FindAllStringSubmatch(content, -1) {
+		names = append(names, m[1])
+	}
+	return names
+}
+
+var dataStatementRegex = regexp.MustCompile(`(?is)^(?:(?:INSERT|UPDATE|DELETE|MERGE|COPY|TRUNCATE)\b|WITH\b.*\b(?:INSERT|UPDATE|DELETE|MERGE)\b)`)
+
+func DataStatements(content string) []Statement {
+	var found []Statement
+	for _, stmt := range SplitStatements(content) {
+		code, lines := skipComments(stmt.SQL)
+		if !dataStatementRegex.MatchString(code) {
+			continue
+		}
+		if lines > 0 {
+			stmt.Line += lines
+			stmt.Column = 1
+		}
+		stmt.SQL = code
+		found = append(found, stmt)
+	}
+	return found
+}
+
+func skipComments(sql string) (string, int) {
+	lines := 0
+	for {
+		trimmed := strings.TrimLeft(sql, " \t\r\n")
+		lines += strings.Count(sql[:len(sql)-len(trimmed)], "\n")
+		sql = trimmed
+		switch {
+		case strings.HasPrefix(sql, "--"):
+			end := strings.Index(sql, "\n")
+			if end < 0 {
+				return "", lines
+			}
+			sql = sql[end:]
+		case strings.HasPrefix(sql, "/*"):
+			end := strings.Index(sql, "*/")
+			if end < 0 {
+				return "", lines
+			}
+			lines += strings.Count(sql[:end], "\n")
+			sql = sql[end+2:]
+		default:
+			return sql, lines
+		}
+	}
+}
+
+// A database that applied only some of the migrations a squashed migration
+// replaces can't use it at all.
+func squashApplied(name string, replaced []string, applied map[string]Migration) (bool, error) {
+	var latest string
+	var count int
+	for _, r := range replaced {
+		if _, ok := applied[r]; ok {
+			count++
+		}
+		if latest == "" || CompareNames(r, latest) > 0 {
+			latest = r
+		}
+	}
+
+	if count == 0 {
+		return false, nil
+	}
+	if _, ok := applied[latest]; !ok {
+		return false, fmt.Errorf("%s squashes migrations this database has only partly applied (up to before %s); "+
+			"apply the rest of them from the archive before applying %s", name, latest, name)
+	}
+	return true, nil
+}
+
+func loadSquashes(migrationsDir string) (map[string]string, error) {
+	entries, err := os.ReadDir(migrationsDir)
+	if err != nil {
+		if os.IsNotExist(err) {
+			return nil, nil
+		}
+		return nil, fmt.Errorf("failed to read migrations directory: %w", err)
+	}
+
+	squashes := make(map[string]string)
+	for _, entry := range entries {
+		name := entry.Name()
+		if entry.IsDir() || !strings.HasSuffix(name, ".sql") || strings.HasSuffix(name, ".down.sql") {
+			continue
+		}
+		content, err := os.ReadFile(filepath.Join(migrationsDir, name))
+		if err != nil {
+			return nil, fmt.Errorf("failed to read migration %s: %w", name, err)
+		}
+		for _, replaced := range Squashes(string(content)) {
+			squashes[replaced] = name
+		}
+	}
+	return squashes, nil
+}
+
+// GetSquashed returns the squashed migrations to record as applied, without
+// running them, since the database applied what they replace.
+func GetSquashed(ctx context.Context, conn *pgx.Conn, migrationsDir string) ([]Migration, error) {
+	applied, err := GetApplied(ctx, conn)
+	if err != nil {
+		return nil, err
+	}
+	appliedMap := make(map[string]Migration)
+	for _, m := range applied {
+		appliedMap[m.Name] = m
+	}
+
+	entries, err := os.ReadDir(migrationsDir)
+	if err != nil {
+		if os.IsNotExist(err) {
+			return nil, nil
+		}
+		return nil, fmt.Errorf("failed to read migrations directory: %w", err)
+	}
+
+	var squashed []Migration
+	for _, entry := range entries {
+		name := entry.Name()
+		if entry.IsDir() || !strings.HasSuffix(name, ".sql") || strings.HasSuffix(name, ".down.sql") {
+			continue
+		}
+		if _, ok := appliedMap[name]; ok {
+			continue
+		}
+
+		content, err := os.ReadFile(filepath.Join(migrationsDir, name))
+		if err != nil {
+			return nil, fmt.Errorf("failed to read migration %s: %w", name, err)
+		}
+		replaced := Squashes(string(content))
+		if len(replaced) == 0 {
+			continue
+		}
+
+		ok, err := squashApplied(name, replaced, appliedMap)
+		if err != nil {
+			return nil, err
+		}
+		if ok {
+			squashed = append(squashed, Migration{Name: name, Path: filepath.Join(migrationsDir, name), Content: string(content)})
+		}
+	}
+
+	sortMigrations(squashed)
+	return squashed, nil
+}
+
+func RecordSquashed(ctx context.Context, conn *pgx.Conn, migrations []Migration) error {
+	for _, m := range migrations {
+		if err := Baseline(ctx, conn, m); err != nil {
+			return err
+		}
+	}
+	return nil
+}
@@ -0,0 +1,185 @@
+package migrate
+
+import (
+	"context"
+	"os"
+	"path/filepath"
+	"reflect"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/terminally-online/shrugged/internal/docker"
+)
+
+func TestSquashes(t *testing.T) {
+	content := `-- Squashed from 2 migrations before 0003.
+-- shrugged:squashes 0001_create_users.sql
+-- shrugged:squashes 0002_create_posts.sql
+
+CREATE TABLE users (id int);
+`
+	want := []string{"0001_create_users.sql", "0002_create_posts.sql"}
+	if got := Squashes(content); !reflect.DeepEqual(got, want) {
+		t.Errorf("Squashes() = %v, want %v", got, want)
+	}
+	if got := Squashes("CREATE TABLE users (id int);"); got != nil {
+		t.Errorf("Squashes() = %v, want none", got)
+	}
+}
+
+func TestDataStatements(t *testing.T) {
+	content := `CREATE TABLE roles (id int, name text);
+-- seed the default roles
+INSERT INTO roles VALUES (1, 'admin');
+UPDATE roles SET name = 'owner' WHERE id = 1;
+CREATE FUNCTION touch() RETURNS trigger AS $$
+BEGIN
+  UPDATE roles SET name = name;
+  RETURN NEW;
+END;
+$$ LANGUAGE plpgsql;
+WITH moved AS (DELETE FROM roles RETURNING *) SELECT count(*) FROM moved;
+/* keep */ CREATE INDEX roles_name_idx ON roles (name);
+`
+
+	got := DataStatements(content)
+	wantLines := []int{3, 4, 11}
+	if len(got) != len(wantLines) {
+		t.Fatalf("DataStatements() = %+v, want statements on lines %v", got, wantLines)
+	}
+	for i, line := range wantLines {
+		if got[i].Line != line {
+			t.Errorf("DataStatements()[%d].Line = %d, want %d", i, got[i].Line, line)
+		}
+	}
+	if !strings.HasPrefix(got[0].SQL, "INSERT INTO roles") {
+		t.Errorf("DataStatements()[0].SQL = %q, want it to start at INSERT", got[0].SQL)
+	}
+}
+
+func TestSquashApplied(t *testing.T) {
+	replaced := []string{"0001_a.sql", "0002_b.sql", "0010_c.sql"}
+
+	tests := []struct {
+		name    string
+		applied []string
+		want    bool
+		wantErr bool
+	}{
+		{name: "new database"},
+		{name: "all applied", applied: []string{"0001_a.sql", "0002_b.sql", "0010_c.sql"}, want: true},
+		{name: "partly applied", applied: []string{"0001_a.sql", "0002_b.sql"}, wantErr: true},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			applied := make(map[string]Migration)
+			for _, name := range tt.applied {
+				applied[name] = Migration{Name: name}
+			}
+
+			got, err := squashApplied("0010_squashed.sql", replaced, applied)
+			if (err != nil) != tt.wantErr {
+				t.Fatalf("squashApplied() error = %v, wantErr %v", err, tt.wantErr)
+			}
+			if got != tt.want {
+				t.Errorf("squashApplied() = %v, want %v", got, tt.want)
+			}
+		})
+	}
+}
+
+func TestSquash_Integration(t *testing.T) {
+	if testing.Short() {
+		t.Skip("skipping integration test")
+	}
+
+	ctx, cancel := context.WithTimeout(context.Background(), 60*time.Second)
+	defer cancel()
+
+	cfg := docker.DefaultPostgresConfig()
+	container, err := docker.StartPostgres(ctx, cfg)
+	if err != nil {
+		t.Fatalf("StartPostgres() error = %v", err)
+	}
+	defer func() { _ = docker.StopContainer(context.Background(), container.ID) }()
+
+	conn, err := Connect(ctx, container.ConnectionString())
+	if err != nil {
+		t.Fatalf("Connect() error = %v", err)
+	}
+	defer func() { _ = conn.Close(context.Background()) }()
+
+	tmpDir, err := os.MkdirTemp("", "migrate_test")
+	if err != nil {
+		t.Fatalf("failed to create temp dir: %v", err)
+	}
+	defer func() { _ = os.RemoveAll(tmpDir) }()
+
+	write := func(name, content string) {
+		if err := os.WriteFile(filepath.Join(tmpDir, name), []byte(content), 0644); err != nil {
+			t.Fatalf("failed to write migration file: %v", err)
+		}
+	}
+	write("0001_create_users.sql", "CREATE TABLE users (id int);")
+	write("0002_create_posts.sql", "CREATE TABLE posts (id int);")
+
+	pending, err := GetPending(ctx, conn, tmpDir)
+	if err != nil {
+		t.Fatalf("GetPending() error = %v", err)
+	}
+	for _, m := range pending {
+		if err := Apply(ctx, conn, m); err != nil {
+			t.Fatalf("Apply(%s) error = %v", m.Name, err)
+		}
+	}
+
+	for _, name := range []string{"0001_create_users.sql", "0002_create_posts.sql"} {
+		if err := os.Remove(filepath.Join(tmpDir, name)); err != nil {
+			t.Fatalf("failed to remove migration file: %v", err)
+		}
+	}
+	write("0002_squashed.sql", `-- shrugged:squashes 0001_create_users.sql
+-- shrugged:squashes 0002_create_posts.sql
+CREATE TABLE users (id int);
+CREATE TABLE posts (id int);
+`)
+	write("0003_create_tags.sql", "CREATE TABLE tags (id int);")
+
+	pending, err = GetPending(ctx, conn, tmpDir)
+	if err != nil {
+		t.Fatalf("GetPending() error = %v", err)
+	}
+	if len(pending) != 1 || pending[0].Name != "0003_create_tags.sql" {
+		t.Fatalf("GetPending() = %v, want only 0003_create_tags.sql", pending)
+	}
+
+	modified, err := HasModifiedMigrations(ctx, conn, tmpDir)
+	if err != nil {
+		t.Fatalf("HasModifiedMigrations() error = %v", err)
+	}
+	if len(modified) != 0 {
+		t.Errorf("squashed migrations reported as modified: %v", modified)
+	}
+
+	squashed, err := GetSquashed(ctx, conn, tmpDir)
+	if err != nil {
+		t.Fatalf("GetSquashed() error = %v", err)
+	}
+	if len(squashed) != 1 || squashed[0].Name != "0002_squashed.sql" {
+		t.Fatalf("GetSquashed() = %v, want 0002_squashed.sql", squashed)
+	}
+
+	if err := RecordSquashed(ctx, conn, squashed); err != nil {
+		t.Fatalf("RecordSquashed() error = %v", err)
+	}
+
+	squashed, err = GetSquashed(ctx, conn, tmpDir)
+	if err != nil {
+		t.Fatalf("GetSquashed() after recording error = %v", err)
+	}
+	if len(squashed) != 0 {
+		t.Errorf("GetSquashed() after recording = %v, want none", squashed)
+	}
+}
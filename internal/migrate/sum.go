@@ -39,7 +39,9 @@ func GenerateSum(migrationsDir string) ([]SumEntry, error) {
 		sqlFiles = append(sqlFiles, name)
 	}
 
-	sort.Strings(sqlFiles)
+	sort.SliceStable(sqlFiles, func(i, j int) bool {
+		return CompareNames(sqlFiles[i], sqlFiles[j]) < 0
+	})
 
 	var sumEntries []SumEntry
 	for _, name := range sqlFiles {
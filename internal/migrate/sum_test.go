@@ -273,3 +273,30 @@ func TestGenerateSum_SortsFiles(t *testing.T) {
 		}
 	}
 }
+
+func TestGenerateSum_SortsByVersion(t *testing.T) {
+	tmpDir, err := os.MkdirTemp("", "sum_test")
+	if err != nil {
+		t.Fatalf("failed to create temp dir: %v", err)
+	}
+	defer func() { _ = os.RemoveAll(tmpDir) }()
+
+	files := []string{"10000_later.sql", "9999_before.sql", "20251216205122_add_invoice_status.sql"}
+	for _, name := range files {
+		if err := os.WriteFile(filepath.Join(tmpDir, name), []byte("SELECT 1;"), 0644); err != nil {
+			t.Fatalf("failed to write file: %v", err)
+		}
+	}
+
+	entries, err := GenerateSum(tmpDir)
+	if err != nil {
+		t.Fatalf("GenerateSum() error = %v", err)
+	}
+
+	expected := []string{"9999_before.sql", "10000_later.sql", "20251216205122_add_invoice_status.sql"}
+	for i, e := range entries {
+		if e.Name != expected[i] {
+			t.Errorf("entry %d = %q, want %q", i, e.Name, expected[i])
+		}
+	}
+}
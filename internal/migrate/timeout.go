@@ -0,0 +1,102 @@
+package migrate
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"time"
+
+	"github.com/jackc/pgx/v5/pgconn"
+)
+
+const lockNotAvailable = "55P03"
+
+// A zero timeout leaves the server's setting in place.
+type Timeouts struct {
+	Lock      time.Duration
+	Statement time.Duration
+}
+
+var timeouts Timeouts
+
+// The timeouts only cover migration statements: they are set locally in each
+// migration transaction, and around each statement of a no-transaction
+// migration, so lock polling and history writes run without them.
+func SetTimeouts(t Timeouts) {
+	timeouts = t
+}
+
+func (t Timeouts) settings() [][2]string {
+	var settings [][2]string
+	for _, s := range []struct {
+		name  string
+		value time.Duration
+	}{
+		{"lock_timeout", t.Lock},
+		{"statement_timeout", t.Statement},
+	} {
+		if s.value > 0 {
+			settings = append(settings, [2]string{s.name, fmt.Sprintf("%dms", s.value.Milliseconds())})
+		}
+	}
+	return settings
+}
+
+func setTimeouts(ctx context.Context, exec execer, t Timeouts, local bool) error {
+	for _, s := range t.settings() {
+		if _, err := exec.Exec(ctx, "SELECT set_config($1, $2, $3)", s[0], s[1], local); err != nil {
+			return fmt.Errorf("failed to set %s: %w", s[0], err)
+		}
+	}
+	return nil
+}
+
+// resetTimeouts restores the values the session started with. In a
+// transaction it only undoes setTimeouts for the rest of it.
+func resetTimeouts(ctx context.Context, exec execer, t Timeouts, local bool) error {
+	for _, s := range t.settings() {
+		sql := "RESET " + s[0]
+		if local {
+			sql = "SET LOCAL " + s[0] + " TO DEFAULT"
+		}
+		if _, err := exec.Exec(ctx, sql); err != nil {
+			return fmt.Errorf("failed to reset %s: %w", s[0], err)
+		}
+	}
+	return nil
+}
+
+func IsLockTimeout(err error) bool {
+	var pgErr *pgconn.PgError
+	return errors.As(err, &pgErr) && pgErr.Code == lockNotAvailable
+}
+
+type RetryOptions struct {
+	Retries int
+	// Backoff doubles for each retry after the first.
+	Backoff time.Duration
+	OnRetry func(retry, retries int, wait time.Duration, err error)
+}
+
+// A failed transactional migration has been rolled back, and a failed
+// no-transaction migration resumes at the statement that timed out, so
+// RetryOnLockTimeout can run fn again as is.
+func RetryOnLockTimeout(ctx context.Context, opts RetryOptions, fn func() error) error {
+	wait := opts.Backoff
+	for retry := 1; ; retry++ {
+		err := fn()
+		if err == nil || retry > opts.Retries || !IsLockTimeout(err) {
+			return err
+		}
+
+		if opts.OnRetry != nil {
+			opts.OnRetry(retry, opts.Retries, wait, err)
+		}
+		select {
+		case <-ctx.Done():
+			return ctx.Err()
+		case <-time.After(wait):
+		}
+		wait *= 2
+	}
+}
@@ -0,0 +1,168 @@
+package migrate
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"testing"
+	"time"
+
+	"github.com/jackc/pgx/v5/pgconn"
+
+	"github.com/terminally-online/shrugged/internal/docker"
+)
+
+func TestIsLockTimeout(t *testing.T) {
+	lockErr := &pgconn.PgError{Code: "55P03", Message: "canceling statement due to lock timeout"}
+
+	if !IsLockTimeout(lockErr) {
+		t.Error("IsLockTimeout(lock_not_available) = false, want true")
+	}
+	if !IsLockTimeout(fmt.Errorf("statement 1 of 2 failed: %w", &StatementError{Err: lockErr})) {
+		t.Error("IsLockTimeout(wrapped) = false, want true")
+	}
+	if IsLockTimeout(&pgconn.PgError{Code: "57014"}) {
+		t.Error("IsLockTimeout(query_canceled) = true, want false")
+	}
+	if IsLockTimeout(errors.New("boom")) {
+		t.Error("IsLockTimeout(other) = true, want false")
+	}
+}
+
+func TestTimeoutsSettings(t *testing.T) {
+	got := Timeouts{Lock: 2 * time.Second}.settings()
+	if len(got) != 1 || got[0] != [2]string{"lock_timeout", "2000ms"} {
+		t.Errorf("settings() = %v, want only lock_timeout 2000ms", got)
+	}
+	if got := (Timeouts{}).settings(); len(got) != 0 {
+		t.Errorf("settings() = %v, want none for zero timeouts", got)
+	}
+}
+
+func TestRetryOnLockTimeout(t *testing.T) {
+	lockErr := &pgconn.PgError{Code: "55P03"}
+
+	var waits []time.Duration
+	calls := 0
+	opts := RetryOptions{
+		Retries: 3,
+		Backoff: time.Millisecond,
+		OnRetry: func(retry, retries int, wait time.Duration, err error) {
+			waits = append(waits, wait)
+		},
+	}
+
+	err := RetryOnLockTimeout(context.Background(), opts, func() error {
+		calls++
+		if calls < 3 {
+			return lockErr
+		}
+		return nil
+	})
+	if err != nil {
+		t.Fatalf("RetryOnLockTimeout() error = %v", err)
+	}
+	if calls != 3 {
+		t.Errorf("calls = %d, want 3", calls)
+	}
+	if len(waits) != 2 || waits[0] != time.Millisecond || waits[1] != 2*time.Millisecond {
+		t.Errorf("waits = %v, want [1ms 2ms]", waits)
+	}
+
+	calls = 0
+	err = RetryOnLockTimeout(context.Background(), opts, func() error {
+		calls++
+		return lockErr
+	})
+	if !IsLockTimeout(err) {
+		t.Errorf("RetryOnLockTimeout() error = %v, want lock timeout", err)
+	}
+	if calls != 4 {
+		t.Errorf("calls = %d, want 4", calls)
+	}
+
+	calls = 0
+	err = RetryOnLockTimeout(context.Background(), opts, func() error {
+		calls++
+		return errors.New("syntax error")
+	})
+	if err == nil || calls != 1 {
+		t.Errorf("other errors should not be retried: calls = %d, err = %v", calls, err)
+	}
+}
+
+func TestSetTimeouts_Integration(t *testing.T) {
+	if testing.Short() {
+		t.Skip("skipping integration test")
+	}
+
+	ctx, cancel := context.WithTimeout(context.Background(), 60*time.Second)
+	defer cancel()
+
+	cfg := docker.DefaultPostgresConfig()
+	container, err := docker.StartPostgres(ctx, cfg)
+	if err != nil {
+		t.Fatalf("StartPostgres() error = %v", err)
+	}
+	defer func() { _ = docker.StopContainer(context.Background(), container.ID) }()
+
+	holder, err := Connect(ctx, container.ConnectionString())
+	if err != nil {
+		t.Fatalf("Connect() error = %v", err)
+	}
+	defer func() { _ = holder.Close(context.Background()) }()
+
+	conn, err := Connect(ctx, container.ConnectionString())
+	if err != nil {
+		t.Fatalf("Connect() error = %v", err)
+	}
+	defer func() { _ = conn.Close(context.Background()) }()
+
+	if _, err := holder.Exec(ctx, "CREATE TABLE hot (id INT)"); err != nil {
+		t.Fatalf("failed to create table: %v", err)
+	}
+
+	SetTimeouts(Timeouts{Lock: 100 * time.Millisecond, Statement: 5 * time.Second})
+	defer SetTimeouts(Timeouts{})
+
+	if err := Apply(ctx, conn, Migration{Name: "000_noop.sql", Content: "SELECT 1;"}); err != nil {
+		t.Fatalf("Apply() error = %v", err)
+	}
+	var lockTimeout string
+	if err := conn.QueryRow(ctx, "SHOW lock_timeout").Scan(&lockTimeout); err != nil {
+		t.Fatalf("failed to show lock_timeout: %v", err)
+	}
+	if lockTimeout != "0" {
+		t.Errorf("lock_timeout = %q after a migration, want the session default 0", lockTimeout)
+	}
+
+	tx, err := holder.Begin(ctx)
+	if err != nil {
+		t.Fatalf("failed to begin: %v", err)
+	}
+	if _, err := tx.Exec(ctx, "LOCK TABLE hot IN ACCESS EXCLUSIVE MODE"); err != nil {
+		t.Fatalf("failed to lock table: %v", err)
+	}
+
+	retries := 0
+	opts := RetryOptions{
+		Retries: 2,
+		Backoff: 10 * time.Millisecond,
+		OnRetry: func(int, int, time.Duration, error) { retries++ },
+	}
+	err = RetryOnLockTimeout(ctx, opts, func() error {
+		return Apply(ctx, conn, Migration{Name: "001_alter.sql", Content: "ALTER TABLE hot ADD COLUMN name TEXT;"})
+	})
+	if !IsLockTimeout(err) {
+		t.Fatalf("Apply() error = %v, want lock timeout", err)
+	}
+	if retries != 2 {
+		t.Errorf("retries = %d, want 2", retries)
+	}
+
+	_ = tx.Rollback(ctx)
+
+	if err := Apply(ctx, conn, Migration{Name: "001_alter.sql", Content: "ALTER TABLE hot ADD COLUMN name TEXT;"}); err != nil {
+		t.Fatalf("Apply() after lock released error = %v", err)
+	}
+}
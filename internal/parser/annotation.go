@@ -0,0 +1,148 @@
+package parser
+
+import (
+	"bufio"
+	"regexp"
+	"strings"
+)
+
+// Rename records that a table, column or enum value in the schema file used
+// to be known by another name. Column is empty for table renames; Enum and
+// Value are set instead of Table for enum value renames.
+type Rename struct {
+	Schema string
+	Table  string
+	Column string
+	Enum   string
+	Value  string
+	From   string
+}
+
+var (
+	renamedFromRegex = regexp.MustCompile(`--\s*shrugged:renamed-from\s+([\w."]+|'(?:[^']|'')*')`)
+	createTableRegex = regexp.MustCompile(`(?i)^\s*CREATE\s+(?:(?:UNLOGGED|TEMP|TEMPORARY)\s+)?TABLE\s+(?:IF\s+NOT\s+EXISTS\s+)?([\w."]+)`)
+	createEnumRegex  = regexp.MustCompile(`(?i)^\s*CREATE\s+TYPE\s+([\w."]+)\s+AS\s+ENUM\b`)
+	columnNameRegex  = regexp.MustCompile(`^\s*("(?:[^"]|"")+"|\w+)`)
+	enumValueRegex   = regexp.MustCompile(`^\s*'((?:[^']|'')*)'`)
+)
+
+var tableElementKeywords = map[string]bool{
+	"constraint": true, "primary": true, "foreign": true, "unique": true,
+	"check": true, "exclude": true, "like": true,
+}
+
+// ParseRenames collects `-- shrugged:renamed-from old_name` annotations from a
+// schema file. An annotation placed on or directly above a CREATE TABLE line
+// renames the table; one placed on or directly above a column definition
+// renames that column, and one on or above a value of a CREATE TYPE ... AS
+// ENUM renames that value.
+func ParseRenames(sql string) []Rename {
+	var renames []Rename
+	var pending string
+	var schema, table, enum string
+	depth := 0
+
+	scanner := bufio.NewScanner(strings.NewReader(sql))
+	for scanner.Scan() {
+		line := scanner.Text()
+		code, comment := line, ""
+		if idx := strings.Index(line, "--"); idx >= 0 {
+			code, comment = line[:idx], line[idx:]
+		}
+
+		from := pending
+		if matches := renamedFromRegex.FindStringSubmatch(comment); matches != nil {
+			from = matches[1]
+		}
+
+		if strings.TrimSpace(code) == "" {
+			pending = from
+			continue
+		}
+		pending = ""
+
+		if depth == 0 {
+			if matches := createTableRegex.FindStringSubmatch(code); matches != nil {
+				schema, table = splitQualifiedIdent(matches[1])
+				enum = ""
+				if from != "" {
+					renames = append(renames, Rename{Schema: schema, Table: table, From: lastIdentPart(from)})
+				}
+				depth = parenDelta(code)
+			} else if matches := createEnumRegex.FindStringSubmatch(code); matches != nil {
+				schema, enum = splitQualifiedIdent(matches[1])
+				table = ""
+				depth = parenDelta(code)
+			}
+			continue
+		}
+
+		if depth == 1 && from != "" && enum != "" {
+			if matches := enumValueRegex.FindStringSubmatch(code); matches != nil {
+				renames = append(renames, Rename{Schema: schema, Enum: enum, Value: unquoteLiteral(matches[1]), From: enumValueName(from)})
+			}
+		} else if depth == 1 && from != "" {
+			if matches := columnNameRegex.FindStringSubmatch(code); matches != nil {
+				name := unquoteIdent(matches[1])
+				if !tableElementKeywords[strings.ToLower(name)] {
+					renames = append(renames, Rename{Schema: schema, Table: table, Column: name, From: lastIdentPart(from)})
+				}
+			}
+		}
+		depth += parenDelta(code)
+		if depth < 0 {
+			depth = 0
+		}
+	}
+
+	return renames
+}
+
+func parenDelta(code string) int {
+	delta := 0
+	inQuote := false
+	for _, r := range code {
+		switch {
+		case r == '\'':
+			inQuote = !inQuote
+		case inQuote:
+		case r == '(':
+			delta++
+		case r == ')':
+			delta--
+		}
+	}
+	return delta
+}
+
+func splitQualifiedIdent(ident string) (string, string) {
+	if idx := strings.LastIndex(ident, "."); idx >= 0 {
+		return unquoteIdent(ident[:idx]), unquoteIdent(ident[idx+1:])
+	}
+	return "", unquoteIdent(ident)
+}
+
+func lastIdentPart(ident string) string {
+	_, name := splitQualifiedIdent(ident)
+	return name
+}
+
+func unquoteIdent(ident string) string {
+	if len(ident) >= 2 && strings.HasPrefix(ident, `"`) && strings.HasSuffix(ident, `"`) {
+		return strings.ReplaceAll(ident[1:len(ident)-1], `""`, `"`)
+	}
+	return strings.ToLower(ident)
+}
+
+func unquoteLiteral(s string) string {
+	return strings.ReplaceAll(s, "''", "'")
+}
+
+// enumValueName reads the old name of an enum value, which may be written
+// quoted as a literal or bare.
+func enumValueName(s string) string {
+	if len(s) >= 2 && strings.HasPrefix(s, "'") && strings.HasSuffix(s, "'") {
+		return unquoteLiteral(s[1 : len(s)-1])
+	}
+	return s
+}
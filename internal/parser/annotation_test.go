@@ -0,0 +1,76 @@
+package parser
+
+import (
+	"reflect"
+	"testing"
+)
+
+func TestParseRenames(t *testing.T) {
+	sql := `CREATE TABLE accounts ( -- shrugged:renamed-from users
+    id serial PRIMARY KEY,
+    email_address text NOT NULL, -- shrugged:renamed-from email
+    -- shrugged:renamed-from created
+    created_at timestamptz DEFAULT now(),
+    CONSTRAINT accounts_email_check CHECK (email_address <> '')
+);
+
+-- shrugged:renamed-from old_posts
+CREATE TABLE app."Posts" (
+    id serial PRIMARY KEY,
+    "Title" text -- shrugged:renamed-from "Name"
+);
+
+CREATE TABLE untouched (
+    id integer -- just a comment
+);
+`
+
+	got := ParseRenames(sql)
+	want := []Rename{
+		{Table: "accounts", From: "users"},
+		{Table: "accounts", Column: "email_address", From: "email"},
+		{Table: "accounts", Column: "created_at", From: "created"},
+		{Schema: "app", Table: "Posts", From: "old_posts"},
+		{Schema: "app", Table: "Posts", Column: "Title", From: "Name"},
+	}
+
+	if !reflect.DeepEqual(got, want) {
+		t.Errorf("ParseRenames() = %+v, want %+v", got, want)
+	}
+}
+
+func TestParseRenames_IgnoresTableConstraints(t *testing.T) {
+	sql := `CREATE TABLE users (
+    id integer,
+    -- shrugged:renamed-from users_pk
+    CONSTRAINT users_pkey PRIMARY KEY (id)
+);`
+
+	if got := ParseRenames(sql); len(got) != 0 {
+		t.Errorf("ParseRenames() = %+v, want none", got)
+	}
+}
+
+func TestParseRenames_EnumValues(t *testing.T) {
+	sql := `CREATE TYPE app.status AS ENUM (
+    'pending',
+    'done', -- shrugged:renamed-from 'complete'
+    -- shrugged:renamed-from Archived
+    'it''s archived'
+);
+
+CREATE TABLE orders (
+    status app.status -- shrugged:renamed-from state
+);`
+
+	got := ParseRenames(sql)
+	want := []Rename{
+		{Schema: "app", Enum: "status", Value: "done", From: "complete"},
+		{Schema: "app", Enum: "status", Value: "it's archived", From: "Archived"},
+		{Table: "orders", Column: "status", From: "state"},
+	}
+
+	if !reflect.DeepEqual(got, want) {
+		t.Errorf("ParseRenames() = %+v, want %+v", got, want)
+	}
+}
@@ -36,6 +36,7 @@ type Schema struct {
 	Roles               []Role
 	RoleGrants          []RoleGrant
 	DefaultPrivileges   []DefaultPrivilege
+	Dependencies        []Dependency
 }
 
 type Table struct {
@@ -47,6 +48,14 @@ type Table struct {
 	PartitionKey   string
 	PartitionOf    string
 	PartitionBound string
+
+	RowSecurity          bool
+	ForceRowSecurity     bool
+	Unlogged             bool
+	StorageParams        []string
+	ReplicaIdentity      string
+	ReplicaIdentityIndex string
+	Inherits             []string
 }
 
 type Column struct {
@@ -56,6 +65,9 @@ type Column struct {
 	Default    string
 	PrimaryKey bool
 
+	// TypeSchema is the schema of a user-defined Type, when known.
+	TypeSchema string
+
 	Identity          string
 	IdentityStart     int64
 	IdentityIncrement int64
@@ -68,6 +80,11 @@ type Column struct {
 	GeneratedType string
 
 	NotNullConstraintName string
+
+	Collation   string
+	Storage     string
+	Compression string
+	Statistics  *int
 }
 
 type Constraint struct {
@@ -89,6 +106,8 @@ type Constraint struct {
 
 	NotEnforced bool
 	NotValid    bool
+
+	Definition string
 }
 
 type Index struct {
@@ -109,13 +128,14 @@ type View struct {
 }
 
 type Function struct {
-	Schema     string
-	Name       string
-	Args       string
-	Returns    string
-	Language   string
-	Body       string
-	Definition string
+	Schema       string
+	Name         string
+	Args         string
+	IdentityArgs string
+	Returns      string
+	Language     string
+	Body         string
+	Definition   string
 }
 
 type Trigger struct {
@@ -154,14 +174,15 @@ type Extension struct {
 }
 
 type Domain struct {
-	Schema     string
-	Name       string
-	Type       string
-	Default    string
-	NotNull    bool
-	Check      string
-	Collation  string
-	Definition string
+	Schema      string
+	Name        string
+	Type        string
+	Default     string
+	NotNull     bool
+	Check       string
+	Constraints []Constraint
+	Collation   string
+	Definition  string
 }
 
 type CompositeType struct {
@@ -292,12 +313,13 @@ type Namespace struct {
 }
 
 type Procedure struct {
-	Schema     string
-	Name       string
-	Args       string
-	Language   string
-	Body       string
-	Definition string
+	Schema       string
+	Name         string
+	Args         string
+	IdentityArgs string
+	Language     string
+	Body         string
+	Definition   string
 }
 
 type EventTrigger struct {
@@ -317,6 +339,18 @@ type Comment struct {
 	Comment    string
 }
 
+// Dependency records that one object references another, as tracked by
+// pg_depend. RefColumn is set when the reference is to a single table column.
+type Dependency struct {
+	Kind      string
+	Schema    string
+	Name      string
+	RefKind   string
+	RefSchema string
+	RefName   string
+	RefColumn string
+}
+
 type Role struct {
 	Name            string
 	SuperUser       bool
@@ -399,6 +433,9 @@ func (s *Schema) ToSQL() string {
 		if d.Check != "" {
 			sql += fmt.Sprintf(" %s", d.Check)
 		}
+		for _, c := range d.Constraints {
+			sql += fmt.Sprintf(" CONSTRAINT %s %s", quoteIdent(c.Name), c.Definition)
+		}
 		sb.WriteString(sql + ";\n\n")
 	}
 
@@ -445,6 +482,13 @@ func (s *Schema) ToSQL() string {
 		sb.WriteString("\n\n")
 	}
 
+	for _, t := range s.Tables {
+		if t.ReplicaIdentity != "" {
+			sb.WriteString(ReplicaIdentitySQL(t, quoteIdent))
+			sb.WriteString("\n\n")
+		}
+	}
+
 	for _, v := range s.Views {
 		sb.WriteString(fmt.Sprintf("CREATE VIEW %s AS %s;\n\n",
 			qualifiedName(v.Schema, v.Name), v.Definition))
@@ -627,10 +671,14 @@ func quoteLiteral(s string) string {
 }
 
 func qualifiedName(schema, name string) string {
+	return qualifiedNameWith(quoteIdent, schema, name)
+}
+
+func qualifiedNameWith(quote func(string) string, schema, name string) string {
 	if schema == "" || schema == "public" {
-		return quoteIdent(name)
+		return quote(name)
 	}
-	return fmt.Sprintf("%s.%s", quoteIdent(schema), quoteIdent(name))
+	return fmt.Sprintf("%s.%s", quote(schema), quote(name))
 }
 
 func sortTablesByDependency(tables []Table) []Table {
@@ -664,6 +712,15 @@ func sortTablesByDependency(tables []Table) []Table {
 				}
 			}
 		}
+		for _, parent := range t.Inherits {
+			refKey := parent
+			if !strings.Contains(refKey, ".") && t.Schema != "" && t.Schema != "public" {
+				refKey = t.Schema + "." + parent
+			}
+			if _, inSet := tableMap[refKey]; inSet {
+				deps[key] = append(deps[key], refKey)
+			}
+		}
 	}
 
 	var sorted []Table
@@ -714,10 +771,14 @@ func generateCreateTable(t Table) string {
 		return sb.String()
 	}
 
-	sb.WriteString(fmt.Sprintf("CREATE TABLE %s (\n", qualifiedName(t.Schema, t.Name)))
+	if t.Unlogged {
+		sb.WriteString(fmt.Sprintf("CREATE UNLOGGED TABLE %s (\n", qualifiedName(t.Schema, t.Name)))
+	} else {
+		sb.WriteString(fmt.Sprintf("CREATE TABLE %s (\n", qualifiedName(t.Schema, t.Name)))
+	}
 
 	for i, col := range t.Columns {
-		sb.WriteString(fmt.Sprintf("    %s %s", quoteIdent(col.Name), col.Type))
+		sb.WriteString(fmt.Sprintf("    %s %s", quoteIdent(col.Name), ColumnType(col)))
 
 		if col.Identity != "" {
 			sb.WriteString(fmt.Sprintf(" GENERATED %s AS IDENTITY", col.Identity))
@@ -828,6 +889,10 @@ func generateCreateTable(t Table) string {
 			}
 
 		case "EXCLUSION":
+			if constraint.Definition != "" {
+				sb.WriteString(fmt.Sprintf("    CONSTRAINT %s %s", quoteIdent(constraint.Name), constraint.Definition))
+				break
+			}
 			using := constraint.ExclusionUsing
 			if using == "" {
 				using = "gist"
@@ -856,14 +921,99 @@ func generateCreateTable(t Table) string {
 
 	sb.WriteString(")")
 
+	if len(t.Inherits) > 0 {
+		sb.WriteString(fmt.Sprintf(" INHERITS (%s)", strings.Join(QuoteTableRefs(t.Inherits, quoteIdent), ", ")))
+	}
 	if t.PartitionBy != "" {
 		sb.WriteString(fmt.Sprintf(" PARTITION BY %s (%s)", t.PartitionBy, t.PartitionKey))
 	}
+	if len(t.StorageParams) > 0 {
+		sb.WriteString(fmt.Sprintf(" WITH (%s)", strings.Join(t.StorageParams, ", ")))
+	}
 
 	sb.WriteString(";")
+	sb.WriteString(RowSecuritySQL(t, quoteIdent))
+	for _, col := range t.Columns {
+		for _, stmt := range ColumnSettingsSQL(qualifiedName(t.Schema, t.Name), col, quoteIdent) {
+			sb.WriteString("\n" + stmt)
+		}
+	}
 	return sb.String()
 }
 
+func ColumnType(col Column) string {
+	colType := col.Type
+	if col.Compression != "" {
+		colType += " COMPRESSION " + col.Compression
+	}
+	if col.Collation != "" {
+		colType += " COLLATE " + col.Collation
+	}
+	return colType
+}
+
+// CollatedColumnType is for ALTER COLUMN TYPE, which takes a collation but
+// not a compression.
+func CollatedColumnType(col Column) string {
+	if col.Collation != "" {
+		return col.Type + " COLLATE " + col.Collation
+	}
+	return col.Type
+}
+
+// Storage and statistics can only be set after the column exists. This and
+// the table helpers below take a quote function because diff only quotes
+// identifiers that need it.
+func ColumnSettingsSQL(table string, col Column, quote func(string) string) []string {
+	var stmts []string
+	if col.Storage != "" {
+		stmts = append(stmts, fmt.Sprintf("ALTER TABLE %s ALTER COLUMN %s SET STORAGE %s;",
+			table, quote(col.Name), col.Storage))
+	}
+	if col.Statistics != nil {
+		stmts = append(stmts, fmt.Sprintf("ALTER TABLE %s ALTER COLUMN %s SET STATISTICS %d;",
+			table, quote(col.Name), *col.Statistics))
+	}
+	return stmts
+}
+
+func RowSecuritySQL(t Table, quote func(string) string) string {
+	var sb strings.Builder
+	if t.RowSecurity {
+		sb.WriteString(fmt.Sprintf("\nALTER TABLE %s ENABLE ROW LEVEL SECURITY;", qualifiedNameWith(quote, t.Schema, t.Name)))
+	}
+	if t.ForceRowSecurity {
+		sb.WriteString(fmt.Sprintf("\nALTER TABLE %s FORCE ROW LEVEL SECURITY;", qualifiedNameWith(quote, t.Schema, t.Name)))
+	}
+	return sb.String()
+}
+
+func ReplicaIdentitySQL(t Table, quote func(string) string) string {
+	identity := t.ReplicaIdentity
+	switch identity {
+	case "":
+		identity = "DEFAULT"
+	case "INDEX":
+		identity = fmt.Sprintf("USING INDEX %s", quote(t.ReplicaIdentityIndex))
+	}
+	return fmt.Sprintf("ALTER TABLE %s REPLICA IDENTITY %s;", qualifiedNameWith(quote, t.Schema, t.Name), identity)
+}
+
+func QuoteTableRef(ref string, quote func(string) string) string {
+	if strings.Contains(ref, ".") {
+		return ref
+	}
+	return quote(ref)
+}
+
+func QuoteTableRefs(refs []string, quote func(string) string) []string {
+	quoted := make([]string, len(refs))
+	for i, ref := range refs {
+		quoted[i] = QuoteTableRef(ref, quote)
+	}
+	return quoted
+}
+
 func generateCreateIndex(i Index) string {
 	var sb strings.Builder
 